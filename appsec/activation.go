@@ -0,0 +1,333 @@
+package appsec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Activation actions
+const (
+	// ActionActivate activates the versions of an activation request
+	ActionActivate = "ACTIVATE"
+	// ActionDeactivate deactivates the versions of an activation request
+	ActionDeactivate = "DEACTIVATE"
+)
+
+// Activation statuses reported by the activation endpoints
+const (
+	// StatusReceived is reported while the activation is being propagated
+	StatusReceived = "RECEIVED"
+	// StatusActivated is reported once the versions are live on the network
+	StatusActivated = "ACTIVATED"
+	// StatusDeactivated is reported once the versions are no longer live on the network
+	StatusDeactivated = "DEACTIVATED"
+	// StatusFailed is reported when the activation did not succeed
+	StatusFailed = "FAILED"
+)
+
+const (
+	defaultPollInterval    = 30 * time.Second
+	defaultMaxPollInterval = 2 * time.Minute
+)
+
+var (
+	// ErrActivationFailed is returned when an activation ended in the FAILED status
+	ErrActivationFailed = errors.New("activation failed")
+)
+
+type (
+	// Activations contains operations available on the Activations resource
+	// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#activationsgroup
+	Activations interface {
+		// ActivateConfigs activates configuration versions on a network
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#postactivations
+		ActivateConfigs(context.Context, ActivationRequest) (*Activation, error)
+
+		// DeactivateConfigs deactivates configuration versions on a network
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#postactivations
+		DeactivateConfigs(context.Context, ActivationRequest) (*Activation, error)
+
+		// GetActivation returns the status of an activation
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#getactivation
+		GetActivation(context.Context, GetActivationRequest) (*Activation, error)
+
+		// GetActivationHistory lists the activations of a configuration, latest first
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#getactivationhistory
+		GetActivationHistory(context.Context, GetActivationHistoryRequest) (*GetActivationHistoryResponse, error)
+	}
+
+	// ActivationRequest describes the versions to activate or deactivate on a network
+	ActivationRequest struct {
+		Environment        Environment        `json:"network"`
+		Note               string             `json:"note"`
+		NotificationEmails []string           `json:"notificationEmails"`
+		Configs            []ActivationConfig `json:"activationConfigs"`
+	}
+
+	// ActivationConfig is a configuration version of an activation
+	ActivationConfig struct {
+		ConfigID      int `json:"configId"`
+		ConfigVersion int `json:"configVersion"`
+	}
+
+	// Activation represents an activation request and its status
+	Activation struct {
+		ActivationID int                `json:"activationId"`
+		Action       string             `json:"action"`
+		Status       string             `json:"status"`
+		Environment  Environment        `json:"network"`
+		CreateDate   time.Time          `json:"createDate"`
+		CreatedBy    string             `json:"createdBy"`
+		Configs      []ActivationConfig `json:"activationConfigs"`
+	}
+
+	// GetActivationRequest selects an activation
+	GetActivationRequest struct {
+		ActivationID int
+	}
+
+	// GetActivationHistoryRequest selects the configuration whose activations are listed
+	GetActivationHistoryRequest struct {
+		ConfigID int
+	}
+
+	// GetActivationHistoryResponse represents the activations of a configuration
+	GetActivationHistoryResponse struct {
+		ConfigID          int                `json:"configId"`
+		ActivationHistory []*ActivationEntry `json:"activationHistory"`
+	}
+
+	// ActivationEntry is an activation of a version in the history of a configuration
+	ActivationEntry struct {
+		ActivationID       int         `json:"activationId"`
+		Version            int         `json:"version"`
+		Environment        Environment `json:"network"`
+		Action             string      `json:"action"`
+		Status             string      `json:"status"`
+		Notes              string      `json:"notes"`
+		NotificationEmails []string    `json:"notificationEmails"`
+		ActivatedBy        string      `json:"activatedBy"`
+		ActivationDate     time.Time   `json:"activationDate"`
+	}
+
+	// WaitForActivationRequest describes the activation to wait for
+	WaitForActivationRequest struct {
+		ActivationID int
+		// PollInterval is the delay before the first status check, it grows
+		// with every poll up to MaxPollInterval
+		PollInterval    time.Duration
+		MaxPollInterval time.Duration
+	}
+
+	// activationBody is the JSON body of ActivateConfigs and DeactivateConfigs
+	activationBody struct {
+		Action string `json:"action"`
+		ActivationRequest
+	}
+)
+
+// IsTerminalActivationStatus reports whether no further status transitions
+// are expected after the given activation status
+func IsTerminalActivationStatus(status string) bool {
+	return status == StatusActivated || status == StatusDeactivated || status == StatusFailed
+}
+
+func (a *appsec) ActivateConfigs(ctx context.Context, params ActivationRequest) (*Activation, error) {
+	logger := a.Log(ctx)
+	logger.Debug("ActivateConfigs")
+
+	return a.activate(ctx, ActionActivate, params)
+}
+
+func (a *appsec) DeactivateConfigs(ctx context.Context, params ActivationRequest) (*Activation, error) {
+	logger := a.Log(ctx)
+	logger.Debug("DeactivateConfigs")
+
+	return a.activate(ctx, ActionDeactivate, params)
+}
+
+func (a *appsec) activate(ctx context.Context, action string, params ActivationRequest) (*Activation, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	var activation Activation
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/appsec/v1/activations", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create activation request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &activation, activationBody{Action: action, ActivationRequest: params})
+	if err != nil {
+		return nil, fmt.Errorf("activation request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.Error(resp)
+	}
+
+	return &activation, nil
+}
+
+func (a *appsec) GetActivation(ctx context.Context, params GetActivationRequest) (*Activation, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	var activation Activation
+
+	logger := a.Log(ctx)
+	logger.Debug("GetActivation")
+
+	uri := fmt.Sprintf("/appsec/v1/activations/%d", params.ActivationID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create getactivation request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &activation)
+	if err != nil {
+		return nil, fmt.Errorf("getactivation request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.Error(resp)
+	}
+
+	return &activation, nil
+}
+
+func (a *appsec) GetActivationHistory(ctx context.Context, params GetActivationHistoryRequest) (*GetActivationHistoryResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	var history GetActivationHistoryResponse
+
+	logger := a.Log(ctx)
+	logger.Debug("GetActivationHistory")
+
+	uri := fmt.Sprintf("/appsec/v1/configs/%d/activations", params.ConfigID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create getactivationhistory request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &history)
+	if err != nil {
+		return nil, fmt.Errorf("getactivationhistory request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.Error(resp)
+	}
+
+	return &history, nil
+}
+
+// WaitForActivation polls an activation until it reaches a terminal status or ctx is done.
+// Every status transition is logged. An ACTIVATED or DEACTIVATED activation is returned as is,
+// a FAILED one with ErrActivationFailed.
+func WaitForActivation(ctx context.Context, client Activations, params WaitForActivationRequest) (*Activation, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debug("WaitForActivation")
+
+	interval := params.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	maxInterval := params.MaxPollInterval
+	if maxInterval < interval {
+		maxInterval = defaultMaxPollInterval
+		if maxInterval < interval {
+			maxInterval = interval
+		}
+	}
+
+	var last *Activation
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if last != nil {
+				return last, fmt.Errorf("waiting for activation %d, last status %s: %w", params.ActivationID, last.Status, ctx.Err())
+			}
+			return nil, fmt.Errorf("waiting for activation %d: %w", params.ActivationID, ctx.Err())
+		case <-timer.C:
+		}
+
+		activation, err := client.GetActivation(ctx, GetActivationRequest{ActivationID: params.ActivationID})
+		if err != nil {
+			return last, err
+		}
+
+		if last == nil || last.Status != activation.Status {
+			logger.Infof("activation %[1]d (%[2]s on %[3]s): %[4]s",
+				params.ActivationID, activation.Action, activation.Environment, activation.Status)
+		}
+		last = activation
+
+		switch activation.Status {
+		case StatusActivated, StatusDeactivated:
+			return activation, nil
+		case StatusFailed:
+			return activation, fmt.Errorf("%w: %d on %s", ErrActivationFailed, params.ActivationID, activation.Environment)
+		}
+
+		if interval = interval * 3 / 2; interval > maxInterval {
+			interval = maxInterval
+		}
+		timer.Reset(interval)
+	}
+}
+
+// Validate validates ActivationRequest
+func (v ActivationRequest) Validate() error {
+	return validation.Errors{
+		"network":            validation.Validate(v.Environment, validation.Required, validation.In(STAGING, PRODUCTION)),
+		"notificationEmails": validation.Validate(v.NotificationEmails, validation.Each(validation.Required)),
+		"activationConfigs": validation.Validate(v.Configs, validation.Required, validation.By(func(interface{}) error {
+			for i, c := range v.Configs {
+				if c.ConfigID <= 0 || c.ConfigVersion <= 0 {
+					return fmt.Errorf("configuration %d needs a configId and a configVersion", i)
+				}
+			}
+			return nil
+		})),
+	}.Filter()
+}
+
+// Validate validates GetActivationRequest
+func (v GetActivationRequest) Validate() error {
+	return validation.Errors{
+		"activationId": validation.Validate(v.ActivationID, validation.Required),
+	}.Filter()
+}
+
+// Validate validates GetActivationHistoryRequest
+func (v GetActivationHistoryRequest) Validate() error {
+	return validation.Errors{
+		"configId": validation.Validate(v.ConfigID, validation.Required),
+	}.Filter()
+}
+
+// Validate validates WaitForActivationRequest
+func (v WaitForActivationRequest) Validate() error {
+	return validation.Errors{
+		"activationId": validation.Validate(v.ActivationID, validation.Required),
+	}.Filter()
+}
@@ -0,0 +1,154 @@
+package appsec_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akamai-playground/appsec"
+)
+
+func TestActivation(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	ctx := context.Background()
+	srv.AppSec.SetActivationPolls(1)
+	site := srv.AppSec.AddConfig("Site", "site protection")
+	shop := srv.AppSec.AddConfig("Shop", "shop protection")
+
+	activation, err := client.ActivateConfigs(ctx, appsec.ActivationRequest{
+		Environment:        appsec.STAGING,
+		Note:               "first release",
+		NotificationEmails: []string{"ops@example.com"},
+		Configs:            []appsec.ActivationConfig{{ConfigID: site.ID, ConfigVersion: 1}, {ConfigID: shop.ID, ConfigVersion: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activation.Status != appsec.StatusReceived || activation.Action != appsec.ActionActivate || activation.Environment != appsec.STAGING {
+		t.Errorf("ActivateConfigs() = %+v, want a RECEIVED activation on STAGING", activation)
+	}
+
+	done, err := appsec.WaitForActivation(ctx, client, appsec.WaitForActivationRequest{
+		ActivationID: activation.ActivationID,
+		PollInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Status != appsec.StatusActivated {
+		t.Errorf("WaitForActivation() status = %s, want %s", done.Status, appsec.StatusActivated)
+	}
+
+	versions, err := client.GetConfigVersions(ctx, appsec.GetConfigVersionsRequest{ConfigID: shop.ID, Detail: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions.StagingActiveVersion != 1 || versions.ProductionActiveVersion != 0 || versions.VersionList[0].Staging.Status != "Active" {
+		t.Errorf("GetConfigVersions() = %+v, want version 1 active on staging only", versions)
+	}
+
+	deactivation, err := client.DeactivateConfigs(ctx, appsec.ActivationRequest{
+		Environment: appsec.STAGING,
+		Configs:     []appsec.ActivationConfig{{ConfigID: site.ID, ConfigVersion: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := appsec.WaitForActivation(ctx, client, appsec.WaitForActivationRequest{
+		ActivationID: deactivation.ActivationID,
+		PollInterval: time.Millisecond,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := client.GetActivationHistory(ctx, appsec.GetActivationHistoryRequest{ConfigID: site.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []appsec.ActivationEntry{
+		{ActivationID: deactivation.ActivationID, Version: 1, Environment: appsec.STAGING, Action: appsec.ActionDeactivate, Status: appsec.StatusDeactivated},
+		{ActivationID: activation.ActivationID, Version: 1, Environment: appsec.STAGING, Action: appsec.ActionActivate, Status: appsec.StatusActivated,
+			Notes: "first release", NotificationEmails: []string{"ops@example.com"}},
+	}
+	if len(history.ActivationHistory) != len(want) {
+		t.Fatalf("GetActivationHistory() = %d entries, want %d", len(history.ActivationHistory), len(want))
+	}
+	for i, e := range history.ActivationHistory {
+		got := *e
+		got.ActivatedBy, got.ActivationDate = "", time.Time{}
+		if len(got.NotificationEmails) == 0 {
+			got.NotificationEmails = nil
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("history[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if err := client.DeleteConfig(ctx, appsec.DeleteConfigRequest{ConfigID: site.ID}); err != nil {
+		t.Errorf("DeleteConfig() of a deactivated configuration: %v", err)
+	}
+}
+
+func TestActivateConfigsErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Shop", "shop protection")
+
+	tests := map[string]struct {
+		params appsec.ActivationRequest
+		want   error
+	}{
+		"missing environment": {
+			params: appsec.ActivationRequest{Configs: []appsec.ActivationConfig{{ConfigID: config.ID, ConfigVersion: 1}}},
+			want:   appsec.ErrStructValidation,
+		},
+		"no configuration": {
+			params: appsec.ActivationRequest{Environment: appsec.PRODUCTION},
+			want:   appsec.ErrStructValidation,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := client.ActivateConfigs(context.Background(), test.params); !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestDeactivateConfigsErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Shop", "shop protection")
+
+	_, err := client.DeactivateConfigs(context.Background(), appsec.ActivationRequest{
+		Environment: appsec.PRODUCTION,
+		Configs:     []appsec.ActivationConfig{{ConfigID: config.ID, ConfigVersion: 1}},
+	})
+	if !errors.Is(err, appsec.ErrValidation) {
+		t.Errorf("deactivating an inactive version: error = %v, want %v", err, appsec.ErrValidation)
+	}
+}
+
+func TestGetActivationErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+
+	_, err := client.GetActivation(context.Background(), appsec.GetActivationRequest{ActivationID: 1})
+	if !errors.Is(err, appsec.ErrNotFound) {
+		t.Errorf("error = %v, want %v", err, appsec.ErrNotFound)
+	}
+}
+
+func TestGetActivationHistoryErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+
+	_, err := client.GetActivationHistory(context.Background(), appsec.GetActivationHistoryRequest{ConfigID: 1})
+	if !errors.Is(err, appsec.ErrNotFound) {
+		t.Errorf("error = %v, want %v", err, appsec.ErrNotFound)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/akamai-playground/tools"
 	"github.com/akamai/AkamaiOPEN-edgegrid-golang/v2/pkg/session"
 )
 
@@ -13,7 +14,22 @@ var (
 	ErrStructValidation = errors.New("struct validation")
 
 	// ErrNotFound is returned when requested resource was not found
-	ErrNotFound = errors.New("resource not found")
+	ErrNotFound = tools.ErrNotFound
+
+	// ErrConflict is returned when the resource changed concurrently
+	ErrConflict = tools.ErrConflict
+
+	// ErrValidation is returned when the API rejected the request content
+	ErrValidation = tools.ErrValidation
+
+	// ErrForbidden is returned when the credentials do not grant access to the resource
+	ErrForbidden = tools.ErrForbidden
+
+	// ErrRateLimited is returned when too many requests were sent
+	ErrRateLimited = tools.ErrRateLimited
+
+	// ErrServerError is returned when the API failed to process the request
+	ErrServerError = tools.ErrServerError
 )
 
 type (
@@ -21,13 +37,16 @@ type (
 	APPSEC interface {
 		Configs
 		ConfigVersions
+		Activations
 		Rules
 		Policy
 	}
 
 	appsec struct {
 		session.Session
-		usePrefixes bool
+		usePrefixes      bool
+		accountSwitchKey string
+		retry            tools.RetryPolicy
 	}
 
 	// Option defines a APPSEC option
@@ -60,7 +79,24 @@ func Client(sess session.Session, opts ...Option) APPSEC {
 	return a
 }
 
+// WithAccountSwitchKey runs every call on behalf of the account of the given key,
+// tools.ContextWithAccountSwitchKey overrides it per call
+func WithAccountSwitchKey(key string) Option {
+	return func(a *appsec) {
+		a.accountSwitchKey = key
+	}
+}
+
+// WithRetries retries rate limited and transiently failing calls according to
+// the policy, see tools.DefaultRetryPolicy
+func WithRetries(policy tools.RetryPolicy) Option {
+	return func(a *appsec) {
+		a.retry = policy
+	}
+}
+
 // Exec overrides the session.Exec to add papi options
 func (p *appsec) Exec(r *http.Request, out interface{}, in ...interface{}) (*http.Response, error) {
-	return p.Session.Exec(r, out, in...)
+	tools.CheckAccountID(tools.AccountSwitchKey(r, p.accountSwitchKey), r)
+	return p.retry.Exec(p.Log(r.Context()), p.Session.Exec, r, out, in...)
 }
@@ -4,7 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
 type (
@@ -14,12 +18,72 @@ type (
 		// GetConfigs provides a read-only list of groups, which may contain properties.
 		// See: https://developer.akamai.com/api/core_features/property_manager/v1.html#getgroups
 		GetConfigs(context.Context) (*GetConfigsResponse, error)
+
+		// GetConfig returns a security configuration
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#getconfiguration
+		GetConfig(context.Context, GetConfigRequest) (*Config, error)
+
+		// CreateConfig creates a security configuration protecting the given hostnames
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#postconfigurations
+		CreateConfig(context.Context, CreateConfigRequest) (*CreateConfigResponse, error)
+
+		// CloneConfig creates a security configuration from a version of an existing one
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#postconfigurations
+		CloneConfig(context.Context, CloneConfigRequest) (*CreateConfigResponse, error)
+
+		// UpdateConfig renames a security configuration and updates its description
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#putconfiguration
+		UpdateConfig(context.Context, UpdateConfigRequest) (*UpdateConfigResponse, error)
+
+		// DeleteConfig deletes a security configuration which was never activated
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#deleteconfiguration
+		DeleteConfig(context.Context, DeleteConfigRequest) error
 	}
 
 	// ConfigVersions contains operations available on Security Configuration Versions resource
 	// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#getsummarylistofconfigurationversions
 	ConfigVersions interface {
-		GetConfigVersions(context.Context, int, ...string) (*GetConfigVersionsResponse, error)
+		// GetConfigVersions lists the versions of a configuration, latest first
+		GetConfigVersions(context.Context, GetConfigVersionsRequest) (*GetConfigVersionsResponse, error)
+
+		// GetConfigVersion returns the details of a version
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#getversion
+		GetConfigVersion(context.Context, GetConfigVersionRequest) (*ConfigVersion, error)
+
+		// CreateConfigVersion creates a version cloned from an existing one
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#postversion
+		CreateConfigVersion(context.Context, CreateConfigVersionRequest) (*ConfigVersion, error)
+	}
+
+	// GetConfigVersionsRequest selects a page of the versions of a configuration
+	GetConfigVersionsRequest struct {
+		ConfigID int
+		// Page is the index of the result page, 1 when unset
+		Page int
+		// PageSize is the number of versions per page, 25 when unset and every version when -1
+		PageSize int
+		// Detail returns the notes, the author and the activation status of the versions
+		Detail bool
+	}
+
+	// GetConfigVersionRequest selects a version of a configuration
+	GetConfigVersionRequest struct {
+		ConfigID int
+		Version  int
+	}
+
+	// CreateConfigVersionRequest describes a version cloned from CreateFromVersion
+	CreateConfigVersionRequest struct {
+		ConfigID          int  `json:"-"`
+		CreateFromVersion int  `json:"createFromVersion"`
+		RuleUpdate        bool `json:"ruleUpdate"`
+	}
+
+	// ConfigVersion represents a version of a configuration with its details
+	ConfigVersion struct {
+		ConfigID   int    `json:"configId"`
+		ConfigName string `json:"configName"`
+		VersionList
 	}
 
 	// Config represents a property config resource
@@ -33,6 +97,68 @@ type (
 		ProductionHostnames []string `json:"productionHostnames,omitempty"`
 	}
 
+	// GetConfigRequest selects a security configuration
+	GetConfigRequest struct {
+		ConfigID int
+	}
+
+	// CreateConfigRequest is the JSON body creating a security configuration
+	CreateConfigRequest struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		ContractID  string   `json:"contractId"`
+		GroupID     int      `json:"groupId"`
+		Hostnames   []string `json:"hostnames"`
+		// CreateFrom is set by CloneConfig
+		CreateFrom *CreateFrom `json:"createFrom,omitempty"`
+	}
+
+	// CreateFrom is the version of a configuration a new one is cloned from
+	CreateFrom struct {
+		ConfigID int `json:"configId"`
+		Version  int `json:"version"`
+	}
+
+	// CloneConfigRequest describes a security configuration cloned from a version
+	// of ConfigID. Hostnames are optional, the source ones are kept when empty
+	CloneConfigRequest struct {
+		ConfigID    int
+		Version     int
+		Name        string
+		Description string
+		ContractID  string
+		GroupID     int
+		Hostnames   []string
+	}
+
+	// CreateConfigResponse represents the configuration created by CreateConfig and CloneConfig
+	CreateConfigResponse struct {
+		ConfigID    int    `json:"configId"`
+		ConfigName  string `json:"configName"`
+		Description string `json:"description"`
+		ContractID  string `json:"contractId"`
+		GroupID     int    `json:"groupId"`
+		Version     int    `json:"version"`
+	}
+
+	// UpdateConfigRequest is the JSON body renaming a security configuration
+	UpdateConfigRequest struct {
+		ConfigID    int    `json:"-"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	// UpdateConfigResponse holds the updated name and description
+	UpdateConfigResponse struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	// DeleteConfigRequest selects the security configuration to delete
+	DeleteConfigRequest struct {
+		ConfigID int
+	}
+
 	// ConfigItems represents sub-compent of the config response
 	ConfigItems struct {
 		Configs []*Config `json:"configurations"`
@@ -95,8 +221,6 @@ func (a *appsec) GetConfigs(ctx context.Context) (*GetConfigsResponse, error) {
 		return nil, fmt.Errorf("failed to create getconfigs request: %w", err)
 	}
 
-	// tools.CheckAccountID(req)
-
 	resp, err := a.Exec(req, &configs)
 	if err != nil {
 		return nil, fmt.Errorf("getconfigs request failed: %w", err)
@@ -109,41 +233,165 @@ func (a *appsec) GetConfigs(ctx context.Context) (*GetConfigsResponse, error) {
 	return &configs, nil
 }
 
-// GetConfigVersions accepts required configID, optional accountID and params:
-// 1. page (integer in string representation) The number of items on each result page. The default value is 25.
-// 2. pageSize (integer in string representation) The index of the result page. If the value is -1,
-// then pagination is ignored. The default value is 1.
-// 3. Detail (boolean in string representation) When true, the results contain detailed information
-// on versions. When false, the results contain summary information on versions.
-func (a *appsec) GetConfigVersions(ctx context.Context, configID int, params ...string) (*GetConfigVersionsResponse, error) {
+func (a *appsec) GetConfig(ctx context.Context, params GetConfigRequest) (*Config, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	var config Config
+
+	logger := a.Log(ctx)
+	logger.Debug("GetConfig")
+
+	uri := fmt.Sprintf("/appsec/v1/configs/%d", params.ConfigID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create getconfig request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &config)
+	if err != nil {
+		return nil, fmt.Errorf("getconfig request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.Error(resp)
+	}
+
+	return &config, nil
+}
+
+func (a *appsec) CreateConfig(ctx context.Context, params CreateConfigRequest) (*CreateConfigResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := a.Log(ctx)
+	logger.Debug("CreateConfig")
+
+	return a.createConfig(ctx, params)
+}
+
+func (a *appsec) CloneConfig(ctx context.Context, params CloneConfigRequest) (*CreateConfigResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := a.Log(ctx)
+	logger.Debug("CloneConfig")
+
+	return a.createConfig(ctx, CreateConfigRequest{
+		Name:        params.Name,
+		Description: params.Description,
+		ContractID:  params.ContractID,
+		GroupID:     params.GroupID,
+		Hostnames:   params.Hostnames,
+		CreateFrom:  &CreateFrom{ConfigID: params.ConfigID, Version: params.Version},
+	})
+}
+
+func (a *appsec) createConfig(ctx context.Context, body CreateConfigRequest) (*CreateConfigResponse, error) {
+	var rval CreateConfigResponse
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/appsec/v1/configs", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create createconfig request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &rval, body)
+	if err != nil {
+		return nil, fmt.Errorf("createconfig request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, a.Error(resp)
+	}
+
+	return &rval, nil
+}
+
+func (a *appsec) UpdateConfig(ctx context.Context, params UpdateConfigRequest) (*UpdateConfigResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := a.Log(ctx)
+	logger.Debug("UpdateConfig")
+
+	var rval UpdateConfigResponse
+
+	uri := fmt.Sprintf("/appsec/v1/configs/%d", params.ConfigID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create updateconfig request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &rval, params)
+	if err != nil {
+		return nil, fmt.Errorf("updateconfig request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.Error(resp)
+	}
+
+	return &rval, nil
+}
+
+func (a *appsec) DeleteConfig(ctx context.Context, params DeleteConfigRequest) error {
+	if err := params.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := a.Log(ctx)
+	logger.Debug("DeleteConfig")
+
+	uri := fmt.Sprintf("/appsec/v1/configs/%d", params.ConfigID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, uri, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create deleteconfig request: %w", err)
+	}
+
+	resp, err := a.Exec(req, nil)
+	if err != nil {
+		return fmt.Errorf("deleteconfig request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return a.Error(resp)
+	}
+
+	return nil
+}
+
+func (a *appsec) GetConfigVersions(ctx context.Context, params GetConfigVersionsRequest) (*GetConfigVersionsResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
 	var configVersions GetConfigVersionsResponse
 
 	logger := a.Log(ctx)
 	logger.Debug("GetConfigVersions")
 
-	var configVersionsURL string
-	switch len(params) {
-	case 1:
-		configVersionsURL = fmt.Sprintf(
-			"/appsec/v1/configs/%d/versions?detail=%s", configID, params[0])
-	case 2:
-		configVersionsURL = fmt.Sprintf(
-			"/appsec/v1/configs/%d/versions?page=%s&pageSize=%s", configID, params[0], params[1])
-	case 3:
-		configVersionsURL = fmt.Sprintf(
-			"/appsec/v1/configs/%d/versions?page=%s&pageSize=%s&detail=%s", configID, params[0], params[1], params[2])
-	default:
-		configVersionsURL = fmt.Sprintf(
-			"/appsec/v1/configs/%d/versions?page=%d&pageSize=%d&detail=%s", configID, 1, 25, "true")
+	q := url.Values{}
+	if params.Page != 0 {
+		q.Set("page", strconv.Itoa(params.Page))
+	}
+	if params.PageSize != 0 {
+		q.Set("pageSize", strconv.Itoa(params.PageSize))
 	}
+	q.Set("detail", strconv.FormatBool(params.Detail))
+	uri := fmt.Sprintf("/appsec/v1/configs/%d/versions?%s", params.ConfigID, q.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, configVersionsURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create getconfigversions request: %w", err)
 	}
 
-	// tools.CheckAccountID(req)
-
 	resp, err := a.Exec(req, &configVersions)
 	if err != nil {
 		return nil, fmt.Errorf("getconfigversions request failed: %w", err)
@@ -155,3 +403,130 @@ func (a *appsec) GetConfigVersions(ctx context.Context, configID int, params ...
 
 	return &configVersions, nil
 }
+
+func (a *appsec) GetConfigVersion(ctx context.Context, params GetConfigVersionRequest) (*ConfigVersion, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	var version ConfigVersion
+
+	logger := a.Log(ctx)
+	logger.Debug("GetConfigVersion")
+
+	uri := fmt.Sprintf("/appsec/v1/configs/%d/versions/%d", params.ConfigID, params.Version)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create getconfigversion request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &version)
+	if err != nil {
+		return nil, fmt.Errorf("getconfigversion request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.Error(resp)
+	}
+
+	return &version, nil
+}
+
+func (a *appsec) CreateConfigVersion(ctx context.Context, params CreateConfigVersionRequest) (*ConfigVersion, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	var version ConfigVersion
+
+	logger := a.Log(ctx)
+	logger.Debug("CreateConfigVersion")
+
+	uri := fmt.Sprintf("/appsec/v1/configs/%d/versions", params.ConfigID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create createconfigversion request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &version, params)
+	if err != nil {
+		return nil, fmt.Errorf("createconfigversion request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, a.Error(resp)
+	}
+
+	return &version, nil
+}
+
+// Validate validates GetConfigRequest
+func (v GetConfigRequest) Validate() error {
+	return validation.Errors{
+		"configId": validation.Validate(v.ConfigID, validation.Required),
+	}.Filter()
+}
+
+// Validate validates CreateConfigRequest
+func (v CreateConfigRequest) Validate() error {
+	return validation.Errors{
+		"name":       validation.Validate(v.Name, validation.Required),
+		"contractId": validation.Validate(v.ContractID, validation.Required),
+		"groupId":    validation.Validate(v.GroupID, validation.Required),
+		"hostnames":  validation.Validate(v.Hostnames, validation.Required, validation.Each(validation.Required)),
+	}.Filter()
+}
+
+// Validate validates CloneConfigRequest
+func (v CloneConfigRequest) Validate() error {
+	return validation.Errors{
+		"configId":   validation.Validate(v.ConfigID, validation.Required),
+		"version":    validation.Validate(v.Version, validation.Required, validation.Min(1)),
+		"name":       validation.Validate(v.Name, validation.Required),
+		"contractId": validation.Validate(v.ContractID, validation.Required),
+		"groupId":    validation.Validate(v.GroupID, validation.Required),
+		"hostnames":  validation.Validate(v.Hostnames, validation.Each(validation.Required)),
+	}.Filter()
+}
+
+// Validate validates UpdateConfigRequest
+func (v UpdateConfigRequest) Validate() error {
+	return validation.Errors{
+		"configId": validation.Validate(v.ConfigID, validation.Required),
+		"name":     validation.Validate(v.Name, validation.Required),
+	}.Filter()
+}
+
+// Validate validates DeleteConfigRequest
+func (v DeleteConfigRequest) Validate() error {
+	return validation.Errors{
+		"configId": validation.Validate(v.ConfigID, validation.Required),
+	}.Filter()
+}
+
+// Validate validates GetConfigVersionsRequest
+func (v GetConfigVersionsRequest) Validate() error {
+	return validation.Errors{
+		"configId": validation.Validate(v.ConfigID, validation.Required),
+		"page":     validation.Validate(v.Page, validation.Min(0)),
+		"pageSize": validation.Validate(v.PageSize, validation.Min(-1)),
+	}.Filter()
+}
+
+// Validate validates GetConfigVersionRequest
+func (v GetConfigVersionRequest) Validate() error {
+	return validation.Errors{
+		"configId": validation.Validate(v.ConfigID, validation.Required),
+		"version":  validation.Validate(v.Version, validation.Required, validation.Min(1)),
+	}.Filter()
+}
+
+// Validate validates CreateConfigVersionRequest
+func (v CreateConfigVersionRequest) Validate() error {
+	return validation.Errors{
+		"configId":          validation.Validate(v.ConfigID, validation.Required),
+		"createFromVersion": validation.Validate(v.CreateFromVersion, validation.Required, validation.Min(1)),
+	}.Filter()
+}
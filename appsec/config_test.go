@@ -0,0 +1,206 @@
+package appsec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akamai-playground/appsec"
+	"github.com/akamai-playground/fakeapi"
+)
+
+// fakeClient starts a fake API server, to be closed by the caller, and returns
+// it with a client signed for it
+func fakeClient(t *testing.T) (*fakeapi.Server, appsec.APPSEC) {
+	t.Helper()
+	srv := fakeapi.NewServer()
+	sess, err := srv.Session()
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	return srv, appsec.Client(sess)
+}
+
+func TestConfigLifecycle(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	ctx := context.Background()
+
+	created, err := client.CreateConfig(ctx, appsec.CreateConfigRequest{
+		Name:        "Shop",
+		Description: "shop protection",
+		ContractID:  "ctr_C-0N7RAC7",
+		GroupID:     12345,
+		Hostnames:   []string{"shop.example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := appsec.CreateConfigResponse{ConfigID: created.ConfigID, ConfigName: "Shop", Description: "shop protection",
+		ContractID: "C-0N7RAC7", GroupID: 12345, Version: 1}
+	if *created != want {
+		t.Errorf("CreateConfig() = %+v, want %+v", created, want)
+	}
+	if err := srv.AppSec.AddPolicy(created.ConfigID, 1, appsec.Policies{PolicyID: "POL_1", PolicyName: "Default"}); err != nil {
+		t.Fatal(err)
+	}
+	rules := []appsec.RuleActions{{ID: 950002, Action: "deny"}}
+	if err := srv.AppSec.SetRules(created.ConfigID, 1, "POL_1", rules); err != nil {
+		t.Fatal(err)
+	}
+
+	clone, err := client.CloneConfig(ctx, appsec.CloneConfigRequest{
+		ConfigID:   created.ConfigID,
+		Version:    1,
+		Name:       "Shop staging",
+		ContractID: "C-0N7RAC7",
+		GroupID:    12345,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloned, err := client.GetRules(ctx, clone.ConfigID, 1, "POL_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cloned.RuleActions) != 1 || *cloned.RuleActions[0] != rules[0] {
+		t.Errorf("cloned rules %v, want %v", cloned.RuleActions, rules)
+	}
+	// the clone owns its rules
+	if err := srv.AppSec.SetRules(created.ConfigID, 1, "POL_1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if cloned, err = client.GetRules(ctx, clone.ConfigID, 1, "POL_1"); err != nil || len(cloned.RuleActions) != 1 {
+		t.Errorf("clone rules changed with the source ones: %v, %v", cloned, err)
+	}
+
+	updated, err := client.UpdateConfig(ctx, appsec.UpdateConfigRequest{ConfigID: clone.ConfigID, Name: "Shop QA", Description: "qa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Shop QA" || updated.Description != "qa" {
+		t.Errorf("UpdateConfig() = %+v", updated)
+	}
+	config, err := client.GetConfig(ctx, appsec.GetConfigRequest{ConfigID: clone.ConfigID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "Shop QA" || config.Description != "qa" || config.LatestVersion != 1 {
+		t.Errorf("GetConfig() = %+v", config)
+	}
+
+	if err := client.DeleteConfig(ctx, appsec.DeleteConfigRequest{ConfigID: clone.ConfigID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetConfig(ctx, appsec.GetConfigRequest{ConfigID: clone.ConfigID}); !errors.Is(err, appsec.ErrNotFound) {
+		t.Errorf("deleted configuration: error = %v, want %v", err, appsec.ErrNotFound)
+	}
+}
+
+func TestCreateConfigErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	srv.AppSec.AddConfig("Shop", "shop protection")
+
+	tests := map[string]struct {
+		params appsec.CreateConfigRequest
+		want   error
+	}{
+		"without hostnames": {
+			params: appsec.CreateConfigRequest{Name: "Blog", ContractID: "C-0N7RAC7", GroupID: 1},
+			want:   appsec.ErrStructValidation,
+		},
+		"without contract": {
+			params: appsec.CreateConfigRequest{Name: "Blog", GroupID: 1, Hostnames: []string{"blog.example.com"}},
+			want:   appsec.ErrStructValidation,
+		},
+		"used name": {
+			params: appsec.CreateConfigRequest{Name: "shop", ContractID: "C-0N7RAC7", GroupID: 1, Hostnames: []string{"blog.example.com"}},
+			want:   appsec.ErrValidation,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := client.CreateConfig(context.Background(), test.params); !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestCloneConfigErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Shop", "shop protection")
+
+	tests := map[string]struct {
+		params appsec.CloneConfigRequest
+		want   error
+	}{
+		"without version": {
+			params: appsec.CloneConfigRequest{ConfigID: config.ID, Name: "Blog", ContractID: "C-0N7RAC7", GroupID: 1},
+			want:   appsec.ErrStructValidation,
+		},
+		"missing version": {
+			params: appsec.CloneConfigRequest{ConfigID: config.ID, Version: 2, Name: "Blog", ContractID: "C-0N7RAC7", GroupID: 1},
+			want:   appsec.ErrNotFound,
+		},
+		"missing configuration": {
+			params: appsec.CloneConfigRequest{ConfigID: config.ID + 1, Version: 1, Name: "Blog", ContractID: "C-0N7RAC7", GroupID: 1},
+			want:   appsec.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := client.CloneConfig(context.Background(), test.params); !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestUpdateConfigErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Shop", "shop protection")
+	srv.AppSec.AddConfig("Blog", "blog protection")
+
+	tests := map[string]struct {
+		params appsec.UpdateConfigRequest
+		want   error
+	}{
+		"without name": {
+			params: appsec.UpdateConfigRequest{ConfigID: config.ID},
+			want:   appsec.ErrStructValidation,
+		},
+		"used name": {
+			params: appsec.UpdateConfigRequest{ConfigID: config.ID, Name: "blog"},
+			want:   appsec.ErrValidation,
+		},
+		"missing configuration": {
+			params: appsec.UpdateConfigRequest{ConfigID: 1, Name: "Shop QA"},
+			want:   appsec.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := client.UpdateConfig(context.Background(), test.params); !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestDeleteConfigErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+
+	err := client.DeleteConfig(context.Background(), appsec.DeleteConfigRequest{ConfigID: 1})
+	if !errors.Is(err, appsec.ErrNotFound) {
+		t.Errorf("error = %v, want %v", err, appsec.ErrNotFound)
+	}
+}
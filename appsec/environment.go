@@ -0,0 +1,65 @@
+package appsec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// STAGING is for STAGING network
+	STAGING Environment = iota + 1
+	// PRODUCTION is for PRODUCTION network
+	PRODUCTION
+)
+
+type (
+	// Environment represents the network a version is activated on (STAGING or PRODUCTION)
+	// It is of enumeration type, written as its name in JSON
+	Environment int
+)
+
+// String returns the name of the environment, UNKNOWN when out of range
+// like the zero value of a request which left it unset
+func (e Environment) String() string {
+	switch e {
+	case STAGING:
+		return "STAGING"
+	case PRODUCTION:
+		return "PRODUCTION"
+	}
+	return "UNKNOWN"
+}
+
+// ParseEnvironment returns the Environment matching the given name (case insensitive)
+func ParseEnvironment(name string) (Environment, error) {
+	switch strings.ToUpper(name) {
+	case STAGING.String():
+		return STAGING, nil
+	case PRODUCTION.String():
+		return PRODUCTION, nil
+	}
+	return 0, fmt.Errorf("unknown environment: %q", name)
+}
+
+// MarshalJSON writes the environment as its name
+func (e Environment) MarshalJSON() ([]byte, error) {
+	if e != STAGING && e != PRODUCTION {
+		return nil, fmt.Errorf("unknown environment: %d", int(e))
+	}
+	return json.Marshal(e.String())
+}
+
+// UnmarshalJSON reads the environment from its name
+func (e *Environment) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	env, err := ParseEnvironment(name)
+	if err != nil {
+		return err
+	}
+	*e = env
+	return nil
+}
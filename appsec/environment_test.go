@@ -0,0 +1,66 @@
+package appsec
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnvironmentJSON(t *testing.T) {
+	tests := map[string]struct {
+		json    string
+		want    Environment
+		withErr bool
+	}{
+		"staging":    {json: `"STAGING"`, want: STAGING},
+		"production": {json: `"production"`, want: PRODUCTION},
+		"unknown":    {json: `"QA"`, withErr: true},
+		"number":     {json: `1`, withErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var env Environment
+			err := json.Unmarshal([]byte(test.json), &env)
+			if test.withErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %v, want an error", test.json, env)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if env != test.want {
+				t.Errorf("Unmarshal(%s) = %v, want %v", test.json, env, test.want)
+			}
+
+			out, err := json.Marshal(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := `"` + test.want.String() + `"`; string(out) != want {
+				t.Errorf("Marshal(%v) = %s, want %s", env, out, want)
+			}
+		})
+	}
+
+	if _, err := json.Marshal(Environment(0)); err == nil {
+		t.Error("Marshal(0) succeeded, want an error")
+	}
+}
+
+func TestEnvironmentString(t *testing.T) {
+	tests := map[Environment]string{
+		STAGING:         "STAGING",
+		PRODUCTION:      "PRODUCTION",
+		0:               "UNKNOWN",
+		PRODUCTION + 1:  "UNKNOWN",
+		Environment(-1): "UNKNOWN",
+	}
+
+	for env, want := range tests {
+		if got := env.String(); got != want {
+			t.Errorf("Environment(%d).String() = %q, want %q", int(env), got, want)
+		}
+	}
+}
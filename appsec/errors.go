@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+
+	"github.com/akamai-playground/tools"
 )
 
 type (
@@ -20,6 +22,8 @@ type (
 		StatusCode    int             `json:"statusCode,omitempty"`
 		Errors        json.RawMessage `json:"errors,omitempty"`
 		Warnings      json.RawMessage `json:"warnings,omitempty"`
+		// Raw is the problem JSON as returned by the API
+		Raw json.RawMessage `json:"-"`
 	}
 )
 
@@ -38,6 +42,7 @@ func (a *appsec) Error(r *http.Response) error {
 		return &e
 	}
 
+	e.Raw = body
 	if err := json.Unmarshal(body, &e); err != nil {
 		a.Log(r.Request.Context()).Errorf("could not unmarshal API error: %s", err)
 		e.Title = fmt.Sprintf("Failed to unmarshal error body")
@@ -57,8 +62,18 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("API error: \n%s", msg)
 }
 
+// Kind returns the kind of the error, one of ErrNotFound, ErrConflict, ErrValidation,
+// ErrForbidden, ErrRateLimited and ErrServerError, or nil
+func (e *Error) Kind() error {
+	return tools.ErrorKind(e.StatusCode, e.Type)
+}
+
 // Is handles error comparisons
 func (e *Error) Is(target error) bool {
+	if kind := e.Kind(); kind != nil && kind == target {
+		return true
+	}
+
 	var t *Error
 	if !errors.As(target, &t) {
 		return false
@@ -0,0 +1,82 @@
+package appsec
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akamai/AkamaiOPEN-edgegrid-golang/v2/pkg/session"
+)
+
+func TestErrorKinds(t *testing.T) {
+	kinds := []error{ErrNotFound, ErrConflict, ErrValidation, ErrForbidden, ErrRateLimited, ErrServerError}
+
+	tests := map[string]struct {
+		status int
+		body   string
+		want   error
+	}{
+		"config not found": {
+			status: http.StatusNotFound,
+			body:   `{"type":"https://problems.luna.akamaiapis.net/appsec/error-types/CONFIG-NOT-FOUND","title":"Not Found","detail":"Configuration 1 not found","statusCode":404}`,
+			want:   ErrNotFound,
+		},
+		"invalid input": {
+			status: http.StatusBadRequest,
+			body:   `{"type":"https://problems.luna.akamaiapis.net/appsec/error-types/INVALID-INPUT-ERROR","title":"Invalid Input Error","statusCode":400}`,
+			want:   ErrValidation,
+		},
+		"unauthorized with an invalid type": {
+			status: http.StatusUnauthorized,
+			body:   `{"type":"https://problems.luna.akamaiapis.net/-/pep-authn/request-error/invalid-token","title":"Not authorized"}`,
+			want:   ErrForbidden,
+		},
+		"concurrent modification": {
+			status: http.StatusConflict,
+			body:   `{"title":"Conflict"}`,
+			want:   ErrConflict,
+		},
+		"rate limited": {
+			status: http.StatusTooManyRequests,
+			body:   `{"title":"Too Many Requests"}`,
+			want:   ErrRateLimited,
+		},
+		"server error with a malformed body": {
+			status: http.StatusInternalServerError,
+			body:   `oops`,
+			want:   ErrServerError,
+		},
+	}
+
+	sess, err := session.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &appsec{Session: sess}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := client.Error(&http.Response{
+				StatusCode: test.status,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+				Request:    httptest.NewRequest(http.MethodGet, "/appsec/v1/configs", nil),
+			})
+
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("errors.As(%v, *Error) = false", err)
+			}
+			if string(apiErr.Raw) != test.body {
+				t.Errorf("Raw = %s, want %s", apiErr.Raw, test.body)
+			}
+			for _, kind := range kinds {
+				if got := errors.Is(err, kind); got != (kind == test.want) {
+					t.Errorf("errors.Is(err, %v) = %t", kind, got)
+				}
+			}
+		})
+	}
+}
@@ -55,8 +55,6 @@ func (a *appsec) GetPolicies(ctx context.Context, configID, versionNumber int) (
 		return nil, fmt.Errorf("failed to create getconfigs request: %w", err)
 	}
 
-	// tools.CheckAccountID(req)
-
 	resp, err := a.Exec(req, &policies)
 	if err != nil {
 		return nil, fmt.Errorf("getpolicies request failed: %w", err)
@@ -2,8 +2,56 @@ package appsec
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Rule actions
+const (
+	// RuleActionAlert logs the requests matching the rule
+	RuleActionAlert = "alert"
+	// RuleActionDeny blocks the requests matching the rule
+	RuleActionDeny = "deny"
+	// RuleActionNone disables the rule
+	RuleActionNone = "none"
+
+	customDenyPrefix = "deny_custom_"
+)
+
+// Condition types
+const (
+	// ConditionPath matches the request path against Paths
+	ConditionPath = "pathMatch"
+	// ConditionHost matches the request host against Hosts
+	ConditionHost = "hostMatch"
+	// ConditionIP matches the client IP against IPs
+	ConditionIP = "ipMatch"
+	// ConditionRequestMethod matches the request method against Methods
+	ConditionRequestMethod = "requestMethodMatch"
+	// ConditionExtension matches the file extension against Extensions
+	ConditionExtension = "extensionMatch"
+	// ConditionRequestHeader matches the Header request header against Value
+	ConditionRequestHeader = "requestHeaderMatch"
+)
+
+// Selectors of the names excluded by an exception
+const (
+	// SelectorCookies selects request cookies
+	SelectorCookies = "REQUEST_COOKIES"
+	// SelectorHeaders selects request headers
+	SelectorHeaders = "REQUEST_HEADERS"
+	// SelectorArgs selects query and form parameters
+	SelectorArgs = "ARGS"
+	// SelectorJSONPairs selects the keys of JSON bodies
+	SelectorJSONPairs = "JSON_PAIRS"
+	// SelectorXMLPairs selects the elements of XML bodies
+	SelectorXMLPairs = "XML_PAIRS"
 )
 
 type (
@@ -13,6 +61,108 @@ type (
 		// GetConfigs provides rules details namely actions
 		// See: https://developer.akamai.com/api/core_features/property_manager/v1.html#getgroups
 		GetRules(context.Context, int, int, string) (*GetRulesResponse, error)
+
+		// UpdateRuleAction sets the action of a rule in a policy of a version which was never activated
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#putruleaction
+		UpdateRuleAction(context.Context, UpdateRuleActionRequest) (*UpdateRuleActionResponse, error)
+
+		// GetRule returns the details of a rule in a policy: its title, tags, attack group,
+		// action, conditions and exceptions
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#getrule
+		GetRule(context.Context, GetRuleRequest) (*Rule, error)
+
+		// GetRuleConditionException returns the conditions and exceptions of a rule in a policy
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#getruleconditionexception
+		GetRuleConditionException(context.Context, GetRuleRequest) (*ConditionException, error)
+
+		// UpdateRuleConditionException replaces the conditions and exceptions of a rule in a policy
+		// of a version which was never activated, empty ones clear them
+		// See: https://developer.akamai.com/api/cloud_security/application_security/v1.html#putruleconditionexception
+		UpdateRuleConditionException(context.Context, UpdateRuleConditionExceptionRequest) (*ConditionException, error)
+	}
+
+	// GetRuleRequest selects a rule of a policy
+	GetRuleRequest struct {
+		ConfigID int
+		Version  int
+		PolicyID string
+		RuleID   int
+	}
+
+	// Rule represents a rule of a policy with its metadata
+	Rule struct {
+		ID                 int                 `json:"id"`
+		Title              string              `json:"title"`
+		Tags               []string            `json:"tags"`
+		AttackGroup        string              `json:"attackGroup"`
+		Action             string              `json:"action"`
+		ConditionException *ConditionException `json:"conditionException,omitempty"`
+	}
+
+	// ConditionException holds the conditions a rule applies under and
+	// the parts of the requests it ignores
+	ConditionException struct {
+		Conditions []*Condition `json:"conditions,omitempty"`
+		Exception  *Exception   `json:"exception,omitempty"`
+	}
+
+	// Condition restricts a rule to the requests it matches, or does not match
+	// when PositiveMatch is false. The field used depends on Type
+	Condition struct {
+		Type          string   `json:"type"`
+		PositiveMatch bool     `json:"positiveMatch"`
+		Paths         []string `json:"paths,omitempty"`
+		Hosts         []string `json:"hosts,omitempty"`
+		IPs           []string `json:"ips,omitempty"`
+		Methods       []string `json:"methods,omitempty"`
+		Extensions    []string `json:"extensions,omitempty"`
+		Header        string   `json:"header,omitempty"`
+		Value         []string `json:"value,omitempty"`
+	}
+
+	// Exception excludes values, or named cookies, headers and parameters, from a rule
+	Exception struct {
+		HeaderCookieOrParamValues        []string         `json:"headerCookieOrParamValues,omitempty"`
+		SpecificHeaderCookieOrParamNames []*SpecificNames `json:"specificHeaderCookieOrParamNames,omitempty"`
+	}
+
+	// SpecificNames are names of the part of the request picked by Selector
+	SpecificNames struct {
+		Names    []string `json:"names"`
+		Selector string   `json:"selector"`
+	}
+
+	// UpdateRuleConditionExceptionRequest describes the new conditions and exceptions of a rule
+	UpdateRuleConditionExceptionRequest struct {
+		ConfigID int    `json:"-"`
+		Version  int    `json:"-"`
+		PolicyID string `json:"-"`
+		RuleID   int    `json:"-"`
+		ConditionException
+	}
+
+	// UpdateRuleActionRequest describes the new action of a rule
+	UpdateRuleActionRequest struct {
+		ConfigID int    `json:"-"`
+		Version  int    `json:"-"`
+		PolicyID string `json:"-"`
+		RuleID   int    `json:"-"`
+		Action   string `json:"action"`
+	}
+
+	// UpdateRuleActionResponse represents the action a rule was given
+	UpdateRuleActionResponse struct {
+		Action string `json:"action"`
+	}
+
+	// UpdateRuleActionsRequest describes the rule actions UpdateRuleActions replaces
+	UpdateRuleActionsRequest struct {
+		ConfigID int
+		Version  int
+		PolicyID string
+		// ActionMap maps the current action of a rule to its new action,
+		// e.g. {"alert": "deny"} denies every rule which alerts
+		ActionMap map[string]string
 	}
 
 	// GetRulesResponse represents a security rule resource
@@ -40,8 +190,6 @@ func (a *appsec) GetRules(ctx context.Context, configID, versionNumber int, poli
 		return nil, fmt.Errorf("failed to create getconfigs request: %w", err)
 	}
 
-	// tools.CheckAccountID(req)
-
 	resp, err := a.Exec(req, &rules)
 	if err != nil {
 		return nil, fmt.Errorf("getrules request failed: %w", err)
@@ -53,3 +201,347 @@ func (a *appsec) GetRules(ctx context.Context, configID, versionNumber int, poli
 
 	return &rules, nil
 }
+
+func (a *appsec) UpdateRuleAction(ctx context.Context, params UpdateRuleActionRequest) (*UpdateRuleActionResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	var rval UpdateRuleActionResponse
+
+	logger := a.Log(ctx)
+	logger.Debug("UpdateRuleAction")
+
+	uri := fmt.Sprintf("/appsec/v1/configs/%d/versions/%d/security-policies/%s/rules/%d",
+		params.ConfigID, params.Version, params.PolicyID, params.RuleID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create updateruleaction request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &rval, params)
+	if err != nil {
+		return nil, fmt.Errorf("updateruleaction request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.Error(resp)
+	}
+
+	return &rval, nil
+}
+
+func (a *appsec) GetRule(ctx context.Context, params GetRuleRequest) (*Rule, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	var rule Rule
+
+	logger := a.Log(ctx)
+	logger.Debug("GetRule")
+
+	uri := fmt.Sprintf("/appsec/v1/configs/%d/versions/%d/security-policies/%s/rules/%d",
+		params.ConfigID, params.Version, params.PolicyID, params.RuleID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create getrule request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &rule)
+	if err != nil {
+		return nil, fmt.Errorf("getrule request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.Error(resp)
+	}
+
+	return &rule, nil
+}
+
+func (a *appsec) GetRuleConditionException(ctx context.Context, params GetRuleRequest) (*ConditionException, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	var ce ConditionException
+
+	logger := a.Log(ctx)
+	logger.Debug("GetRuleConditionException")
+
+	uri := fmt.Sprintf("/appsec/v1/configs/%d/versions/%d/security-policies/%s/rules/%d/condition-exception",
+		params.ConfigID, params.Version, params.PolicyID, params.RuleID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create getruleconditionexception request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &ce)
+	if err != nil {
+		return nil, fmt.Errorf("getruleconditionexception request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.Error(resp)
+	}
+
+	return &ce, nil
+}
+
+func (a *appsec) UpdateRuleConditionException(ctx context.Context, params UpdateRuleConditionExceptionRequest) (*ConditionException, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	var ce ConditionException
+
+	logger := a.Log(ctx)
+	logger.Debug("UpdateRuleConditionException")
+
+	uri := fmt.Sprintf("/appsec/v1/configs/%d/versions/%d/security-policies/%s/rules/%d/condition-exception",
+		params.ConfigID, params.Version, params.PolicyID, params.RuleID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create updateruleconditionexception request: %w", err)
+	}
+
+	resp, err := a.Exec(req, &ce, params)
+	if err != nil {
+		return nil, fmt.Errorf("updateruleconditionexception request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.Error(resp)
+	}
+
+	return &ce, nil
+}
+
+// ExcludeNames adds names of the part of the request picked by selector to the exception,
+// e.g. ExcludeNames(SelectorCookies, "session") makes the rule ignore the session cookie
+func (c *ConditionException) ExcludeNames(selector string, names ...string) {
+	if c.Exception == nil {
+		c.Exception = &Exception{}
+	}
+	for _, s := range c.Exception.SpecificHeaderCookieOrParamNames {
+		if s.Selector != selector {
+			continue
+		}
+		for _, name := range names {
+			if !containsString(s.Names, name) {
+				s.Names = append(s.Names, name)
+			}
+		}
+		return
+	}
+	c.Exception.SpecificHeaderCookieOrParamNames = append(c.Exception.SpecificHeaderCookieOrParamNames,
+		&SpecificNames{Names: append([]string(nil), names...), Selector: selector})
+}
+
+// PlanUpdateRuleActions returns the rule actions UpdateRuleActions would change, by rule ID
+func PlanUpdateRuleActions(ctx context.Context, client Rules, params UpdateRuleActionsRequest) ([]*RuleActionChange, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debug("PlanUpdateRuleActions")
+
+	rules, err := client.GetRules(ctx, params.ConfigID, params.Version, params.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []*RuleActionChange{}
+	for _, r := range rules.RuleActions {
+		if to, ok := params.ActionMap[r.Action]; ok && to != r.Action {
+			changes = append(changes, &RuleActionChange{ID: r.ID, From: r.Action, To: to})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+	return changes, nil
+}
+
+// UpdateRuleActions applies params.ActionMap to the rules of a policy, a call per rule to change.
+// It returns the changes applied, up to the failed one when a call fails
+func UpdateRuleActions(ctx context.Context, client Rules, params UpdateRuleActionsRequest) ([]*RuleActionChange, error) {
+	changes, err := PlanUpdateRuleActions(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debugf("UpdateRuleActions: %d rules to change", len(changes))
+
+	for i, c := range changes {
+		if _, err := client.UpdateRuleAction(ctx, UpdateRuleActionRequest{
+			ConfigID: params.ConfigID,
+			Version:  params.Version,
+			PolicyID: params.PolicyID,
+			RuleID:   c.ID,
+			Action:   c.To,
+		}); err != nil {
+			return changes[:i], fmt.Errorf("rule %d: %w", c.ID, err)
+		}
+	}
+	return changes, nil
+}
+
+// CustomDenyAction returns the action denying requests with the custom deny response of the given ID
+func CustomDenyAction(id int) string {
+	return customDenyPrefix + strconv.Itoa(id)
+}
+
+// ValidateRuleAction checks that action is alert, deny, none or a custom deny action
+func ValidateRuleAction(action string) error {
+	switch action {
+	case RuleActionAlert, RuleActionDeny, RuleActionNone:
+		return nil
+	}
+	if id := strings.TrimPrefix(action, customDenyPrefix); id != action {
+		if n, err := strconv.Atoi(id); err == nil && n > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid rule action %q, want alert, deny, none or deny_custom_{id}", action)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleAction is a validation rule calling ValidateRuleAction
+var ruleAction = validation.By(func(value interface{}) error {
+	action, ok := value.(string)
+	if !ok {
+		return errors.New("must be a string")
+	}
+	return ValidateRuleAction(action)
+})
+
+// Validate validates UpdateRuleActionRequest
+func (v UpdateRuleActionRequest) Validate() error {
+	return validation.Errors{
+		"configId": validation.Validate(v.ConfigID, validation.Required),
+		"version":  validation.Validate(v.Version, validation.Required, validation.Min(1)),
+		"policyId": validation.Validate(v.PolicyID, validation.Required),
+		"ruleId":   validation.Validate(v.RuleID, validation.Required),
+		"action":   validation.Validate(v.Action, validation.Required, ruleAction),
+	}.Filter()
+}
+
+// Validate validates UpdateRuleActionsRequest
+func (v UpdateRuleActionsRequest) Validate() error {
+	return validation.Errors{
+		"configId": validation.Validate(v.ConfigID, validation.Required),
+		"version":  validation.Validate(v.Version, validation.Required, validation.Min(1)),
+		"policyId": validation.Validate(v.PolicyID, validation.Required),
+		"actionMap": validation.Validate(v.ActionMap, validation.Required, validation.By(func(interface{}) error {
+			from := make([]string, 0, len(v.ActionMap))
+			for action := range v.ActionMap {
+				from = append(from, action)
+			}
+			sort.Strings(from)
+			for _, action := range from {
+				if err := ValidateRuleAction(action); err != nil {
+					return err
+				}
+				if err := ValidateRuleAction(v.ActionMap[action]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})),
+	}.Filter()
+}
+
+// Validate validates GetRuleRequest
+func (v GetRuleRequest) Validate() error {
+	return validation.Errors{
+		"configId": validation.Validate(v.ConfigID, validation.Required),
+		"version":  validation.Validate(v.Version, validation.Required, validation.Min(1)),
+		"policyId": validation.Validate(v.PolicyID, validation.Required),
+		"ruleId":   validation.Validate(v.RuleID, validation.Required),
+	}.Filter()
+}
+
+// Validate validates UpdateRuleConditionExceptionRequest
+func (v UpdateRuleConditionExceptionRequest) Validate() error {
+	return validation.Errors{
+		"configId":           validation.Validate(v.ConfigID, validation.Required),
+		"version":            validation.Validate(v.Version, validation.Required, validation.Min(1)),
+		"policyId":           validation.Validate(v.PolicyID, validation.Required),
+		"ruleId":             validation.Validate(v.RuleID, validation.Required),
+		"conditionException": v.ConditionException.Validate(),
+	}.Filter()
+}
+
+// Validate validates ConditionException
+func (v ConditionException) Validate() error {
+	errs := validation.Errors{}
+	for i, c := range v.Conditions {
+		if c == nil {
+			errs[fmt.Sprintf("conditions[%d]", i)] = errors.New("is nil")
+			continue
+		}
+		if err := c.Validate(); err != nil {
+			errs[fmt.Sprintf("conditions[%d]", i)] = err
+		}
+	}
+	if v.Exception != nil {
+		for i, s := range v.Exception.SpecificHeaderCookieOrParamNames {
+			if s == nil {
+				errs[fmt.Sprintf("exception.specificHeaderCookieOrParamNames[%d]", i)] = errors.New("is nil")
+				continue
+			}
+			errs[fmt.Sprintf("exception.specificHeaderCookieOrParamNames[%d]", i)] = validation.Errors{
+				"names": validation.Validate(s.Names, validation.Required, validation.Each(validation.Required)),
+				"selector": validation.Validate(s.Selector, validation.Required,
+					validation.In(SelectorCookies, SelectorHeaders, SelectorArgs, SelectorJSONPairs, SelectorXMLPairs)),
+			}.Filter()
+		}
+		errs["exception.headerCookieOrParamValues"] = validation.Validate(v.Exception.HeaderCookieOrParamValues,
+			validation.Each(validation.Required))
+	}
+	return errs.Filter()
+}
+
+// Validate validates Condition, the field matching its type is required
+func (v Condition) Validate() error {
+	var (
+		field string
+		value interface{}
+	)
+	switch v.Type {
+	case ConditionPath:
+		field, value = "paths", v.Paths
+	case ConditionHost:
+		field, value = "hosts", v.Hosts
+	case ConditionIP:
+		field, value = "ips", v.IPs
+	case ConditionRequestMethod:
+		field, value = "methods", v.Methods
+	case ConditionExtension:
+		field, value = "extensions", v.Extensions
+	case ConditionRequestHeader:
+		field, value = "header", v.Header
+	default:
+		return validation.Errors{
+			"type": validation.Validate(v.Type, validation.Required, validation.In(ConditionPath, ConditionHost,
+				ConditionIP, ConditionRequestMethod, ConditionExtension, ConditionRequestHeader)),
+		}.Filter()
+	}
+	return validation.Errors{
+		field: validation.Validate(value, validation.Required),
+	}.Filter()
+}
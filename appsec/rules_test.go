@@ -0,0 +1,364 @@
+package appsec_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/akamai-playground/appsec"
+)
+
+func TestValidateRuleAction(t *testing.T) {
+	tests := map[string]struct {
+		action  string
+		withErr bool
+	}{
+		"alert":              {action: "alert"},
+		"deny":               {action: "deny"},
+		"none":               {action: "none"},
+		"custom deny":        {action: appsec.CustomDenyAction(622918)},
+		"custom deny no ID":  {action: "deny_custom_", withErr: true},
+		"custom deny bad ID": {action: "deny_custom_abc", withErr: true},
+		"upper case":         {action: "DENY", withErr: true},
+		"unknown":            {action: "block", withErr: true},
+		"empty":              {action: "", withErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := appsec.ValidateRuleAction(test.action)
+			if test.withErr != (err != nil) {
+				t.Errorf("ValidateRuleAction(%q) = %v, want error: %t", test.action, err, test.withErr)
+			}
+		})
+	}
+}
+
+func TestUpdateRuleActions(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	ctx := context.Background()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+	if err := srv.AppSec.AddPolicy(config.ID, 1, appsec.Policies{PolicyID: "POL_1", PolicyName: "Default"}); err != nil {
+		t.Fatal(err)
+	}
+	rules := []appsec.RuleActions{{ID: 950004, Action: "alert"}, {ID: 950002, Action: "deny"}, {ID: 950003, Action: "alert"}, {ID: 950001, Action: "none"}}
+	if err := srv.AppSec.SetRules(config.ID, 1, "POL_1", rules); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.AppSec.SetActiveVersion(config.ID, 1, appsec.PRODUCTION); err != nil {
+		t.Fatal(err)
+	}
+
+	draft, err := appsec.CloneActiveVersion(ctx, client, appsec.CloneActiveVersionRequest{ConfigID: config.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := appsec.UpdateRuleActionsRequest{
+		ConfigID:  config.ID,
+		Version:   draft.Version,
+		PolicyID:  "POL_1",
+		ActionMap: map[string]string{appsec.RuleActionAlert: appsec.RuleActionDeny},
+	}
+
+	plan, err := appsec.PlanUpdateRuleActions(ctx, client, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*appsec.RuleActionChange{{ID: 950003, From: "alert", To: "deny"}, {ID: 950004, From: "alert", To: "deny"}}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("PlanUpdateRuleActions() = %v, want %v", plan, want)
+	}
+
+	changes, err := appsec.UpdateRuleActions(ctx, client, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("UpdateRuleActions() = %v, want %v", changes, want)
+	}
+
+	custom := appsec.CustomDenyAction(622918)
+	out, err := client.UpdateRuleAction(ctx, appsec.UpdateRuleActionRequest{
+		ConfigID: config.ID, Version: draft.Version, PolicyID: "POL_1", RuleID: 950001, Action: custom,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Action != custom {
+		t.Errorf("UpdateRuleAction() = %q, want %q", out.Action, custom)
+	}
+
+	got, err := client.GetRules(ctx, config.ID, draft.Version, "POL_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := make(map[int]string)
+	for _, r := range got.RuleActions {
+		actions[r.ID] = r.Action
+	}
+	wantActions := map[int]string{950001: custom, 950002: "deny", 950003: "deny", 950004: "deny"}
+	if !reflect.DeepEqual(actions, wantActions) {
+		t.Errorf("GetRules() = %v, want %v", actions, wantActions)
+	}
+}
+
+func TestUpdateRuleActionsErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+	if err := srv.AppSec.AddPolicy(config.ID, 1, appsec.Policies{PolicyID: "POL_1", PolicyName: "Default"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.AppSec.SetRules(config.ID, 1, "POL_1", []appsec.RuleActions{{ID: 950002, Action: "alert"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.AppSec.SetActiveVersion(config.ID, 1, appsec.PRODUCTION); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		params appsec.UpdateRuleActionsRequest
+		want   error
+	}{
+		"invalid action map": {
+			params: appsec.UpdateRuleActionsRequest{ConfigID: config.ID, Version: 1, PolicyID: "POL_1", ActionMap: map[string]string{"alert": "deny_custom_"}},
+			want:   appsec.ErrStructValidation,
+		},
+		"activated version": {
+			params: appsec.UpdateRuleActionsRequest{ConfigID: config.ID, Version: 1, PolicyID: "POL_1", ActionMap: map[string]string{"alert": "deny"}},
+			want:   appsec.ErrValidation,
+		},
+		"missing policy": {
+			params: appsec.UpdateRuleActionsRequest{ConfigID: config.ID, Version: 1, PolicyID: "POL_2", ActionMap: map[string]string{"alert": "deny"}},
+			want:   appsec.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := appsec.UpdateRuleActions(context.Background(), client, test.params); !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestUpdateRuleActionErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+	if err := srv.AppSec.AddPolicy(config.ID, 1, appsec.Policies{PolicyID: "POL_1", PolicyName: "Default"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.AppSec.SetRules(config.ID, 1, "POL_1", []appsec.RuleActions{{ID: 950001, Action: "none"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		params appsec.UpdateRuleActionRequest
+		want   error
+	}{
+		"invalid action": {
+			params: appsec.UpdateRuleActionRequest{ConfigID: config.ID, Version: 1, PolicyID: "POL_1", RuleID: 950001, Action: "block"},
+			want:   appsec.ErrStructValidation,
+		},
+		"missing rule": {
+			params: appsec.UpdateRuleActionRequest{ConfigID: config.ID, Version: 1, PolicyID: "POL_1", RuleID: 1, Action: "deny"},
+			want:   appsec.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := client.UpdateRuleAction(context.Background(), test.params); !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestConditionValidate(t *testing.T) {
+	tests := map[string]struct {
+		condition appsec.Condition
+		withErr   bool
+	}{
+		"path":              {condition: appsec.Condition{Type: appsec.ConditionPath, Paths: []string{"/login"}}},
+		"header":            {condition: appsec.Condition{Type: appsec.ConditionRequestHeader, Header: "X-Debug", Value: []string{"1"}}},
+		"ip":                {condition: appsec.Condition{Type: appsec.ConditionIP, PositiveMatch: true, IPs: []string{"10.0.0.0/8"}}},
+		"path without path": {condition: appsec.Condition{Type: appsec.ConditionPath, Hosts: []string{"example.com"}}, withErr: true},
+		"header without name": {
+			condition: appsec.Condition{Type: appsec.ConditionRequestHeader, Value: []string{"1"}},
+			withErr:   true,
+		},
+		"unknown type": {condition: appsec.Condition{Type: "cookieMatch", Value: []string{"1"}}, withErr: true},
+		"no type":      {condition: appsec.Condition{Paths: []string{"/"}}, withErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.condition.Validate()
+			if test.withErr != (err != nil) {
+				t.Errorf("Validate() = %v, want error: %t", err, test.withErr)
+			}
+		})
+	}
+}
+
+func TestRuleConditionException(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	ctx := context.Background()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+	if err := srv.AppSec.AddPolicy(config.ID, 1, appsec.Policies{PolicyID: "POL_1", PolicyName: "Default"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.AppSec.SetRules(config.ID, 1, "POL_1", []appsec.RuleActions{{ID: 950002, Action: "deny"}}); err != nil {
+		t.Fatal(err)
+	}
+	srv.AppSec.DefineRule(appsec.Rule{ID: 950002, Title: "System Command Access", Tags: []string{"OWASP_CRS/WEB_ATTACK/COMMAND_INJECTION"}, AttackGroup: "CMD"})
+	rule := appsec.GetRuleRequest{ConfigID: config.ID, Version: 1, PolicyID: "POL_1", RuleID: 950002}
+
+	got, err := client.GetRule(ctx, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := appsec.Rule{ID: 950002, Title: "System Command Access", Tags: []string{"OWASP_CRS/WEB_ATTACK/COMMAND_INJECTION"}, AttackGroup: "CMD", Action: "deny"}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("GetRule() = %+v, want %+v", got, want)
+	}
+
+	ce, err := client.GetRuleConditionException(ctx, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ce.Conditions) != 0 || ce.Exception != nil {
+		t.Errorf("GetRuleConditionException() = %+v, want none", ce)
+	}
+
+	// exclude the session cookie and the X-Debug header from the rule on /login
+	ce.Conditions = append(ce.Conditions, &appsec.Condition{Type: appsec.ConditionPath, PositiveMatch: true, Paths: []string{"/login"}})
+	ce.ExcludeNames(appsec.SelectorCookies, "session")
+	ce.ExcludeNames(appsec.SelectorHeaders, "X-Debug")
+	ce.ExcludeNames(appsec.SelectorCookies, "session", "tracking")
+	updated, err := client.UpdateRuleConditionException(ctx, appsec.UpdateRuleConditionExceptionRequest{
+		ConfigID: config.ID, Version: 1, PolicyID: "POL_1", RuleID: 950002, ConditionException: *ce,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCE := appsec.ConditionException{
+		Conditions: []*appsec.Condition{{Type: appsec.ConditionPath, PositiveMatch: true, Paths: []string{"/login"}}},
+		Exception: &appsec.Exception{SpecificHeaderCookieOrParamNames: []*appsec.SpecificNames{
+			{Names: []string{"session", "tracking"}, Selector: appsec.SelectorCookies},
+			{Names: []string{"X-Debug"}, Selector: appsec.SelectorHeaders},
+		}},
+	}
+	if !reflect.DeepEqual(*updated, wantCE) {
+		t.Errorf("UpdateRuleConditionException() = %+v, want %+v", updated, wantCE)
+	}
+
+	got, err = client.GetRule(ctx, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ConditionException == nil || !reflect.DeepEqual(*got.ConditionException, wantCE) {
+		t.Errorf("GetRule() conditionException = %+v, want %+v", got.ConditionException, wantCE)
+	}
+
+	// exceptions follow the rules into new versions
+	if _, err := client.CreateConfigVersion(ctx, appsec.CreateConfigVersionRequest{ConfigID: config.ID, CreateFromVersion: 1}); err != nil {
+		t.Fatal(err)
+	}
+	cloned, err := client.GetRuleConditionException(ctx, appsec.GetRuleRequest{ConfigID: config.ID, Version: 2, PolicyID: "POL_1", RuleID: 950002})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*cloned, wantCE) {
+		t.Errorf("GetRuleConditionException() of the clone = %+v, want %+v", cloned, wantCE)
+	}
+}
+
+func TestGetRuleErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+	if err := srv.AppSec.AddPolicy(config.ID, 1, appsec.Policies{PolicyID: "POL_1", PolicyName: "Default"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		params appsec.GetRuleRequest
+		want   error
+	}{
+		"without rule": {
+			params: appsec.GetRuleRequest{ConfigID: config.ID, Version: 1, PolicyID: "POL_1"},
+			want:   appsec.ErrStructValidation,
+		},
+		"missing rule": {
+			params: appsec.GetRuleRequest{ConfigID: config.ID, Version: 1, PolicyID: "POL_1", RuleID: 1},
+			want:   appsec.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := client.GetRule(context.Background(), test.params); !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestUpdateRuleConditionExceptionErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+	if err := srv.AppSec.AddPolicy(config.ID, 1, appsec.Policies{PolicyID: "POL_1", PolicyName: "Default"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.AppSec.SetRules(config.ID, 1, "POL_1", []appsec.RuleActions{{ID: 950002, Action: "deny"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.AppSec.SetActiveVersion(config.ID, 1, appsec.STAGING); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateConfigVersion(context.Background(), appsec.CreateConfigVersionRequest{ConfigID: config.ID, CreateFromVersion: 1}); err != nil {
+		t.Fatal(err)
+	}
+	unknownSelector := appsec.ConditionException{}
+	unknownSelector.ExcludeNames("REQUEST_BODY", "password")
+
+	tests := map[string]struct {
+		version int
+		ce      appsec.ConditionException
+		want    error
+	}{
+		"condition without paths": {
+			version: 2,
+			ce:      appsec.ConditionException{Conditions: []*appsec.Condition{{Type: appsec.ConditionPath}}},
+			want:    appsec.ErrStructValidation,
+		},
+		"unknown selector": {
+			version: 2,
+			ce:      unknownSelector,
+			want:    appsec.ErrStructValidation,
+		},
+		"activated version": {
+			version: 1,
+			want:    appsec.ErrValidation,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := client.UpdateRuleConditionException(context.Background(), appsec.UpdateRuleConditionExceptionRequest{
+				ConfigID: config.ID, Version: test.version, PolicyID: "POL_1", RuleID: 950002, ConditionException: test.ce,
+			})
+			if !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package tools forwards to github.com/akamai-playground/tools, where the
+// account switching, retry and error kind helpers moved to be shared by the
+// netlist and appsec clients. New code should import that package instead
+package tools
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/akamai-playground/tools"
+)
+
+type (
+	// RetryPolicy is tools.RetryPolicy
+	RetryPolicy = tools.RetryPolicy
+
+	// ExecFunc is tools.ExecFunc
+	ExecFunc = tools.ExecFunc
+)
+
+// Kinds of API errors, they are the errors of the tools package
+var (
+	// ErrNotFound is tools.ErrNotFound
+	ErrNotFound = tools.ErrNotFound
+	// ErrConflict is tools.ErrConflict
+	ErrConflict = tools.ErrConflict
+	// ErrValidation is tools.ErrValidation
+	ErrValidation = tools.ErrValidation
+	// ErrForbidden is tools.ErrForbidden
+	ErrForbidden = tools.ErrForbidden
+	// ErrRateLimited is tools.ErrRateLimited
+	ErrRateLimited = tools.ErrRateLimited
+	// ErrServerError is tools.ErrServerError
+	ErrServerError = tools.ErrServerError
+)
+
+// DefaultRetryPolicy is tools.DefaultRetryPolicy
+var DefaultRetryPolicy = tools.DefaultRetryPolicy
+
+// CheckAccountID adds the accountSwitchKey query parameter to the request
+// when accountID is a non empty string, see tools.CheckAccountID
+func CheckAccountID(accountID interface{}, req *http.Request) {
+	tools.CheckAccountID(accountID, req)
+}
+
+// ContextWithAccountSwitchKey calls tools.ContextWithAccountSwitchKey
+func ContextWithAccountSwitchKey(ctx context.Context, key string) context.Context {
+	return tools.ContextWithAccountSwitchKey(ctx, key)
+}
+
+// AccountSwitchKey calls tools.AccountSwitchKey
+func AccountSwitchKey(req *http.Request, clientKey string) string {
+	return tools.AccountSwitchKey(req, clientKey)
+}
+
+// ErrorKind calls tools.ErrorKind
+func ErrorKind(statusCode int, problemType string) error {
+	return tools.ErrorKind(statusCode, problemType)
+}
+
+// IsIdempotent calls tools.IsIdempotent
+func IsIdempotent(method string) bool {
+	return tools.IsIdempotent(method)
+}
+
+// RetryDelay calls tools.RetryDelay
+func RetryDelay(resp *http.Response) (time.Duration, bool) {
+	return tools.RetryDelay(resp)
+}
@@ -0,0 +1,26 @@
+package tools_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/akamai-playground/appsec/tools"
+)
+
+func TestCheckAccountID(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/appsec/v1/configs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools.CheckAccountID("1-ABCD", req)
+	if got := req.URL.Query().Get("accountSwitchKey"); got != "1-ABCD" {
+		t.Errorf("accountSwitchKey = %q, want 1-ABCD", got)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	if err := tools.ErrorKind(http.StatusNotFound, ""); !errors.Is(err, tools.ErrNotFound) {
+		t.Errorf("ErrorKind(404) = %v, want %v", err, tools.ErrNotFound)
+	}
+}
@@ -0,0 +1,294 @@
+package appsec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/apex/log"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// ErrNoActiveVersion is returned by CloneActiveVersion when no version is active on the network
+var ErrNoActiveVersion = errors.New("no active version")
+
+type (
+	// CloneActiveVersionRequest describes the version CloneActiveVersion creates
+	CloneActiveVersionRequest struct {
+		ConfigID int
+		// Environment is the network whose active version is cloned, PRODUCTION when unset
+		Environment Environment
+		RuleUpdate  bool
+	}
+
+	// DiffConfigVersionsRequest selects the versions DiffConfigVersions compares
+	DiffConfigVersionsRequest struct {
+		ConfigID int
+		From     int
+		To       int
+	}
+
+	// VersionDiff describes the policies and rule actions which differ between two versions
+	VersionDiff struct {
+		ConfigID        int           `json:"configId"`
+		From            int           `json:"from"`
+		To              int           `json:"to"`
+		AddedPolicies   []*Policies   `json:"addedPolicies,omitempty"`
+		RemovedPolicies []*Policies   `json:"removedPolicies,omitempty"`
+		ChangedPolicies []*PolicyDiff `json:"changedPolicies,omitempty"`
+	}
+
+	// PolicyDiff describes the changes of a policy present in both versions
+	PolicyDiff struct {
+		PolicyID         string              `json:"policyId"`
+		Name             *FieldChange        `json:"name,omitempty"`
+		SecurityControls []ControlChange     `json:"securityControls,omitempty"`
+		RuleActions      []*RuleActionChange `json:"ruleActions,omitempty"`
+	}
+
+	// FieldChange holds the value of a field in both versions
+	FieldChange struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+
+	// ControlChange is a security control switched on or off
+	ControlChange struct {
+		Control string `json:"control"`
+		From    bool   `json:"from"`
+		To      bool   `json:"to"`
+	}
+
+	// RuleActionChange is the action of a rule in both versions,
+	// From is empty for a rule the policy gained and To for a rule it lost
+	RuleActionChange struct {
+		ID   int    `json:"id"`
+		From string `json:"from,omitempty"`
+		To   string `json:"to,omitempty"`
+	}
+
+	logProvider interface {
+		Log(ctx context.Context) log.Interface
+	}
+)
+
+// CloneActiveVersion creates a version cloned from the version active on params.Environment.
+// It returns ErrNoActiveVersion when the configuration is not active there
+func CloneActiveVersion(ctx context.Context, client ConfigVersions, params CloneActiveVersionRequest) (*ConfigVersion, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debug("CloneActiveVersion")
+
+	versions, err := client.GetConfigVersions(ctx, GetConfigVersionsRequest{ConfigID: params.ConfigID, PageSize: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	active := versions.ProductionActiveVersion
+	if params.Environment == STAGING {
+		active = versions.StagingActiveVersion
+	}
+	if active == 0 {
+		return nil, fmt.Errorf("%w on %s for configuration %d", ErrNoActiveVersion, params.environment(), params.ConfigID)
+	}
+	logger.Debugf("cloning version %d active on %s", active, params.environment())
+
+	return client.CreateConfigVersion(ctx, CreateConfigVersionRequest{
+		ConfigID:          params.ConfigID,
+		CreateFromVersion: active,
+		RuleUpdate:        params.RuleUpdate,
+	})
+}
+
+// DiffConfigVersions compares the security policies of two versions and the rule actions
+// of the policies present in both. It costs a rules call per policy of each version
+func DiffConfigVersions(ctx context.Context, client APPSEC, params DiffConfigVersionsRequest) (*VersionDiff, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debug("DiffConfigVersions")
+
+	from, err := client.GetPolicies(ctx, params.ConfigID, params.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := client.GetPolicies(ctx, params.ConfigID, params.To)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := VersionDiff{ConfigID: params.ConfigID, From: params.From, To: params.To}
+	before := make(map[string]*Policies, len(from.Policies))
+	for _, p := range from.Policies {
+		before[p.PolicyID] = p
+	}
+	after := make(map[string]bool, len(to.Policies))
+	for _, p := range to.Policies {
+		after[p.PolicyID] = true
+		old, ok := before[p.PolicyID]
+		if !ok {
+			diff.AddedPolicies = append(diff.AddedPolicies, p)
+			continue
+		}
+
+		pd, err := diffPolicy(ctx, client, params, old, p)
+		if err != nil {
+			return nil, err
+		}
+		if pd != nil {
+			diff.ChangedPolicies = append(diff.ChangedPolicies, pd)
+		}
+	}
+	for _, p := range from.Policies {
+		if !after[p.PolicyID] {
+			diff.RemovedPolicies = append(diff.RemovedPolicies, p)
+		}
+	}
+	return &diff, nil
+}
+
+// diffPolicy returns the changes of a policy between two versions, nil when there are none
+func diffPolicy(ctx context.Context, client Rules, params DiffConfigVersionsRequest, from, to *Policies) (*PolicyDiff, error) {
+	pd := PolicyDiff{PolicyID: to.PolicyID}
+	if from.PolicyName != to.PolicyName {
+		pd.Name = &FieldChange{From: from.PolicyName, To: to.PolicyName}
+	}
+
+	before, after := from.PolicySecurityControls.controls(), to.PolicySecurityControls.controls()
+	names := make([]string, 0, len(after))
+	for name := range after {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if before[name] != after[name] {
+			pd.SecurityControls = append(pd.SecurityControls, ControlChange{Control: name, From: before[name], To: after[name]})
+		}
+	}
+
+	oldRules, err := client.GetRules(ctx, params.ConfigID, params.From, from.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+	newRules, err := client.GetRules(ctx, params.ConfigID, params.To, to.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+	actions := make(map[int]*RuleActionChange)
+	for _, r := range oldRules.RuleActions {
+		actions[r.ID] = &RuleActionChange{ID: r.ID, From: r.Action}
+	}
+	for _, r := range newRules.RuleActions {
+		if c, ok := actions[r.ID]; ok {
+			c.To = r.Action
+			continue
+		}
+		actions[r.ID] = &RuleActionChange{ID: r.ID, To: r.Action}
+	}
+	for _, c := range actions {
+		if c.From != c.To {
+			pd.RuleActions = append(pd.RuleActions, c)
+		}
+	}
+	sort.Slice(pd.RuleActions, func(i, j int) bool { return pd.RuleActions[i].ID < pd.RuleActions[j].ID })
+
+	if pd.Name == nil && len(pd.SecurityControls) == 0 && len(pd.RuleActions) == 0 {
+		return nil, nil
+	}
+	return &pd, nil
+}
+
+// Empty reports whether both versions hold the same policies and rule actions
+func (d *VersionDiff) Empty() bool {
+	return len(d.AddedPolicies) == 0 && len(d.RemovedPolicies) == 0 && len(d.ChangedPolicies) == 0
+}
+
+// String renders the diff for humans, one change per line
+func (d *VersionDiff) String() string {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "configuration %d: version %d -> version %d\n", d.ConfigID, d.From, d.To)
+	for _, p := range d.AddedPolicies {
+		fmt.Fprintf(&b, "  + policy %s (%s)\n", p.PolicyID, p.PolicyName)
+	}
+	for _, p := range d.RemovedPolicies {
+		fmt.Fprintf(&b, "  - policy %s (%s)\n", p.PolicyID, p.PolicyName)
+	}
+	rules := 0
+	for _, p := range d.ChangedPolicies {
+		fmt.Fprintf(&b, "  ~ policy %s\n", p.PolicyID)
+		if p.Name != nil {
+			fmt.Fprintf(&b, "    ~ name: %q -> %q\n", p.Name.From, p.Name.To)
+		}
+		for _, c := range p.SecurityControls {
+			fmt.Fprintf(&b, "    ~ %s: %t -> %t\n", c.Control, c.From, c.To)
+		}
+		for _, r := range p.RuleActions {
+			switch {
+			case r.From == "":
+				fmt.Fprintf(&b, "    + rule %d: %s\n", r.ID, r.To)
+			case r.To == "":
+				fmt.Fprintf(&b, "    - rule %d: %s\n", r.ID, r.From)
+			default:
+				fmt.Fprintf(&b, "    ~ rule %d: %s -> %s\n", r.ID, r.From, r.To)
+			}
+		}
+		rules += len(p.RuleActions)
+	}
+
+	fmt.Fprintf(&b, "Diff: %d policies added, %d removed, %d changed, %d rule actions changed.\n",
+		len(d.AddedPolicies), len(d.RemovedPolicies), len(d.ChangedPolicies), rules)
+	return b.String()
+}
+
+// controls returns the security controls by their JSON name
+func (c PolicySecurityControls) controls() map[string]bool {
+	return map[string]bool{
+		"applyApplicationLayerControls": c.ApplyApplicationLayerControls,
+		"applyNetworkLayerControls":     c.ApplyNetworkLayerControls,
+		"applyRateControls":             c.ApplyRateControls,
+		"applyReputationControls":       c.ApplyReputationControls,
+		"applyBotmanControls":           c.ApplyBotmanControls,
+		"applyApiConstraints":           c.ApplyAPIConstraints,
+		"applySlowPostControls":         c.ApplySlowPostControls,
+	}
+}
+
+func (v CloneActiveVersionRequest) environment() Environment {
+	if v.Environment == 0 {
+		return PRODUCTION
+	}
+	return v.Environment
+}
+
+// loggerFor returns the logger of the client when it has one
+func loggerFor(ctx context.Context, client interface{}) log.Interface {
+	if l, ok := client.(logProvider); ok {
+		return l.Log(ctx)
+	}
+	return log.FromContext(ctx)
+}
+
+// Validate validates CloneActiveVersionRequest
+func (v CloneActiveVersionRequest) Validate() error {
+	return validation.Errors{
+		"configId":    validation.Validate(v.ConfigID, validation.Required),
+		"environment": validation.Validate(v.Environment, validation.In(STAGING, PRODUCTION)),
+	}.Filter()
+}
+
+// Validate validates DiffConfigVersionsRequest
+func (v DiffConfigVersionsRequest) Validate() error {
+	return validation.Errors{
+		"configId": validation.Validate(v.ConfigID, validation.Required),
+		"from":     validation.Validate(v.From, validation.Required, validation.Min(1)),
+		"to":       validation.Validate(v.To, validation.Required, validation.Min(1)),
+	}.Filter()
+}
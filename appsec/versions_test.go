@@ -0,0 +1,167 @@
+package appsec_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/akamai-playground/appsec"
+)
+
+func TestConfigVersions(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	ctx := context.Background()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+	for _, p := range []appsec.Policies{{PolicyID: "POL_1", PolicyName: "Default"}, {PolicyID: "POL_2", PolicyName: "API"}} {
+		if err := srv.AppSec.AddPolicy(config.ID, 1, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rules := []appsec.RuleActions{{ID: 950002, Action: "deny"}, {ID: 950006, Action: "alert"}}
+	if err := srv.AppSec.SetRules(config.ID, 1, "POL_1", rules); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.AppSec.SetActiveVersion(config.ID, 1, appsec.PRODUCTION); err != nil {
+		t.Fatal(err)
+	}
+
+	cloned, err := appsec.CloneActiveVersion(ctx, client, appsec.CloneActiveVersionRequest{ConfigID: config.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cloned.Version != 2 || cloned.BasedOn != 1 || cloned.ConfigID != config.ID || cloned.Production.Status != "Inactive" {
+		t.Errorf("CloneActiveVersion() = %+v, want inactive version 2 based on 1", cloned)
+	}
+
+	got, err := client.GetConfigVersion(ctx, appsec.GetConfigVersionRequest{ConfigID: config.ID, Version: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 1 || got.ConfigName != "Site" || got.Production.Status != "Active" {
+		t.Errorf("GetConfigVersion() = %+v, want version 1 active on production", got)
+	}
+
+	// version 2 gets a new rule action, loses POL_2 and gains POL_3
+	if err := srv.AppSec.SetRules(config.ID, 2, "POL_1", []appsec.RuleActions{{ID: 950002, Action: "alert"}, {ID: 950006, Action: "alert"}, {ID: 950007, Action: "deny"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.AppSec.AddPolicy(config.ID, 2, appsec.Policies{PolicyID: "POL_3", PolicyName: "Login"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateConfigVersion(ctx, appsec.CreateConfigVersionRequest{ConfigID: config.ID, CreateFromVersion: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := client.GetConfigVersions(ctx, appsec.GetConfigVersionsRequest{ConfigID: config.ID, PageSize: 2, Detail: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions.TotalSize != 3 || len(versions.VersionList) != 2 || versions.VersionList[0].Version != 3 ||
+		versions.VersionList[1].BasedOn != 1 || versions.ProductionActiveVersion != 1 {
+		t.Errorf("GetConfigVersions() = %+v, want versions 3 and 2 of 3", versions)
+	}
+
+	diff, err := appsec.DiffConfigVersions(ctx, client, appsec.DiffConfigVersionsRequest{ConfigID: config.ID, From: 1, To: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.AddedPolicies) != 1 || diff.AddedPolicies[0].PolicyID != "POL_3" ||
+		len(diff.RemovedPolicies) != 0 || len(diff.ChangedPolicies) != 1 {
+		t.Fatalf("DiffConfigVersions() = %+v, want POL_3 added and POL_1 changed", diff)
+	}
+	wantRules := []*appsec.RuleActionChange{{ID: 950002, From: "deny", To: "alert"}, {ID: 950007, To: "deny"}}
+	if !reflect.DeepEqual(diff.ChangedPolicies[0].RuleActions, wantRules) {
+		t.Errorf("rule actions diff = %v, want %v", diff.ChangedPolicies[0].RuleActions, wantRules)
+	}
+
+	same, err := appsec.DiffConfigVersions(ctx, client, appsec.DiffConfigVersionsRequest{ConfigID: config.ID, From: 1, To: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !same.Empty() {
+		t.Errorf("DiffConfigVersions() of a clone = %s, want no changes", same)
+	}
+}
+
+func TestCloneActiveVersionErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+	if err := srv.AppSec.SetActiveVersion(config.ID, 1, appsec.PRODUCTION); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		params appsec.CloneActiveVersionRequest
+		want   error
+	}{
+		"nothing active on staging": {
+			params: appsec.CloneActiveVersionRequest{ConfigID: config.ID, Environment: appsec.STAGING},
+			want:   appsec.ErrNoActiveVersion,
+		},
+		"missing configuration": {
+			params: appsec.CloneActiveVersionRequest{ConfigID: config.ID + 1},
+			want:   appsec.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := appsec.CloneActiveVersion(context.Background(), client, test.params); !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestGetConfigVersionErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+
+	_, err := client.GetConfigVersion(context.Background(), appsec.GetConfigVersionRequest{ConfigID: config.ID, Version: 9})
+	if !errors.Is(err, appsec.ErrNotFound) {
+		t.Errorf("error = %v, want %v", err, appsec.ErrNotFound)
+	}
+}
+
+func TestCreateConfigVersionErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+
+	_, err := client.CreateConfigVersion(context.Background(), appsec.CreateConfigVersionRequest{ConfigID: config.ID, CreateFromVersion: 9})
+	if !errors.Is(err, appsec.ErrValidation) {
+		t.Errorf("error = %v, want %v", err, appsec.ErrValidation)
+	}
+}
+
+func TestGetConfigVersionsErrors(t *testing.T) {
+	srv, client := fakeClient(t)
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+
+	tests := map[string]struct {
+		params appsec.GetConfigVersionsRequest
+		want   error
+	}{
+		"invalid page size": {
+			params: appsec.GetConfigVersionsRequest{ConfigID: config.ID, PageSize: -2},
+			want:   appsec.ErrStructValidation,
+		},
+		"missing configuration": {
+			params: appsec.GetConfigVersionsRequest{ConfigID: config.ID + 1},
+			want:   appsec.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := client.GetConfigVersions(context.Background(), test.params); !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,454 @@
+// Package expiry records when temporary network list elements elapse in a
+// local state file and removes them from their lists once they did
+package expiry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/akamai-playground/netlist"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// DefaultPath is the state file used by the CLI when none is given
+const DefaultPath = "netlist-expiry.json"
+
+type (
+	// Entry records a temporary element of a list
+	Entry struct {
+		NetworkListID string    `json:"networkListId"`
+		Element       string    `json:"element"`
+		AddedAt       time.Time `json:"addedAt"`
+		ExpiresAt     time.Time `json:"expiresAt"`
+	}
+
+	// Store keeps the entries of a state file, see Open, along with the lists
+	// whose elements were removed but which still have to be activated
+	Store struct {
+		path    string
+		mu      sync.Mutex
+		entries map[entryKey]Entry
+		pending map[string]bool
+	}
+
+	// ExpireRequest describes the elapsed entries to remove from their lists
+	ExpireRequest struct {
+		Store *Store
+		// NetworkListID limits the expiry to a list, every list of the store is expired when empty
+		NetworkListID string
+		// Now is the time entries elapse against, time.Now when zero
+		Now time.Time
+		// MaxRetries is the number of times a conflicting update is retried, see netlist.MutateNetworkListRequest
+		MaxRetries int
+		// Activate lists the environments to activate the lists on once elements were removed
+		Activate               []netlist.Environment
+		Comments               string
+		NotificationRecipients []string
+		// Wait blocks until every activation reaches a terminal status
+		Wait bool
+		// PollInterval is the initial delay between status checks with Wait
+		PollInterval time.Duration
+	}
+
+	// ExpireResult is the outcome of the expiry of a list
+	ExpireResult struct {
+		NetworkListID string
+		// Removed lists the elapsed elements removed from the list
+		Removed []string
+		// Missing lists the elapsed elements the list no longer held, they are only forgotten
+		Missing     []string
+		List        *netlist.NetworkListResponse
+		Activations []*netlist.ActivationNetworkListResponse
+	}
+
+	entryKey struct {
+		listID  string
+		element string
+	}
+
+	stateFile struct {
+		Entries []Entry `json:"entries"`
+		// PendingActivations lists the lists to activate on the next Expire
+		PendingActivations []string `json:"pendingActivations,omitempty"`
+	}
+)
+
+// Open reads the entries of the state file at path, a missing file holds none
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, entries: map[entryKey]Entry{}, pending: map[string]bool{}}
+
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state stateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for _, e := range state.Entries {
+		s.entries[entryKey{e.NetworkListID, e.Element}] = e
+	}
+	for _, id := range state.PendingActivations {
+		s.pending[id] = true
+	}
+	return s, nil
+}
+
+// Track records that the elements were added to the list at addedAt and elapse after ttl.
+// Tracking an element again replaces its expiry
+func (s *Store) Track(listID string, elements []string, addedAt time.Time, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range elements {
+		s.entries[entryKey{listID, e}] = Entry{
+			NetworkListID: listID,
+			Element:       e,
+			AddedAt:       addedAt.UTC(),
+			ExpiresAt:     addedAt.Add(ttl).UTC(),
+		}
+	}
+}
+
+// Untrack forgets the elements of the list
+func (s *Store) Untrack(listID string, elements ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range elements {
+		delete(s.entries, entryKey{listID, e})
+	}
+}
+
+// Entries returns every entry, sorted by list and expiry
+func (s *Store) Entries() []Entry {
+	return s.filter(func(Entry) bool { return true })
+}
+
+// Expired returns the entries elapsed at now, sorted by list and expiry
+func (s *Store) Expired(now time.Time) []Entry {
+	return s.filter(func(e Entry) bool { return !e.ExpiresAt.After(now) })
+}
+
+func (s *Store) filter(keep func(Entry) bool) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if keep(e) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch {
+		case a.NetworkListID != b.NetworkListID:
+			return a.NetworkListID < b.NetworkListID
+		case !a.ExpiresAt.Equal(b.ExpiresAt):
+			return a.ExpiresAt.Before(b.ExpiresAt)
+		}
+		return a.Element < b.Element
+	})
+	return entries
+}
+
+// setPending records whether the list still has to be activated
+func (s *Store) setPending(listID string, pending bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pending {
+		s.pending[listID] = true
+	} else {
+		delete(s.pending, listID)
+	}
+}
+
+// pendingActivations returns the lists still to be activated, sorted
+func (s *Store) pendingActivations() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.pending))
+	for id := range s.pending {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Save writes the entries to the state file, replacing it only once fully written
+func (s *Store) Save() error {
+	state := stateFile{Entries: s.Entries(), PendingActivations: s.pendingActivations()}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Expire removes the elapsed elements from their lists, a single update per list
+// guarded by its sync point, and activates the lists which changed. The store
+// is saved after every list, so the lists expired before a failure stay forgotten.
+// A list whose activation failed is recorded in the store and activated again
+// by the next Expire with Activate set, even when no element elapsed since
+func Expire(ctx context.Context, client netlist.NetworkList, params ExpireRequest) ([]ExpireResult, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+
+	var results []ExpireResult
+	for _, listID := range params.listIDs() {
+		elapsed := params.elapsed(listID)
+		result := ExpireResult{NetworkListID: listID}
+
+		list, err := netlist.MutateNetworkList(ctx, client, netlist.MutateNetworkListRequest{
+			NetworkListID: listID,
+			MaxRetries:    params.MaxRetries,
+			Policy:        &netlist.ElementPolicy{},
+			Mutate: func(list *netlist.NetworkListResponse) error {
+				result.Removed, result.Missing = nil, nil
+				list.List = removeElapsed(list.List, elapsed, &result)
+				return nil
+			},
+		})
+		switch {
+		case errors.Is(err, netlist.ErrNotFound):
+			// the list is gone, so are its elements
+			result.Missing = elementsOf(elapsed)
+		case err != nil:
+			return results, err
+		}
+		result.List = list
+
+		// the removed elements are forgotten along with the activation they
+		// call for, which is only cleared once it succeeded
+		params.Store.Untrack(listID, elementsOf(elapsed)...)
+		if list == nil {
+			params.Store.setPending(listID, false)
+		} else if len(result.Removed) > 0 && len(params.Activate) > 0 {
+			params.Store.setPending(listID, true)
+		}
+		if err := params.Store.Save(); err != nil {
+			return results, err
+		}
+
+		if list != nil && params.pending(listID) {
+			if result.Activations, err = activate(ctx, client, params, list); err != nil {
+				results = append(results, result)
+				return results, err
+			}
+			params.Store.setPending(listID, false)
+			if err := params.Store.Save(); err != nil {
+				return results, err
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Plan describes the updates and the activations Expire would make, a plan per list
+func Plan(ctx context.Context, client netlist.NetworkList, params ExpireRequest) ([]*netlist.Plan, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+
+	var plans []*netlist.Plan
+	for _, listID := range params.listIDs() {
+		current, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+			OptionalParams: &netlist.OptionalParams{Extended: true, IncludeElements: true},
+			NetworkListID:  listID,
+		})
+		if errors.Is(err, netlist.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var result ExpireResult
+		plan, err := netlist.PlanUpdateNetworkList(ctx, client, netlist.UpdateNetworkListRequest{
+			BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+				GetNetworkListRequest: &netlist.GetNetworkListRequest{NetworkListID: listID},
+				Name:                  current.Name,
+				Type:                  current.Type,
+				Description:           current.Description,
+				List:                  removeElapsed(current.List, params.elapsed(listID), &result),
+				Policy:                &netlist.ElementPolicy{},
+			},
+			SyncPoint: current.SyncPoint,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(result.Removed) > 0 || params.pending(listID) {
+			target := current.SyncPoint
+			if len(result.Removed) > 0 {
+				target++
+			}
+			for _, env := range params.Activate {
+				activation, err := netlist.PlanActivateNetworkList(ctx, client, netlist.ActivateNetworkListRequest{
+					NetworkListID: listID,
+					Environment:   env,
+				})
+				if err != nil {
+					return nil, err
+				}
+				for _, a := range activation.Activations {
+					a.TargetSyncPoint = target
+					plan.Activations = append(plan.Activations, a)
+				}
+			}
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// listIDs returns the lists holding elapsed entries and, when lists are
+// activated, the lists whose activation is pending, sorted
+func (v ExpireRequest) listIDs() []string {
+	var ids []string
+	seen := map[string]bool{}
+	add := func(id string) {
+		if (v.NetworkListID == "" || id == v.NetworkListID) && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, e := range v.Store.Expired(v.now()) {
+		add(e.NetworkListID)
+	}
+	if len(v.Activate) > 0 {
+		for _, id := range v.Store.pendingActivations() {
+			add(id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// pending tells whether the list is to be activated
+func (v ExpireRequest) pending(listID string) bool {
+	if len(v.Activate) == 0 {
+		return false
+	}
+	v.Store.mu.Lock()
+	defer v.Store.mu.Unlock()
+	return v.Store.pending[listID]
+}
+
+// elapsed returns the elapsed entries of a list by element
+func (v ExpireRequest) elapsed(listID string) map[string]struct{} {
+	elapsed := map[string]struct{}{}
+	for _, e := range v.Store.Expired(v.now()) {
+		if e.NetworkListID == listID {
+			elapsed[e.Element] = struct{}{}
+		}
+	}
+	return elapsed
+}
+
+func (v ExpireRequest) now() time.Time {
+	if v.Now.IsZero() {
+		return time.Now()
+	}
+	return v.Now
+}
+
+// removeElapsed returns the elements which did not elapse, recording
+// the removed and the missing ones in result
+func removeElapsed(elements []string, elapsed map[string]struct{}, result *ExpireResult) []string {
+	kept := make([]string, 0, len(elements))
+	found := map[string]struct{}{}
+	for _, e := range elements {
+		if _, ok := elapsed[e]; ok {
+			result.Removed = append(result.Removed, e)
+			found[e] = struct{}{}
+			continue
+		}
+		kept = append(kept, e)
+	}
+	for _, e := range elementsOf(elapsed) {
+		if _, ok := found[e]; !ok {
+			result.Missing = append(result.Missing, e)
+		}
+	}
+	return kept
+}
+
+func elementsOf(set map[string]struct{}) []string {
+	elements := make([]string, 0, len(set))
+	for e := range set {
+		elements = append(elements, e)
+	}
+	sort.Strings(elements)
+	return elements
+}
+
+func activate(ctx context.Context, client netlist.NetworkList, params ExpireRequest, list *netlist.NetworkListResponse) ([]*netlist.ActivationNetworkListResponse, error) {
+	var activations []*netlist.ActivationNetworkListResponse
+	for _, env := range params.Activate {
+		activation, err := client.ActivateNetworkList(ctx, netlist.ActivateNetworkListRequest{
+			NetworkListID:          list.UniqueID,
+			Environment:            env,
+			Comments:               params.Comments,
+			NotificationRecipients: params.NotificationRecipients,
+		})
+		if err != nil {
+			return activations, err
+		}
+		if params.Wait {
+			activation, err = netlist.WaitForActivation(ctx, client, netlist.WaitForActivationRequest{
+				NetworkListID: list.UniqueID,
+				Environment:   env,
+				SyncPoint:     activation.SyncPoint,
+				PollInterval:  params.PollInterval,
+			})
+			if err != nil {
+				return activations, err
+			}
+		}
+		activations = append(activations, activation)
+	}
+	return activations, nil
+}
+
+// Validate validates ExpireRequest
+func (v ExpireRequest) Validate() error {
+	return validation.Errors{
+		"store": validation.Validate(v.Store, validation.NotNil),
+		"activate": validation.Validate(v.Activate, validation.Each(
+			validation.In(netlist.STAGING, netlist.PRODUCTION))),
+	}.Filter()
+}
@@ -0,0 +1,289 @@
+package expiry_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akamai-playground/expiry"
+	"github.com/akamai-playground/netlist"
+	"github.com/akamai-playground/netlist/fake"
+)
+
+var now = time.Date(2020, 6, 8, 12, 0, 0, 0, time.UTC)
+
+func statePath(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "expiry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, expiry.DefaultPath), func() { os.RemoveAll(dir) }
+}
+
+func TestStore(t *testing.T) {
+	path, cleanup := statePath(t)
+	defer cleanup()
+
+	store, err := expiry.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := store.Entries(); len(entries) != 0 {
+		t.Fatalf("missing state file holds %v", entries)
+	}
+
+	store.Track("1002_B", []string{"2.2.2.2"}, now, time.Hour)
+	store.Track("1001_A", []string{"1.1.1.1", "1.1.1.2"}, now, 2*time.Hour)
+	store.Track("1001_A", []string{"1.1.1.2"}, now, time.Hour)
+	store.Track("1001_A", []string{"1.1.1.3"}, now, time.Hour)
+	store.Untrack("1001_A", "1.1.1.3")
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := expiry.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []expiry.Entry{
+		{NetworkListID: "1001_A", Element: "1.1.1.2", AddedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{NetworkListID: "1001_A", Element: "1.1.1.1", AddedAt: now, ExpiresAt: now.Add(2 * time.Hour)},
+		{NetworkListID: "1002_B", Element: "2.2.2.2", AddedAt: now, ExpiresAt: now.Add(time.Hour)},
+	}
+	if got := reopened.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened entries %+v, want %+v", got, want)
+	}
+
+	tests := map[string]struct {
+		at   time.Time
+		want []expiry.Entry
+	}{
+		"nothing elapsed":     {at: now.Add(59 * time.Minute)},
+		"elapsed at expiry":   {at: now.Add(time.Hour), want: []expiry.Entry{want[0], want[2]}},
+		"everything elapsed":  {at: now.Add(3 * time.Hour), want: want},
+		"before any addition": {at: now.Add(-time.Hour)},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := reopened.Expired(test.at)
+			if len(got) != len(test.want) || (len(got) > 0 && !reflect.DeepEqual(got, test.want)) {
+				t.Errorf("expired %+v, want %+v", got, test.want)
+			}
+		})
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expiry.Open(path); err == nil {
+		t.Error("opened a corrupted state file")
+	}
+}
+
+func TestExpire(t *testing.T) {
+	tests := map[string]struct {
+		listID      string
+		activate    []netlist.Environment
+		wantLists   map[string][]string
+		wantResults []expiry.ExpireResult
+		plans       int
+		activations int
+		remaining   int
+	}{
+		"every list": {
+			wantLists: map[string][]string{
+				"1001_A": {"1.1.1.3"},
+				"1002_B": {"2.2.2.2"},
+			},
+			wantResults: []expiry.ExpireResult{
+				{NetworkListID: "1001_A", Removed: []string{"1.1.1.1", "1.1.1.2"}, Missing: []string{"1.1.1.9"}},
+				{NetworkListID: "1002_B", Missing: []string{"2.2.2.3"}},
+				{NetworkListID: "1003_GONE", Missing: []string{"3.3.3.3"}},
+			},
+			plans:     2,
+			remaining: 1,
+		},
+		"single list activated": {
+			listID:   "1001_A",
+			activate: []netlist.Environment{netlist.STAGING},
+			wantLists: map[string][]string{
+				"1001_A": {"1.1.1.3"},
+				"1002_B": {"2.2.2.2", "2.2.2.3"},
+			},
+			wantResults: []expiry.ExpireResult{
+				{NetworkListID: "1001_A", Removed: []string{"1.1.1.1", "1.1.1.2"}, Missing: []string{"1.1.1.9"}},
+			},
+			plans:       1,
+			activations: 1,
+			remaining:   3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path, cleanup := statePath(t)
+			defer cleanup()
+
+			ctx := context.Background()
+			client := fake.New(fake.WithActivationPolls(0))
+			a := client.Seed(netlist.NetworkListResponse{Name: "A", Type: netlist.IP.String(), List: []string{"1.1.1.1", "1.1.1.2", "1.1.1.3"}})
+			b := client.Seed(netlist.NetworkListResponse{Name: "B", Type: netlist.IP.String(), List: []string{"2.2.2.2", "2.2.2.3"}})
+
+			store, err := expiry.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			store.Track(a.UniqueID, []string{"1.1.1.1", "1.1.1.2", "1.1.1.9"}, now, time.Hour)
+			store.Track(a.UniqueID, []string{"1.1.1.3"}, now, 48*time.Hour)
+			// 2.2.2.3 was removed by hand, the list changes nothing
+			store.Track(b.UniqueID, []string{"2.2.2.3"}, now, time.Hour)
+			store.Track("1003_GONE", []string{"3.3.3.3"}, now, time.Hour)
+			if _, err := client.RemoveElement(ctx, netlist.RemoveElementRequest{
+				AddElementRequest: &netlist.AddElementRequest{NetworkListID: b.UniqueID, Element: "2.2.2.3"},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if test.listID != "" {
+				if _, err := client.AddElement(ctx, netlist.AddElementRequest{NetworkListID: b.UniqueID, Element: "2.2.2.3"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			params := expiry.ExpireRequest{
+				Store:         store,
+				NetworkListID: test.listID,
+				Now:           now.Add(2 * time.Hour),
+				Activate:      test.activate,
+			}
+			plans, err := expiry.Plan(ctx, client, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(plans) != test.plans {
+				t.Errorf("%d plans, want %d", len(plans), test.plans)
+			}
+
+			results, err := expiry.Expire(ctx, client, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != len(test.wantResults) {
+				t.Fatalf("%d results, want %d", len(results), len(test.wantResults))
+			}
+			activations := 0
+			for i, r := range results {
+				want := test.wantResults[i]
+				if r.NetworkListID != want.NetworkListID || !reflect.DeepEqual(r.Removed, want.Removed) ||
+					!reflect.DeepEqual(r.Missing, want.Missing) {
+					t.Errorf("result %d: %+v, want %+v", i, r, want)
+				}
+				activations += len(r.Activations)
+			}
+			if activations != test.activations {
+				t.Errorf("%d activations, want %d", activations, test.activations)
+			}
+
+			for id, want := range test.wantLists {
+				got, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+					OptionalParams: &netlist.OptionalParams{IncludeElements: true},
+					NetworkListID:  id,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got.List, want) {
+					t.Errorf("%s holds %v, want %v", id, got.List, want)
+				}
+			}
+
+			reopened, err := expiry.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entries := reopened.Entries(); len(entries) != test.remaining {
+				t.Errorf("%d entries saved, want %d: %+v", len(entries), test.remaining, entries)
+			}
+		})
+	}
+}
+
+func TestExpireErrors(t *testing.T) {
+	path, cleanup := statePath(t)
+	defer cleanup()
+	store, err := expiry.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	client := fake.New()
+	l := client.Seed(netlist.NetworkListResponse{Name: "A", Type: netlist.IP.String(), List: []string{"1.1.1.1"}})
+	store.Track(l.UniqueID, []string{"1.1.1.1"}, now, time.Hour)
+
+	if _, err := expiry.Expire(ctx, client, expiry.ExpireRequest{}); !errors.Is(err, netlist.ErrStructValidation) {
+		t.Errorf("error = %v, want %v", err, netlist.ErrStructValidation)
+	}
+
+	client.Fail(fake.MethodUpdateNetworkList, fake.NewError(500, "boom"), 1)
+	if _, err := expiry.Expire(ctx, client, expiry.ExpireRequest{Store: store, Now: now.Add(time.Hour)}); !errors.Is(err, netlist.ErrServerError) {
+		t.Fatalf("error = %v, want %v", err, netlist.ErrServerError)
+	}
+	if entries := store.Expired(now.Add(time.Hour)); len(entries) != 1 {
+		t.Errorf("failed expiry forgot %+v", entries)
+	}
+}
+
+func TestExpireActivationRetry(t *testing.T) {
+	path, cleanup := statePath(t)
+	defer cleanup()
+	store, err := expiry.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	client := fake.New(fake.WithActivationPolls(0))
+	l := client.Seed(netlist.NetworkListResponse{Name: "A", Type: netlist.IP.String(), List: []string{"1.1.1.1", "1.1.1.2"}})
+	store.Track(l.UniqueID, []string{"1.1.1.1"}, now, time.Hour)
+	params := expiry.ExpireRequest{Store: store, Now: now.Add(2 * time.Hour), Activate: []netlist.Environment{netlist.STAGING}}
+
+	client.Fail(fake.MethodActivateNetworkList, fake.NewError(500, "boom"), 1)
+	if _, err := expiry.Expire(ctx, client, params); !errors.Is(err, netlist.ErrServerError) {
+		t.Fatalf("error = %v, want %v", err, netlist.ErrServerError)
+	}
+
+	// the element is gone, the next run started from the saved state still activates the list
+	if params.Store, err = expiry.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	plans, err := expiry.Plan(ctx, client, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || len(plans[0].Activations) != 1 {
+		t.Errorf("plans = %+v, want the pending activation", plans)
+	}
+	results, err := expiry.Expire(ctx, client, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || len(results[0].Removed) != 0 || len(results[0].Activations) != 1 {
+		t.Fatalf("results = %+v, want the list activated again", results)
+	}
+	if calls := client.Calls(fake.MethodActivateNetworkList); calls != 2 {
+		t.Errorf("%d activation calls, want 2", calls)
+	}
+
+	if results, err = expiry.Expire(ctx, client, params); err != nil || len(results) != 0 {
+		t.Errorf("third run = %+v, %v, want nothing left to do", results, err)
+	}
+	if entries := params.Store.Entries(); len(entries) != 0 {
+		t.Errorf("entries left: %+v", entries)
+	}
+}
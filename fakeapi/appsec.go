@@ -0,0 +1,901 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akamai-playground/appsec"
+)
+
+const (
+	appSecPrefix      = "/appsec/v1/configs"
+	activationsPrefix = "/appsec/v1/activations"
+)
+
+type (
+	// AppSec is an in-memory store of security configurations,
+	// their versions, security policies, rule actions and activations
+	AppSec struct {
+		mu              sync.Mutex
+		configs         map[int]*secConfig
+		activations     []*secActivation
+		activationPolls int
+		// definitions holds the metadata of the rules, by rule ID
+		definitions map[int]appsec.Rule
+		nextID      int
+		user        string
+		now         func() time.Time
+	}
+
+	secConfig struct {
+		config     appsec.Config
+		contractID string
+		groupID    int
+		hostnames  []string
+		versions   []*secVersion
+	}
+
+	secVersion struct {
+		info     appsec.VersionList
+		policies []*appsec.Policies
+		rules    map[string][]*appsec.RuleActions
+		// exceptions holds the conditions and exceptions of the rules, by policy and rule ID
+		exceptions map[string]map[int]*appsec.ConditionException
+	}
+
+	secActivation struct {
+		activation appsec.Activation
+		notes      string
+		emails     []string
+		// polls is the number of status checks left before the activation completes
+		polls int
+	}
+
+	// activationBody is the JSON body of an activation request
+	activationBody struct {
+		Action string `json:"action"`
+		appsec.ActivationRequest
+	}
+
+	// secError is an error of the AppSec store answered with its status code,
+	// the other errors are answered with a 404
+	secError struct {
+		status int
+		detail string
+	}
+)
+
+// NewAppSec returns an empty AppSec store
+func NewAppSec() *AppSec {
+	return &AppSec{
+		configs:     make(map[int]*secConfig),
+		definitions: make(map[int]appsec.Rule),
+		nextID:      10000,
+		user:        "fake",
+		now:         time.Now,
+	}
+}
+
+// AddConfig stores a new security configuration with its version 1
+func (a *AppSec) AddConfig(name, description string) appsec.Config {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.nextID++
+	c := &secConfig{
+		config: appsec.Config{
+			ID:            a.nextID,
+			LatestVersion: 1,
+			Name:          name,
+			Description:   description,
+		},
+	}
+	c.versions = append(c.versions, a.newVersion(1, 0, ""))
+	a.configs[c.config.ID] = c
+	return c.config
+}
+
+// AddPolicy stores a security policy in a configuration version
+func (a *AppSec) AddPolicy(configID, version int, policy appsec.Policies) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	v, err := a.version(configID, version)
+	if err != nil {
+		return err
+	}
+	p := policy
+	v.policies = append(v.policies, &p)
+	return nil
+}
+
+// SetRules replaces the rule actions of a security policy
+func (a *AppSec) SetRules(configID, version int, policyID string, rules []appsec.RuleActions) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	v, err := a.version(configID, version)
+	if err != nil {
+		return err
+	}
+	if v.policy(policyID) == nil {
+		return fmt.Errorf("policy %s not found in version %d of configuration %d", policyID, version, configID)
+	}
+
+	actions := make([]*appsec.RuleActions, 0, len(rules))
+	for i := range rules {
+		r := rules[i]
+		actions = append(actions, &r)
+	}
+	v.rules[policyID] = actions
+	return nil
+}
+
+// DefineRule stores the title, tags and attack group of a rule, shared by every policy
+func (a *AppSec) DefineRule(rule appsec.Rule) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.definitions[rule.ID] = appsec.Rule{
+		ID:          rule.ID,
+		Title:       rule.Title,
+		Tags:        append([]string(nil), rule.Tags...),
+		AttackGroup: rule.AttackGroup,
+	}
+}
+
+// SetActiveVersion marks a version as the one active on env,
+// the previously active version being deactivated
+func (a *AppSec) SetActiveVersion(configID, version int, env appsec.Environment) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	c, err := a.config(configID)
+	if err != nil {
+		return err
+	}
+	v, err := a.version(configID, version)
+	if err != nil {
+		return err
+	}
+
+	if c.activeVersion(env) == nil {
+		return fmt.Errorf("unknown environment %v", int(env))
+	}
+	c.activate(env, v)
+	return nil
+}
+
+// SetActivationPolls sets the number of status checks an activation stays
+// RECEIVED for, 0 completes it on the first check
+func (a *AppSec) SetActivationPolls(polls int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.activationPolls = polls
+}
+
+// createConfig stores a new configuration, cloned from body.CreateFrom when set
+func (a *AppSec) createConfig(body appsec.CreateConfigRequest) (*appsec.CreateConfigResponse, error) {
+	if body.Name == "" || body.ContractID == "" || body.GroupID == 0 {
+		return nil, badRequest("name, contractId and groupId are required")
+	}
+	if err := a.checkName(0, body.Name); err != nil {
+		return nil, err
+	}
+
+	var from *secVersion
+	hostnames := body.Hostnames
+	if body.CreateFrom != nil {
+		source, err := a.config(body.CreateFrom.ConfigID)
+		if err != nil {
+			return nil, err
+		}
+		if from, err = a.version(body.CreateFrom.ConfigID, body.CreateFrom.Version); err != nil {
+			return nil, err
+		}
+		if len(hostnames) == 0 {
+			hostnames = source.hostnames
+		}
+	}
+	if len(hostnames) == 0 {
+		return nil, badRequest("hostnames are required")
+	}
+
+	a.nextID++
+	c := &secConfig{
+		config: appsec.Config{
+			ID:            a.nextID,
+			LatestVersion: 1,
+			Name:          body.Name,
+			Description:   body.Description,
+		},
+		contractID: strings.TrimPrefix(body.ContractID, "ctr_"),
+		groupID:    body.GroupID,
+		hostnames:  append([]string(nil), hostnames...),
+	}
+	v := a.newVersion(1, 0, "")
+	if from != nil {
+		v = a.cloneVersion(from, 1, 0, "")
+	}
+	c.versions = append(c.versions, v)
+	a.configs[c.config.ID] = c
+
+	return &appsec.CreateConfigResponse{
+		ConfigID:    c.config.ID,
+		ConfigName:  c.config.Name,
+		Description: c.config.Description,
+		ContractID:  c.contractID,
+		GroupID:     c.groupID,
+		Version:     1,
+	}, nil
+}
+
+// updateConfig renames a configuration
+func (a *AppSec) updateConfig(body appsec.UpdateConfigRequest) (*appsec.UpdateConfigResponse, error) {
+	c, err := a.config(body.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+	if body.Name == "" {
+		return nil, badRequest("name is required")
+	}
+	if err := a.checkName(body.ConfigID, body.Name); err != nil {
+		return nil, err
+	}
+
+	c.config.Name, c.config.Description = body.Name, body.Description
+	return &appsec.UpdateConfigResponse{Name: body.Name, Description: body.Description}, nil
+}
+
+// deleteConfig deletes a configuration which is active nowhere
+func (a *AppSec) deleteConfig(configID int) error {
+	c, err := a.config(configID)
+	if err != nil {
+		return err
+	}
+	if c.config.StagingVersion != 0 || c.config.ProductionVersion != 0 {
+		return badRequest(fmt.Sprintf("configuration %d is active, deactivate it first", configID))
+	}
+	delete(a.configs, configID)
+	return nil
+}
+
+// checkName rejects a name used by another configuration than configID
+func (a *AppSec) checkName(configID int, name string) error {
+	for id, c := range a.configs {
+		if id != configID && strings.EqualFold(c.config.Name, name) {
+			return badRequest(fmt.Sprintf("configuration name %q is already used by %d", name, id))
+		}
+	}
+	return nil
+}
+
+// createVersion stores a new version of a configuration cloned from body.CreateFromVersion
+func (a *AppSec) createVersion(body appsec.CreateConfigVersionRequest) (*appsec.ConfigVersion, error) {
+	c, err := a.config(body.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+	if body.CreateFromVersion == 0 {
+		return nil, badRequest("createFromVersion is required")
+	}
+	from, err := a.version(body.ConfigID, body.CreateFromVersion)
+	if err != nil {
+		return nil, badRequest(err.Error())
+	}
+
+	v := a.cloneVersion(from, len(c.versions)+1, body.CreateFromVersion, from.info.VersionNotes)
+	c.versions = append(c.versions, v)
+	c.config.LatestVersion = v.info.Version
+	return c.versionDetails(v), nil
+}
+
+// createActivation records an activation, checking the versions it activates or deactivates
+func (a *AppSec) createActivation(body activationBody) (*appsec.Activation, error) {
+	if body.Action != appsec.ActionActivate && body.Action != appsec.ActionDeactivate {
+		return nil, badRequest(fmt.Sprintf("invalid action %q", body.Action))
+	}
+	if body.Environment == 0 || len(body.Configs) == 0 {
+		return nil, badRequest("network and activationConfigs are required")
+	}
+	for _, ac := range body.Configs {
+		c, err := a.config(ac.ConfigID)
+		if err != nil {
+			return nil, badRequest(err.Error())
+		}
+		if _, err := a.version(ac.ConfigID, ac.ConfigVersion); err != nil {
+			return nil, badRequest(err.Error())
+		}
+		if body.Action == appsec.ActionDeactivate && *c.activeVersion(body.Environment) != ac.ConfigVersion {
+			return nil, badRequest(fmt.Sprintf("version %d of configuration %d is not active on %s",
+				ac.ConfigVersion, ac.ConfigID, body.Environment))
+		}
+	}
+
+	act := &secActivation{
+		activation: appsec.Activation{
+			ActivationID: 1000 + len(a.activations) + 1,
+			Action:       body.Action,
+			Status:       appsec.StatusReceived,
+			Environment:  body.Environment,
+			CreateDate:   a.now().UTC(),
+			CreatedBy:    a.user,
+			Configs:      append([]appsec.ActivationConfig(nil), body.Configs...),
+		},
+		notes:  body.Note,
+		emails: append([]string(nil), body.NotificationEmails...),
+		polls:  a.activationPolls,
+	}
+	a.activations = append(a.activations, act)
+	out := act.activation
+	return &out, nil
+}
+
+// activation returns the status of an activation, completing it once polled
+// as many times as set by SetActivationPolls
+func (a *AppSec) activation(activationID int) (*appsec.Activation, error) {
+	i := activationID - 1000 - 1
+	if i < 0 || i >= len(a.activations) {
+		return nil, fmt.Errorf("activation %d not found", activationID)
+	}
+
+	act := a.activations[i]
+	if act.activation.Status == appsec.StatusReceived {
+		if act.polls > 0 {
+			act.polls--
+		} else {
+			a.complete(act)
+		}
+	}
+	out := act.activation
+	return &out, nil
+}
+
+// complete applies an activation to the versions it names
+func (a *AppSec) complete(act *secActivation) {
+	env := act.activation.Environment
+	for _, ac := range act.activation.Configs {
+		c, ok := a.configs[ac.ConfigID]
+		if !ok {
+			act.activation.Status = appsec.StatusFailed
+			return
+		}
+		v := c.versions[ac.ConfigVersion-1]
+		if act.activation.Action == appsec.ActionActivate {
+			c.activate(env, v)
+			continue
+		}
+		if active := c.activeVersion(env); *active == ac.ConfigVersion {
+			*active = 0
+			v.setStatus(env, "Deactivated")
+		}
+	}
+
+	act.activation.Status = appsec.StatusActivated
+	if act.activation.Action == appsec.ActionDeactivate {
+		act.activation.Status = appsec.StatusDeactivated
+	}
+}
+
+// activationHistory lists the activations of a configuration, latest first
+func (a *AppSec) activationHistory(configID int) (*appsec.GetActivationHistoryResponse, error) {
+	if _, err := a.config(configID); err != nil {
+		return nil, err
+	}
+
+	out := &appsec.GetActivationHistoryResponse{ConfigID: configID, ActivationHistory: []*appsec.ActivationEntry{}}
+	for i := len(a.activations) - 1; i >= 0; i-- {
+		act := a.activations[i]
+		for _, ac := range act.activation.Configs {
+			if ac.ConfigID != configID {
+				continue
+			}
+			out.ActivationHistory = append(out.ActivationHistory, &appsec.ActivationEntry{
+				ActivationID:       act.activation.ActivationID,
+				Version:            ac.ConfigVersion,
+				Environment:        act.activation.Environment,
+				Action:             act.activation.Action,
+				Status:             act.activation.Status,
+				Notes:              act.notes,
+				NotificationEmails: act.emails,
+				ActivatedBy:        act.activation.CreatedBy,
+				ActivationDate:     act.activation.CreateDate,
+			})
+		}
+	}
+	return out, nil
+}
+
+// updateRuleAction sets the action of a rule in a version which was never activated
+func (a *AppSec) updateRuleAction(body appsec.UpdateRuleActionRequest) (*appsec.UpdateRuleActionResponse, error) {
+	v, r, err := a.rule(body.ConfigID, body.Version, body.PolicyID, body.RuleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.checkEditable(body.ConfigID); err != nil {
+		return nil, err
+	}
+	if err := appsec.ValidateRuleAction(body.Action); err != nil {
+		return nil, badRequest(err.Error())
+	}
+
+	r.Action = body.Action
+	return &appsec.UpdateRuleActionResponse{Action: body.Action}, nil
+}
+
+// ruleDetails returns a rule of a policy with its metadata, conditions and exceptions
+func (a *AppSec) ruleDetails(configID, version int, policyID string, ruleID int) (*appsec.Rule, error) {
+	v, r, err := a.rule(configID, version, policyID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := a.definitions[ruleID]
+	out.ID, out.Action = r.ID, r.Action
+	if out.Tags == nil {
+		out.Tags = []string{}
+	}
+	if ce := v.exceptions[policyID][ruleID]; ce != nil {
+		out.ConditionException = copyConditionException(ce)
+	}
+	return &out, nil
+}
+
+// conditionException returns the conditions and exceptions of a rule, empty when it has none
+func (a *AppSec) conditionException(configID, version int, policyID string, ruleID int) (*appsec.ConditionException, error) {
+	v, _, err := a.rule(configID, version, policyID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if ce := v.exceptions[policyID][ruleID]; ce != nil {
+		return copyConditionException(ce), nil
+	}
+	return &appsec.ConditionException{}, nil
+}
+
+// updateConditionException replaces the conditions and exceptions of a rule in a version which was never activated
+func (a *AppSec) updateConditionException(body appsec.UpdateRuleConditionExceptionRequest) (*appsec.ConditionException, error) {
+	v, _, err := a.rule(body.ConfigID, body.Version, body.PolicyID, body.RuleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.checkEditable(body.ConfigID); err != nil {
+		return nil, err
+	}
+	if err := body.ConditionException.Validate(); err != nil {
+		return nil, badRequest(err.Error())
+	}
+
+	if v.exceptions[body.PolicyID] == nil {
+		v.exceptions[body.PolicyID] = make(map[int]*appsec.ConditionException)
+	}
+	if len(body.Conditions) == 0 && body.Exception == nil {
+		delete(v.exceptions[body.PolicyID], body.RuleID)
+		return &appsec.ConditionException{}, nil
+	}
+	v.exceptions[body.PolicyID][body.RuleID] = copyConditionException(&body.ConditionException)
+	return copyConditionException(&body.ConditionException), nil
+}
+
+func (c *secConfig) versionDetails(v *secVersion) *appsec.ConfigVersion {
+	return &appsec.ConfigVersion{ConfigID: c.config.ID, ConfigName: c.config.Name, VersionList: v.info}
+}
+
+func (a *AppSec) newVersion(number, basedOn int, notes string) *secVersion {
+	return &secVersion{
+		info: appsec.VersionList{
+			Version:      number,
+			VersionNotes: notes,
+			CreateDate:   a.now().UTC(),
+			CreatedBy:    a.user,
+			BasedOn:      basedOn,
+			Production:   appsec.Production{Status: "Inactive"},
+			Staging:      appsec.Staging{Status: "Inactive"},
+		},
+		rules:      make(map[string][]*appsec.RuleActions),
+		exceptions: make(map[string]map[int]*appsec.ConditionException),
+	}
+}
+
+// cloneVersion returns a new version holding a copy of the policies and rules of from
+func (a *AppSec) cloneVersion(from *secVersion, number, basedOn int, notes string) *secVersion {
+	v := a.newVersion(number, basedOn, notes)
+	for _, p := range from.policies {
+		policy := *p
+		v.policies = append(v.policies, &policy)
+	}
+	for policyID, rules := range from.rules {
+		actions := make([]*appsec.RuleActions, 0, len(rules))
+		for _, r := range rules {
+			action := *r
+			actions = append(actions, &action)
+		}
+		v.rules[policyID] = actions
+	}
+	for policyID, rules := range from.exceptions {
+		v.exceptions[policyID] = make(map[int]*appsec.ConditionException, len(rules))
+		for ruleID, ce := range rules {
+			v.exceptions[policyID][ruleID] = copyConditionException(ce)
+		}
+	}
+	return v
+}
+
+// copyConditionException returns a deep copy of ce
+func copyConditionException(ce *appsec.ConditionException) *appsec.ConditionException {
+	var out appsec.ConditionException
+	data, _ := json.Marshal(ce)
+	_ = json.Unmarshal(data, &out)
+	return &out
+}
+
+func (a *AppSec) config(configID int) (*secConfig, error) {
+	c, ok := a.configs[configID]
+	if !ok {
+		return nil, fmt.Errorf("configuration %d not found", configID)
+	}
+	return c, nil
+}
+
+func (a *AppSec) version(configID, version int) (*secVersion, error) {
+	c, err := a.config(configID)
+	if err != nil {
+		return nil, err
+	}
+	if version < 1 || version > len(c.versions) {
+		return nil, fmt.Errorf("version %d of configuration %d not found", version, configID)
+	}
+	return c.versions[version-1], nil
+}
+
+// activeVersion returns the field holding the version active on env, nil for an unknown environment
+func (c *secConfig) activeVersion(env appsec.Environment) *int {
+	switch env {
+	case appsec.STAGING:
+		return &c.config.StagingVersion
+	case appsec.PRODUCTION:
+		return &c.config.ProductionVersion
+	}
+	return nil
+}
+
+// activate makes v the version active on env, the previously active version being deactivated
+func (c *secConfig) activate(env appsec.Environment, v *secVersion) {
+	active := c.activeVersion(env)
+	if *active != 0 {
+		c.versions[*active-1].setStatus(env, "Deactivated")
+	}
+	*active = v.info.Version
+	v.setStatus(env, "Active")
+}
+
+func (v *secVersion) setStatus(env appsec.Environment, status string) {
+	if env == appsec.STAGING {
+		v.info.Staging.Status = status
+		return
+	}
+	v.info.Production.Status = status
+}
+
+// rule returns a version and the rule of one of its policies
+func (a *AppSec) rule(configID, version int, policyID string, ruleID int) (*secVersion, *appsec.RuleActions, error) {
+	v, err := a.version(configID, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if v.policy(policyID) == nil {
+		return nil, nil, fmt.Errorf("policy %s not found", policyID)
+	}
+	for _, r := range v.rules[policyID] {
+		if r.ID == ruleID {
+			return v, r, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("rule %d not found in policy %s", ruleID, policyID)
+}
+
+// checkEditable rejects changes to a version which was activated
+func (v *secVersion) checkEditable(configID int) error {
+	if v.info.Staging.Status != "Inactive" || v.info.Production.Status != "Inactive" {
+		return badRequest(fmt.Sprintf("version %d of configuration %d was activated and is read-only", v.info.Version, configID))
+	}
+	return nil
+}
+
+func (v *secVersion) policy(policyID string) *appsec.Policies {
+	for _, p := range v.policies {
+		if p.PolicyID == policyID {
+			return p
+		}
+	}
+	return nil
+}
+
+// serveAppSec routes the application security API to the AppSec store
+func (s *Server) serveAppSec(w http.ResponseWriter, r *http.Request) {
+	a := s.AppSec
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if r.URL.Path == activationsPrefix || isSubPath(r.URL.Path, activationsPrefix) {
+		a.serveActivations(w, r)
+		return
+	}
+	if r.URL.Path != appSecPrefix && !isSubPath(r.URL.Path, appSecPrefix) {
+		writeProblem(w, r, http.StatusNotFound, "Not Found", fmt.Sprintf("no route for %s", r.URL.Path))
+		return
+	}
+
+	parts := route(r.URL.Path, appSecPrefix)
+	var (
+		configID, version, ruleID int
+		err                       error
+	)
+	if len(parts) > 0 {
+		if configID, err = strconv.Atoi(parts[0]); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", fmt.Sprintf("invalid configuration ID %q", parts[0]))
+			return
+		}
+	}
+	if len(parts) > 2 {
+		if version, err = strconv.Atoi(parts[2]); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", fmt.Sprintf("invalid version %q", parts[2]))
+			return
+		}
+	}
+	if len(parts) > 6 && parts[5] == "rules" {
+		if ruleID, err = strconv.Atoi(parts[6]); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", fmt.Sprintf("invalid rule ID %q", parts[6]))
+			return
+		}
+	}
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		ids := make([]int, 0, len(a.configs))
+		for id := range a.configs {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+
+		var out appsec.GetConfigsResponse
+		out.Configs = []*appsec.Config{}
+		for _, id := range ids {
+			c := a.configs[id].config
+			out.Configs = append(out.Configs, &c)
+		}
+		writeJSON(w, http.StatusOK, out)
+
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var body appsec.CreateConfigRequest
+		if err := readJSON(r, &body); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		out, err := a.createConfig(body)
+		respondAppSec(w, r, http.StatusCreated, out, err)
+
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		c, err := a.config(configID)
+		if err != nil {
+			respondAppSec(w, r, http.StatusOK, nil, err)
+			return
+		}
+		out := c.config
+		respondAppSec(w, r, http.StatusOK, &out, nil)
+
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		var body appsec.UpdateConfigRequest
+		if err := readJSON(r, &body); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		body.ConfigID = configID
+		out, err := a.updateConfig(body)
+		respondAppSec(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		respondAppSec(w, r, http.StatusNoContent, nil, a.deleteConfig(configID))
+
+	case len(parts) == 2 && parts[1] == "versions" && r.Method == http.MethodGet:
+		c, err := a.config(configID)
+		if err != nil {
+			writeProblem(w, r, http.StatusNotFound, "Not Found", err.Error())
+			return
+		}
+		out, err := c.versionsPage(r)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+
+	case len(parts) == 2 && parts[1] == "activations" && r.Method == http.MethodGet:
+		out, err := a.activationHistory(configID)
+		respondAppSec(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 2 && parts[1] == "versions" && r.Method == http.MethodPost:
+		var body appsec.CreateConfigVersionRequest
+		if err := readJSON(r, &body); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		body.ConfigID = configID
+		out, err := a.createVersion(body)
+		respondAppSec(w, r, http.StatusCreated, out, err)
+
+	case len(parts) == 3 && parts[1] == "versions" && r.Method == http.MethodGet:
+		v, err := a.version(configID, version)
+		if err != nil {
+			respondAppSec(w, r, http.StatusOK, nil, err)
+			return
+		}
+		respondAppSec(w, r, http.StatusOK, a.configs[configID].versionDetails(v), nil)
+
+	case len(parts) == 4 && parts[1] == "versions" && parts[3] == "security-policies" && r.Method == http.MethodGet:
+		v, err := a.version(configID, version)
+		if err != nil {
+			writeProblem(w, r, http.StatusNotFound, "Not Found", err.Error())
+			return
+		}
+		out := appsec.GetPoliciesResponse{ConfigID: configID, Version: version, Policies: []*appsec.Policies{}}
+		out.Policies = append(out.Policies, v.policies...)
+		writeJSON(w, http.StatusOK, out)
+
+	case len(parts) == 6 && parts[1] == "versions" && parts[3] == "security-policies" && parts[5] == "rules" && r.Method == http.MethodGet:
+		v, err := a.version(configID, version)
+		if err != nil {
+			writeProblem(w, r, http.StatusNotFound, "Not Found", err.Error())
+			return
+		}
+		if v.policy(parts[4]) == nil {
+			writeProblem(w, r, http.StatusNotFound, "Not Found", fmt.Sprintf("policy %s not found", parts[4]))
+			return
+		}
+		out := appsec.GetRulesResponse{RuleActions: []*appsec.RuleActions{}}
+		out.RuleActions = append(out.RuleActions, v.rules[parts[4]]...)
+		writeJSON(w, http.StatusOK, out)
+
+	case len(parts) == 7 && parts[1] == "versions" && parts[3] == "security-policies" && parts[5] == "rules" && r.Method == http.MethodPut:
+		var body appsec.UpdateRuleActionRequest
+		if err := readJSON(r, &body); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		body.ConfigID, body.Version, body.PolicyID, body.RuleID = configID, version, parts[4], ruleID
+		out, err := a.updateRuleAction(body)
+		respondAppSec(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 7 && parts[1] == "versions" && parts[3] == "security-policies" && parts[5] == "rules" && r.Method == http.MethodGet:
+		out, err := a.ruleDetails(configID, version, parts[4], ruleID)
+		respondAppSec(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 8 && parts[1] == "versions" && parts[3] == "security-policies" && parts[5] == "rules" &&
+		parts[7] == "condition-exception" && r.Method == http.MethodGet:
+		out, err := a.conditionException(configID, version, parts[4], ruleID)
+		respondAppSec(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 8 && parts[1] == "versions" && parts[3] == "security-policies" && parts[5] == "rules" &&
+		parts[7] == "condition-exception" && r.Method == http.MethodPut:
+		var body appsec.UpdateRuleConditionExceptionRequest
+		if err := readJSON(r, &body); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		body.ConfigID, body.Version, body.PolicyID, body.RuleID = configID, version, parts[4], ruleID
+		out, err := a.updateConditionException(body)
+		respondAppSec(w, r, http.StatusOK, out, err)
+
+	default:
+		writeProblem(w, r, http.StatusNotFound, "Not Found", fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+// serveActivations routes the activation requests to the AppSec store, its lock held
+func (a *AppSec) serveActivations(w http.ResponseWriter, r *http.Request) {
+	parts := route(r.URL.Path, activationsPrefix)
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var body activationBody
+		if err := readJSON(r, &body); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		out, err := a.createActivation(body)
+		respondAppSec(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		activationID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", fmt.Sprintf("invalid activation ID %q", parts[0]))
+			return
+		}
+		out, err := a.activation(activationID)
+		respondAppSec(w, r, http.StatusOK, out, err)
+
+	default:
+		writeProblem(w, r, http.StatusNotFound, "Not Found", fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+// versionsPage returns the page of versions selected by the page, pageSize
+// and detail query parameters, latest version first
+func (c *secConfig) versionsPage(r *http.Request) (*appsec.GetConfigVersionsResponse, error) {
+	q := r.URL.Query()
+	page, pageSize := 1, 25
+	var err error
+	if p := q.Get("page"); p != "" {
+		if page, err = strconv.Atoi(p); err != nil || page < 1 {
+			return nil, fmt.Errorf("invalid page %q", p)
+		}
+	}
+	if p := q.Get("pageSize"); p != "" {
+		if pageSize, err = strconv.Atoi(p); err != nil || pageSize == 0 || pageSize < -1 {
+			return nil, fmt.Errorf("invalid pageSize %q", p)
+		}
+	}
+
+	out := &appsec.GetConfigVersionsResponse{
+		TotalSize:               len(c.versions),
+		PageSize:                pageSize,
+		Page:                    page,
+		ConfigID:                c.config.ID,
+		ConfigName:              c.config.Name,
+		StagingActiveVersion:    c.config.StagingVersion,
+		ProductionActiveVersion: c.config.ProductionVersion,
+		LastCreatedVersion:      c.config.LatestVersion,
+		VersionList:             []*appsec.VersionList{},
+	}
+
+	start, end := 0, len(c.versions)
+	if pageSize > 0 {
+		if start = (page - 1) * pageSize; start > len(c.versions) {
+			start = len(c.versions)
+		}
+		if end = start + pageSize; end > len(c.versions) {
+			end = len(c.versions)
+		}
+	}
+	detail := q.Get("detail") != "false"
+	for i := len(c.versions) - 1 - start; i >= len(c.versions)-end; i-- {
+		v := c.versions[i].info
+		if !detail {
+			v = appsec.VersionList{Version: v.Version}
+		}
+		out.VersionList = append(out.VersionList, &v)
+	}
+	return out, nil
+}
+
+// respondAppSec writes out with the given status, or the problem matching err
+func respondAppSec(w http.ResponseWriter, r *http.Request, status int, out interface{}, err error) {
+	var secErr *secError
+	switch {
+	case errors.As(err, &secErr):
+		writeProblem(w, r, secErr.status, http.StatusText(secErr.status), secErr.detail)
+	case err != nil:
+		writeProblem(w, r, http.StatusNotFound, "Not Found", err.Error())
+	default:
+		writeJSON(w, status, out)
+	}
+}
+
+func badRequest(detail string) error {
+	return &secError{status: http.StatusBadRequest, detail: detail}
+}
+
+func (e *secError) Error() string {
+	return e.detail
+}
+
+func isSubPath(path, prefix string) bool {
+	return len(path) > len(prefix) && path[:len(prefix)] == prefix && path[len(prefix)] == '/'
+}
@@ -0,0 +1,177 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/akamai-playground/netlist"
+)
+
+const netListPrefix = "/network-list/v2/network-lists"
+
+// serveNetList routes the network lists API to the fake.NetList
+func (s *Server) serveNetList(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, netListPrefix) {
+		writeProblem(w, r, http.StatusNotFound, "Not Found", fmt.Sprintf("no route for %s", r.URL.Path))
+		return
+	}
+
+	ctx := r.Context()
+	q := r.URL.Query()
+	opts := &netlist.OptionalParams{
+		Extended:        q.Get("extended") == "true",
+		IncludeElements: q.Get("includeElements") == "true",
+	}
+	parts := route(r.URL.Path, netListPrefix)
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		params := netlist.ListNetworkListsRequest{OptionalParams: opts, Search: q.Get("search")}
+		if t := q.Get("listType"); t != "" {
+			listType, err := netlist.ParseNetworkType(t)
+			if err != nil {
+				writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+				return
+			}
+			params.ListType = listType
+		}
+		out, err := s.NetList.ListNetworkLists(ctx, params)
+		respond(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var body netlist.BodyNetworkListRequest
+		if err := readJSON(r, &body); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		body.GetNetworkListRequest = nil
+		out, err := s.NetList.CreateNetworkList(ctx, netlist.CreateNetworkListRequest{BodyNetworkListRequest: &body})
+		respond(w, r, http.StatusCreated, out, err)
+
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		out, err := s.NetList.GetNetworkList(ctx, netlist.GetNetworkListRequest{OptionalParams: opts, NetworkListID: parts[0]})
+		respond(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		var body netlist.UpdateNetworkListRequest
+		if err := readJSON(r, &body); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		if body.BodyNetworkListRequest == nil {
+			body.BodyNetworkListRequest = &netlist.BodyNetworkListRequest{}
+		}
+		body.GetNetworkListRequest = &netlist.GetNetworkListRequest{OptionalParams: opts, NetworkListID: parts[0]}
+		out, err := s.NetList.UpdateNetworkList(ctx, body)
+		respond(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		out, err := s.NetList.DeleteNetworkList(ctx, netlist.DeleteNetworkListRequest{
+			GetNetworkListRequest: &netlist.GetNetworkListRequest{NetworkListID: parts[0]},
+		})
+		respond(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 2 && parts[1] == "append" && r.Method == http.MethodPost:
+		var body netlist.AppendListRequest
+		if err := readJSON(r, &body); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		body.NetworkListID = parts[0]
+		out, err := s.NetList.AppendList(ctx, body)
+		respond(w, r, http.StatusAccepted, out, err)
+
+	case len(parts) == 2 && parts[1] == "elements" && r.Method == http.MethodPut:
+		out, err := s.NetList.AddElement(ctx, netlist.AddElementRequest{NetworkListID: parts[0], Element: q.Get("element")})
+		respond(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 2 && parts[1] == "elements" && r.Method == http.MethodDelete:
+		out, err := s.NetList.RemoveElement(ctx, netlist.RemoveElementRequest{
+			AddElementRequest: &netlist.AddElementRequest{NetworkListID: parts[0], Element: q.Get("element")},
+		})
+		respond(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 2 && parts[1] == "details" && r.Method == http.MethodPut:
+		var body netlist.UpdateNetworkListDetailsRequest
+		if err := readJSON(r, &body); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		body.NetworkListID = parts[0]
+		err := s.NetList.UpdateNetworkListDetails(ctx, body)
+		respond(w, r, http.StatusNoContent, nil, err)
+
+	case len(parts) == 4 && parts[1] == "environments" && parts[3] == "activate" && r.Method == http.MethodPost:
+		var body netlist.ActivateNetworkListRequest
+		if err := readJSON(r, &body); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		env, err := netlist.ParseEnvironment(parts[2])
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		body.NetworkListID, body.Environment = parts[0], env
+		out, err := s.NetList.ActivateNetworkList(ctx, body)
+		respond(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 4 && parts[1] == "environments" && parts[3] == "status" && r.Method == http.MethodGet:
+		env, err := netlist.ParseEnvironment(parts[2])
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		out, err := s.NetList.GetActivationNetworkList(ctx, netlist.ActivateNetworkListRequest{NetworkListID: parts[0], Environment: env})
+		respond(w, r, http.StatusOK, out, err)
+
+	case len(parts) == 4 && parts[1] == "sync-points" && parts[3] == "history" && r.Method == http.MethodGet:
+		syncPoint, err := strconv.Atoi(parts[2])
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", fmt.Sprintf("invalid sync point %q", parts[2]))
+			return
+		}
+		out, err := s.NetList.GetActivationSnapshot(ctx, netlist.GetActivationSnapshotRequest{
+			NetworkListID: parts[0],
+			Extended:      opts.Extended,
+			SyncPoint:     syncPoint,
+		})
+		respond(w, r, http.StatusOK, out, err)
+
+	default:
+		writeProblem(w, r, http.StatusNotFound, "Not Found", fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+// respond writes out with the success status, or err shaped as an API error
+func respond(w http.ResponseWriter, r *http.Request, status int, out interface{}, err error) {
+	if err == nil {
+		writeJSON(w, status, out)
+		return
+	}
+
+	var apiErr *netlist.Error
+	switch {
+	case errors.As(err, &apiErr):
+		if apiErr.StatusCode == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(apiErr.StatusCode)
+		_ = json.NewEncoder(w).Encode(problem{
+			Type:     apiErr.Type,
+			Title:    apiErr.Title,
+			Status:   apiErr.StatusCode,
+			Detail:   apiErr.Detail,
+			Instance: r.URL.Path,
+		})
+	case errors.Is(err, netlist.ErrStructValidation):
+		writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+	default:
+		writeProblem(w, r, http.StatusInternalServerError, "Internal Server Error", err.Error())
+	}
+}
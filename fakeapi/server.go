@@ -0,0 +1,274 @@
+// Package fakeapi provides a local HTTPS server speaking the network lists and
+// application security routes used by the netlist and appsec packages, so the
+// real session.Session path can be exercised end to end without network access
+package fakeapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akamai-playground/netlist/fake"
+	"github.com/akamai/AkamaiOPEN-edgegrid-golang/v2/pkg/edgegrid"
+	"github.com/akamai/AkamaiOPEN-edgegrid-golang/v2/pkg/session"
+)
+
+const (
+	// ClientToken is the client token expected in signed requests unless WithCredentials is used
+	ClientToken = "akab-client-token-fake"
+	// ClientSecret is the client secret written to the generated .edgerc
+	ClientSecret = "fake-client-secret"
+	// AccessToken is the access token expected in signed requests unless WithCredentials is used
+	AccessToken = "akab-access-token-fake"
+
+	authScheme      = "EG1-HMAC-SHA256 "
+	timestampFormat = "20060102T15:04:05-0700"
+	problemPrefix   = "https://problems.luna.akamaiapis.net/"
+)
+
+type (
+	// Server is a fake Akamai API server. State is kept in memory, network lists
+	// in a fake.NetList and security configurations in an AppSec store
+	Server struct {
+		*httptest.Server
+		NetList *fake.NetList
+		AppSec  *AppSec
+
+		clientToken  string
+		clientSecret string
+		accessToken  string
+
+		mu       sync.Mutex
+		requests []Request
+	}
+
+	// Request is a request received by the Server
+	Request struct {
+		Method string
+		Path   string
+		Query  url.Values
+		Body   []byte
+	}
+
+	// Option configures a Server
+	Option func(*Server)
+
+	// problem is an API error response, see https://tools.ietf.org/html/rfc7807
+	problem struct {
+		Type     string `json:"type"`
+		Title    string `json:"title"`
+		Status   int    `json:"status"`
+		Detail   string `json:"detail"`
+		Instance string `json:"instance,omitempty"`
+	}
+)
+
+// WithNetList serves the given network lists instead of an empty fake.NetList
+func WithNetList(n *fake.NetList) Option {
+	return func(s *Server) {
+		s.NetList = n
+	}
+}
+
+// WithAppSec serves the given security configurations instead of an empty store
+func WithAppSec(a *AppSec) Option {
+	return func(s *Server) {
+		s.AppSec = a
+	}
+}
+
+// WithCredentials sets the credentials the server hands out and expects
+func WithCredentials(clientToken, clientSecret, accessToken string) Option {
+	return func(s *Server) {
+		s.clientToken, s.clientSecret, s.accessToken = clientToken, clientSecret, accessToken
+	}
+}
+
+// NewServer starts a Server, it must be closed once done
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		clientToken:  ClientToken,
+		clientSecret: ClientSecret,
+		accessToken:  AccessToken,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.NetList == nil {
+		s.NetList = fake.New(fake.WithActivationPolls(0))
+	}
+	if s.AppSec == nil {
+		s.AppSec = NewAppSec()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/network-list/v2/", s.serveNetList)
+	mux.HandleFunc("/appsec/v1/", s.serveAppSec)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, http.StatusNotFound, "Not Found", fmt.Sprintf("no route for %s", r.URL.Path))
+	})
+	s.Server = httptest.NewTLSServer(s.record(s.authenticate(mux)))
+	return s
+}
+
+// Host returns the host:port of the server, as written in .edgerc
+func (s *Server) Host() string {
+	return s.Listener.Addr().String()
+}
+
+// Config returns the EdgeGrid credentials of the server
+func (s *Server) Config() *edgegrid.Config {
+	return &edgegrid.Config{
+		Host:         s.Host(),
+		ClientToken:  s.clientToken,
+		ClientSecret: s.clientSecret,
+		AccessToken:  s.accessToken,
+		MaxBody:      131072,
+	}
+}
+
+// Session returns a session signing requests with the server credentials
+// and trusting its certificate
+func (s *Server) Session(opts ...session.Option) (session.Session, error) {
+	opts = append([]session.Option{session.WithSigner(s.Config()), session.WithClient(s.Client())}, opts...)
+	return session.New(opts...)
+}
+
+// WriteEdgerc writes an .edgerc file pointing to the server under the given section.
+// The client reading it must trust the server certificate, see WriteCACert
+func (s *Server) WriteEdgerc(path, section string) error {
+	content := fmt.Sprintf("[%s]\nclient_secret = %s\nhost = %s\naccess_token = %s\nclient_token = %s\n",
+		section, s.clientSecret, s.Host(), s.accessToken, s.clientToken)
+	return ioutil.WriteFile(path, []byte(content), 0600)
+}
+
+// WriteCACert writes the self-signed certificate of the server as PEM, to be trusted
+// by clients reading the .edgerc written by WriteEdgerc, e.g. with --ca-cert
+func (s *Server) WriteCACert(path string) error {
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}
+	return ioutil.WriteFile(path, pem.EncodeToMemory(block), 0600)
+}
+
+// Requests returns the requests received so far, in order
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		r.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Query:  r.URL.Query(),
+			Body:   body,
+		})
+		s.mu.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate rejects the requests whose Authorization header is not shaped
+// like an EdgeGrid signature issued with the server credentials. The signature
+// itself is not recomputed
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := s.checkAuthorization(r.Header.Get("Authorization")); err != nil {
+			writeProblem(w, r, http.StatusUnauthorized, "Not authorized", err.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) checkAuthorization(header string) error {
+	if !strings.HasPrefix(header, authScheme) {
+		return fmt.Errorf("missing %sAuthorization header", authScheme)
+	}
+
+	fields := make(map[string]string)
+	for _, field := range strings.Split(strings.TrimPrefix(header, authScheme), ";") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("malformed Authorization field %q", field)
+		}
+		fields[kv[0]] = kv[1]
+	}
+
+	for _, name := range []string{"client_token", "access_token", "timestamp", "nonce", "signature"} {
+		if fields[name] == "" {
+			return fmt.Errorf("missing %s in Authorization header", name)
+		}
+	}
+	if fields["client_token"] != s.clientToken {
+		return fmt.Errorf("unknown client token %q", fields["client_token"])
+	}
+	if fields["access_token"] != s.accessToken {
+		return fmt.Errorf("invalid access token for client token %q", fields["client_token"])
+	}
+	if _, err := time.Parse(timestampFormat, fields["timestamp"]); err != nil {
+		return fmt.Errorf("invalid timestamp %q", fields["timestamp"])
+	}
+	if sig, err := base64.StdEncoding.DecodeString(fields["signature"]); err != nil || len(sig) != 32 {
+		return fmt.Errorf("signature is not a base64 encoded HMAC-SHA256")
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	if v == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, title, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(problem{
+		Type:     problemPrefix + strings.ToLower(strings.Replace(title, " ", "-", -1)),
+		Title:    title,
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+// route splits the path below prefix into its segments
+func route(path, prefix string) []string {
+	path = strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,273 @@
+package fakeapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/akamai-playground/appsec"
+	"github.com/akamai-playground/fakeapi"
+	"github.com/akamai-playground/netlist"
+)
+
+func TestAuthorization(t *testing.T) {
+	srv := fakeapi.NewServer()
+	defer srv.Close()
+
+	const valid = "client_token=" + fakeapi.ClientToken + ";access_token=" + fakeapi.AccessToken +
+		";timestamp=20200101T00:00:00+0000;nonce=n;signature=AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+	tests := map[string]struct {
+		header string
+		status int
+	}{
+		"signed request": {
+			header: "EG1-HMAC-SHA256 " + valid,
+			status: http.StatusOK,
+		},
+		"missing header": {
+			status: http.StatusUnauthorized,
+		},
+		"other scheme": {
+			header: "Bearer token",
+			status: http.StatusUnauthorized,
+		},
+		"unknown client token": {
+			header: "EG1-HMAC-SHA256 client_token=other;access_token=" + fakeapi.AccessToken +
+				";timestamp=20200101T00:00:00+0000;nonce=n;signature=AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			status: http.StatusUnauthorized,
+		},
+		"invalid access token": {
+			header: "EG1-HMAC-SHA256 client_token=" + fakeapi.ClientToken +
+				";access_token=other;timestamp=20200101T00:00:00+0000;nonce=n;signature=AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			status: http.StatusUnauthorized,
+		},
+		"invalid timestamp": {
+			header: "EG1-HMAC-SHA256 client_token=" + fakeapi.ClientToken + ";access_token=" + fakeapi.AccessToken +
+				";timestamp=yesterday;nonce=n;signature=AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			status: http.StatusUnauthorized,
+		},
+		"invalid signature": {
+			header: "EG1-HMAC-SHA256 client_token=" + fakeapi.ClientToken + ";access_token=" + fakeapi.AccessToken +
+				";timestamp=20200101T00:00:00+0000;nonce=n;signature=short",
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/network-list/v2/network-lists", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.header != "" {
+				req.Header.Set("Authorization", test.header)
+			}
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != test.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, test.status)
+			}
+		})
+	}
+}
+
+func TestNetListRoutes(t *testing.T) {
+	srv := fakeapi.NewServer()
+	defer srv.Close()
+	sess, err := srv.Session()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := netlist.Client(sess)
+	ctx := context.Background()
+
+	created, err := client.CreateNetworkList(ctx, netlist.CreateNetworkListRequest{
+		BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+			Name: "Blocked", Type: "IP", Description: "blocked", List: []string{"1.1.1.1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := created.UniqueID
+
+	if _, err := client.AppendList(ctx, netlist.AppendListRequest{NetworkListID: id, List: []string{"2.2.2.2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.RemoveElement(ctx, netlist.RemoveElementRequest{
+		AddElementRequest: &netlist.AddElementRequest{NetworkListID: id, Element: "1.1.1.1"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+		OptionalParams: &netlist.OptionalParams{IncludeElements: true},
+		NetworkListID:  id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2.2.2.2"}; !reflect.DeepEqual(list.List, want) || list.SyncPoint != 2 {
+		t.Errorf("list at sync point %d with %v, want 2 with %v", list.SyncPoint, list.List, want)
+	}
+
+	lists, err := client.ListNetworkLists(ctx, netlist.ListNetworkListsRequest{
+		OptionalParams: &netlist.OptionalParams{},
+		ListType:       netlist.IP,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists.NetworkLists) != 1 || lists.NetworkLists[0].UniqueID != id {
+		t.Errorf("ListNetworkLists() returned %d lists, want %s", len(lists.NetworkLists), id)
+	}
+
+	tests := map[string]struct {
+		call func() error
+		kind error
+	}{
+		"missing list": {
+			call: func() error {
+				_, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+					OptionalParams: &netlist.OptionalParams{},
+					NetworkListID:  "1_MISSING",
+				})
+				return err
+			},
+			kind: netlist.ErrNotFound,
+		},
+		"stale sync point": {
+			call: func() error {
+				_, err := client.UpdateNetworkList(ctx, netlist.UpdateNetworkListRequest{
+					BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+						GetNetworkListRequest: &netlist.GetNetworkListRequest{
+							OptionalParams: &netlist.OptionalParams{},
+							NetworkListID:  id,
+						},
+						Name:        "Blocked",
+						Type:        "IP",
+						Description: "blocked",
+						List:        []string{"3.3.3.3"},
+					},
+					SyncPoint: 1,
+				})
+				return err
+			},
+			kind: netlist.ErrConflict,
+		},
+		"missing element": {
+			call: func() error {
+				_, err := client.RemoveElement(ctx, netlist.RemoveElementRequest{
+					AddElementRequest: &netlist.AddElementRequest{NetworkListID: id, Element: "9.9.9.9"},
+				})
+				return err
+			},
+			kind: netlist.ErrNotFound,
+		},
+		"invalid element": {
+			call: func() error {
+				_, err := client.AppendList(ctx, netlist.AppendListRequest{NetworkListID: id, List: []string{"FR"}})
+				return err
+			},
+			kind: netlist.ErrValidation,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.call()
+			var apiErr *netlist.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *netlist.Error", err)
+			}
+			if !errors.Is(err, test.kind) {
+				t.Errorf("error = %v, want %v", err, test.kind)
+			}
+		})
+	}
+}
+
+func TestAppSecRoutes(t *testing.T) {
+	srv := fakeapi.NewServer()
+	defer srv.Close()
+	config := srv.AppSec.AddConfig("Site", "site protection")
+	if err := srv.AppSec.AddPolicy(config.ID, 1, appsec.Policies{PolicyID: "POL_1", PolicyName: "Default"}); err != nil {
+		t.Fatal(err)
+	}
+	rules := []appsec.RuleActions{{ID: 950002, Action: "deny"}, {ID: 950006, Action: "alert"}}
+	if err := srv.AppSec.SetRules(config.ID, 1, "POL_1", rules); err != nil {
+		t.Fatal(err)
+	}
+
+	sess, err := srv.Session()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := appsec.Client(sess)
+	ctx := context.Background()
+
+	configs, err := client.GetConfigs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs.Configs) != 1 || configs.Configs[0].ID != config.ID {
+		t.Errorf("GetConfigs() = %+v, want configuration %d", configs.Configs, config.ID)
+	}
+
+	policies, err := client.GetPolicies(ctx, config.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies.Policies) != 1 || policies.Policies[0].PolicyID != "POL_1" {
+		t.Errorf("GetPolicies() = %+v, want POL_1", policies.Policies)
+	}
+
+	got, err := client.GetRules(ctx, config.ID, 1, "POL_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.RuleActions) != len(rules) || *got.RuleActions[0] != rules[0] {
+		t.Errorf("GetRules() = %v, want %v", got.RuleActions, rules)
+	}
+
+	tests := map[string]struct {
+		call func() error
+	}{
+		"missing configuration": {
+			call: func() error {
+				_, err := client.GetPolicies(ctx, 1, 1)
+				return err
+			},
+		},
+		"missing version": {
+			call: func() error {
+				_, err := client.GetPolicies(ctx, config.ID, 2)
+				return err
+			},
+		},
+		"missing policy": {
+			call: func() error {
+				_, err := client.GetRules(ctx, config.ID, 1, "POL_2")
+				return err
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.call()
+			var apiErr *appsec.Error
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+				t.Fatalf("error = %v, want a 404 *appsec.Error", err)
+			}
+			if !errors.Is(err, appsec.ErrNotFound) {
+				t.Errorf("error = %v, want %v", err, appsec.ErrNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+// Package feed parses threat intelligence blocklists and applies them to IP network lists
+package feed
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/akamai-playground/input"
+	"github.com/akamai-playground/netlist"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Formats of the feeds
+const (
+	// FormatPlain is one IP address or CIDR block per line, optionally annotated after # or ;
+	FormatPlain Format = "plain"
+	// FormatDROP is the Spamhaus DROP format: "CIDR ; SBL id" lines below ; comments
+	FormatDROP Format = "drop"
+	// FormatNetset is a FireHOL netset: one IP address or CIDR block per line below a # header
+	FormatNetset Format = "netset"
+	// FormatCSV is a CSV file whose header row names the IP column, see Options.Column
+	FormatCSV Format = "csv"
+)
+
+// DefaultColumn is the header of the IP column of CSV feeds when Options.Column is empty
+const DefaultColumn = "ip"
+
+var (
+	// ErrUnknownFormat is returned for formats other than plain, drop, netset and csv
+	ErrUnknownFormat = errors.New("unknown feed format")
+	// ErrNoColumn is reported for the CSV lines read before a header row naming the IP column
+	ErrNoColumn = errors.New("no IP column")
+)
+
+type (
+	// Format is the layout of a feed
+	Format string
+
+	// Options tells how a feed is parsed
+	Options struct {
+		Format Format
+		// Column is the header of the IP column of CSV feeds, DefaultColumn when empty
+		Column string
+	}
+
+	// ApplyRequest describes the IP list to reconcile with the elements of a feed
+	ApplyRequest struct {
+		NetworkListID string
+		// Elements are normalized before being compared with the list, elements
+		// of the list equal to a feed element written differently are kept as is.
+		// They are required, an empty feed is most likely a failed download
+		Elements []string
+		// Aggregate merges the adjacent prefixes of the feed
+		Aggregate bool
+		// ElementCallsLimit is the maximum number of AddElement and RemoveElement
+		// calls, bigger changes replace the list through UpdateNetworkList
+		ElementCallsLimit int
+		// Policy tells how elements in special purpose ranges are validated
+		Policy *netlist.ElementPolicy
+	}
+)
+
+// ParseFormat returns the Format matching the given name (case insensitive)
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatPlain, FormatDROP, FormatNetset, FormatCSV:
+		return f, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+}
+
+// Read reads the feed elements of the sources, see input.Reader.Read for the
+// sources accepted. Every element must be an IP address or a CIDR block,
+// the other ones are reported as input.LineErrors
+func Read(opts Options, sources ...string) ([]input.Element, error) {
+	r, err := newReader(opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.Read(sources...)
+}
+
+// Parse reads the feed elements of in, naming it source in the elements and the errors
+func Parse(in io.Reader, source string, opts Options) ([]input.Element, error) {
+	r, err := newReader(opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.Parse(in, source)
+}
+
+func newReader(opts Options) (*input.Reader, error) {
+	var split input.LineParser
+	switch opts.Format {
+	case FormatPlain, FormatNetset:
+		split = input.SplitAnnotation
+	case FormatDROP:
+		split = splitDROP
+	case FormatCSV:
+		column := opts.Column
+		if column == "" {
+			column = DefaultColumn
+		}
+		split = csvColumn(column)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, opts.Format)
+	}
+
+	return input.NewReader(input.WithLineParser(split), input.WithValidator(func(value string) error {
+		_, err := netlist.ParseIPElement(value)
+		return err
+	})), nil
+}
+
+// splitDROP skips the ; comments of DROP feeds
+func splitDROP(line string) (string, string, error) {
+	if strings.HasPrefix(line, ";") {
+		return "", "", nil
+	}
+	return input.SplitAnnotation(line)
+}
+
+// csvColumn returns a LineParser reading the given column. Every line holding
+// the column name is a header row, so that several CSV files can be read in a row
+func csvColumn(name string) input.LineParser {
+	index := -1
+	return func(line string) (string, string, error) {
+		cr := csv.NewReader(strings.NewReader(line))
+		cr.TrimLeadingSpace = true
+		record, err := cr.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				err = parseErr.Err
+			}
+			return "", "", err
+		}
+
+		for i, field := range record {
+			if strings.EqualFold(strings.TrimSpace(field), name) {
+				index = i
+				return "", "", nil
+			}
+		}
+		switch {
+		case index < 0:
+			return "", "", fmt.Errorf("%w: no header row with a %q column", ErrNoColumn, name)
+		case index >= len(record):
+			return "", "", fmt.Errorf("%d fields, want at least %d", len(record), index+1)
+		}
+		return strings.TrimSpace(record[index]), "", nil
+	}
+}
+
+// SyncRequest returns the request reconciling the list with the feed elements,
+// the feed is normalized against the list retrieved with GetNetworkList
+func SyncRequest(ctx context.Context, client netlist.NetworkList, params ApplyRequest) (*netlist.SyncNetworkListRequest, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+
+	current, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+		OptionalParams: &netlist.OptionalParams{
+			Extended:        true,
+			IncludeElements: true,
+		},
+		NetworkListID: params.NetworkListID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(current.Type, netlist.IP.String()) {
+		return nil, fmt.Errorf("%w: %s is %s, feeds go to IP lists", netlist.ErrTypeMismatch, current.UniqueID, current.Type)
+	}
+
+	desired, err := netlist.NormalizeIPElements(params.Elements, params.Aggregate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+
+	// elements are compared as strings, keep the spelling of the list
+	// so that 1.1.1.1/32 is not replaced by 1.1.1.1
+	spelling := make(map[string]string, len(current.List))
+	for _, e := range current.List {
+		if n, err := netlist.ParseIPElement(e); err == nil {
+			spelling[n.String()] = e
+		}
+	}
+	for i, e := range desired {
+		n, err := netlist.ParseIPElement(e)
+		if err != nil {
+			continue
+		}
+		if live, ok := spelling[n.String()]; ok {
+			desired[i] = live
+		}
+	}
+
+	return &netlist.SyncNetworkListRequest{
+		Desired: netlist.DesiredNetworkList{
+			UniqueID:    current.UniqueID,
+			Name:        current.Name,
+			Type:        current.Type,
+			Description: current.Description,
+			List:        desired,
+		},
+		ElementCallsLimit: params.ElementCallsLimit,
+		Policy:            params.Policy,
+	}, nil
+}
+
+// Apply reconciles the list with the feed elements: missing elements are appended,
+// small changes go through AddElement and RemoveElement and bigger ones replace
+// the list through UpdateNetworkList, guarded by its sync point. See netlist.SyncNetworkList
+func Apply(ctx context.Context, client netlist.NetworkList, params ApplyRequest) (*netlist.SyncNetworkListResponse, error) {
+	req, err := SyncRequest(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+	return netlist.SyncNetworkList(ctx, client, *req)
+}
+
+// Plan describes what Apply would change, the elements diff included
+func Plan(ctx context.Context, client netlist.NetworkList, params ApplyRequest) (*netlist.Plan, error) {
+	req, err := SyncRequest(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+	return netlist.PlanSyncNetworkList(ctx, client, *req)
+}
+
+// Validate validates ApplyRequest
+func (v ApplyRequest) Validate() error {
+	return validation.Errors{
+		"networkListId":     validation.Validate(v.NetworkListID, validation.Required),
+		"elements":          validation.Validate(v.Elements, validation.Required),
+		"elementCallsLimit": validation.Validate(v.ElementCallsLimit, validation.Min(0)),
+	}.Filter()
+}
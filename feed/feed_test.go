@@ -0,0 +1,259 @@
+package feed_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/akamai-playground/feed"
+	"github.com/akamai-playground/input"
+	"github.com/akamai-playground/netlist"
+	"github.com/akamai-playground/netlist/fake"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		opts  feed.Options
+		input string
+		want  []input.Element
+	}{
+		"plain": {
+			opts:  feed.Options{Format: feed.FormatPlain},
+			input: "# blocked\n1.1.1.1\n10.0.0.0/8 # scanner\n",
+			want: []input.Element{
+				{Value: "1.1.1.1", Source: "feed", Line: 2},
+				{Value: "10.0.0.0/8", Annotation: "scanner", Source: "feed", Line: 3},
+			},
+		},
+		"drop": {
+			opts:  feed.Options{Format: feed.FormatDROP},
+			input: "; Spamhaus DROP List 2020/06/08\n; Last-Modified: Mon, 08 Jun 2020\n1.10.16.0/20 ; SBL256894\n1.19.0.0/16 ; SBL434604\n",
+			want: []input.Element{
+				{Value: "1.10.16.0/20", Annotation: "SBL256894", Source: "feed", Line: 3},
+				{Value: "1.19.0.0/16", Annotation: "SBL434604", Source: "feed", Line: 4},
+			},
+		},
+		"netset": {
+			opts:  feed.Options{Format: feed.FormatNetset},
+			input: "#\n# firehol_level1\n#\n# Maintainer      : FireHOL\n#\n0.0.0.0/8\n1.10.16.0/20\r\n",
+			want: []input.Element{
+				{Value: "0.0.0.0/8", Source: "feed", Line: 6},
+				{Value: "1.10.16.0/20", Source: "feed", Line: 7},
+			},
+		},
+		"csv with the default column": {
+			opts:  feed.Options{Format: feed.FormatCSV},
+			input: "# exported\nfirst_seen,IP,port\n2020-06-01,1.1.1.1,443\n\"2020-06-02\", 2.2.2.2 ,80\n",
+			want: []input.Element{
+				{Value: "1.1.1.1", Source: "feed", Line: 3},
+				{Value: "2.2.2.2", Source: "feed", Line: 4},
+			},
+		},
+		"csv with a named column": {
+			opts:  feed.Options{Format: feed.FormatCSV, Column: "dst_ip"},
+			input: "dst_ip,dst_port\n3.3.3.3,443\n",
+			want:  []input.Element{{Value: "3.3.3.3", Source: "feed", Line: 2}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := feed.Parse(strings.NewReader(test.input), "feed", test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parsed %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]struct {
+		opts  feed.Options
+		input string
+		lines []int
+		err   error
+	}{
+		"invalid addresses": {
+			opts:  feed.Options{Format: feed.FormatPlain},
+			input: "1.1.1.1\nexample.com\n1.1.1.300\n",
+			lines: []int{2, 3},
+		},
+		"drop line with two elements": {
+			opts:  feed.Options{Format: feed.FormatDROP},
+			input: "; comment\n1.10.16.0/20 ; SBL256894\n1.19.0.0/16 1.20.0.0/16 ; SBL434604\n",
+			lines: []int{3},
+		},
+		"csv without header": {
+			opts:  feed.Options{Format: feed.FormatCSV},
+			input: "1.1.1.1,443\n",
+			lines: []int{1},
+		},
+		"csv short row": {
+			opts:  feed.Options{Format: feed.FormatCSV},
+			input: "port,ip\n443,1.1.1.1\n80\n",
+			lines: []int{3},
+		},
+		"unknown format": {
+			opts: feed.Options{Format: "json"},
+			err:  feed.ErrUnknownFormat,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := feed.Parse(strings.NewReader(test.input), "feed", test.opts)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			var lineErrs input.LineErrors
+			if !errors.As(err, &lineErrs) {
+				t.Fatalf("error = %v, want input.LineErrors", err)
+			}
+			var lines []int
+			for _, e := range lineErrs {
+				lines = append(lines, e.Line)
+			}
+			if !reflect.DeepEqual(lines, test.lines) {
+				t.Errorf("malformed lines %v, want %v: %v", lines, test.lines, err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := map[string]struct {
+		list      []string
+		elements  []string
+		aggregate bool
+		strategy  netlist.SyncStrategy
+		want      []string
+		calls     map[string]int
+	}{
+		"additions are appended": {
+			list:     []string{"1.1.1.1"},
+			elements: []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"},
+			strategy: netlist.SyncAppend,
+			want:     []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"},
+			calls:    map[string]int{fake.MethodAppendList: 1},
+		},
+		"small changes go element by element": {
+			list:     []string{"1.1.1.1", "2.2.2.2"},
+			elements: []string{"1.1.1.1", "3.3.3.3"},
+			strategy: netlist.SyncElements,
+			want:     []string{"1.1.1.1", "3.3.3.3"},
+			calls:    map[string]int{fake.MethodAddElement: 1, fake.MethodRemoveElement: 1},
+		},
+		"big changes replace the list": {
+			list:     []string{"1.1.1.1", "1.1.1.2", "1.1.1.3", "1.1.1.4", "1.1.1.5", "1.1.1.6"},
+			elements: []string{"2.2.2.1", "2.2.2.2", "2.2.2.3", "2.2.2.4", "2.2.2.5", "2.2.2.6"},
+			strategy: netlist.SyncUpdate,
+			want:     []string{"2.2.2.1", "2.2.2.2", "2.2.2.3", "2.2.2.4", "2.2.2.5", "2.2.2.6"},
+			calls:    map[string]int{fake.MethodUpdateNetworkList: 1},
+		},
+		"list spelling is kept": {
+			list:     []string{"1.1.1.1/32", "10.0.0.0/8"},
+			elements: []string{"1.1.1.1", "10.1.2.3/8", "10.0.0.0/8"},
+			strategy: netlist.SyncNone,
+			want:     []string{"1.1.1.1/32", "10.0.0.0/8"},
+			calls:    map[string]int{},
+		},
+		"aggregated feed": {
+			list:      []string{"10.0.0.0/25"},
+			elements:  []string{"10.0.0.0/25", "10.0.0.128/25"},
+			aggregate: true,
+			strategy:  netlist.SyncElements,
+			want:      []string{"10.0.0.0/24"},
+			calls:     map[string]int{fake.MethodAddElement: 1, fake.MethodRemoveElement: 1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.New()
+			l := client.Seed(netlist.NetworkListResponse{Name: "Feed", Type: netlist.IP.String(), List: test.list})
+			params := feed.ApplyRequest{
+				NetworkListID:     l.UniqueID,
+				Elements:          test.elements,
+				Aggregate:         test.aggregate,
+				ElementCallsLimit: 4,
+				Policy:            &netlist.ElementPolicy{},
+			}
+
+			plan, err := feed.Plan(ctx, client, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := feed.Apply(ctx, client, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Strategy != test.strategy || plan.Strategy != test.strategy {
+				t.Errorf("applied with %s, planned %s, want %s", out.Strategy, plan.Strategy, test.strategy)
+			}
+			if !reflect.DeepEqual(plan.ElementsDiff, out.ElementsDiff) {
+				t.Errorf("planned %+v, applied %+v", plan.ElementsDiff, out.ElementsDiff)
+			}
+
+			got := append([]string{}, out.List.List...)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("list holds %v, want %v", got, test.want)
+			}
+			for _, method := range []string{fake.MethodAppendList, fake.MethodAddElement, fake.MethodRemoveElement, fake.MethodUpdateNetworkList} {
+				if calls := client.Calls(method); calls != test.calls[method] {
+					t.Errorf("%d %s calls, want %d", calls, method, test.calls[method])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	ctx := context.Background()
+	client := fake.New()
+	geo := client.Seed(netlist.NetworkListResponse{Name: "Countries", Type: netlist.GEO.String(), List: []string{"FR"}})
+	ip := client.Seed(netlist.NetworkListResponse{Name: "Feed", Type: netlist.IP.String(), List: []string{"1.1.1.1"}})
+
+	tests := map[string]struct {
+		params feed.ApplyRequest
+		err    error
+	}{
+		"GEO list": {
+			params: feed.ApplyRequest{NetworkListID: geo.UniqueID, Elements: []string{"1.1.1.1"}},
+			err:    netlist.ErrTypeMismatch,
+		},
+		"empty feed": {
+			params: feed.ApplyRequest{NetworkListID: ip.UniqueID},
+			err:    netlist.ErrStructValidation,
+		},
+		"invalid element": {
+			params: feed.ApplyRequest{NetworkListID: ip.UniqueID, Elements: []string{"example.com"}},
+			err:    netlist.ErrStructValidation,
+		},
+		"missing list": {
+			params: feed.ApplyRequest{NetworkListID: "404_MISSING", Elements: []string{"1.1.1.1"}},
+			err:    netlist.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := feed.Apply(ctx, client, test.params); !errors.Is(err, test.err) {
+				t.Errorf("error = %v, want %v", err, test.err)
+			}
+		})
+	}
+	if calls := client.Calls(fake.MethodUpdateNetworkList) + client.Calls(fake.MethodAppendList); calls != 0 {
+		t.Errorf("%d list changes, want none", calls)
+	}
+}
@@ -1,62 +1,34 @@
 package main
 
 import (
-	"bufio"
-	"os"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 
-	"github.com/apex/log"
+	"github.com/akamai-playground/netlist"
 )
 
-// var countriesISO = []string{"AD", "AE", "AF", "AG", "AI", "AL", "AM", "AO", "AQ", "AR", "AS", "AT",
-// 	"AU", "AW", "AZ", "BA", "BB", "BD", "BE", "BF", "BG", "BH", "BI", "BJ", "BL", "BM", "BN", "BO",
-// 	"BQ", "BR", "BS", "BT", "BV", "BW", "BY", "BZ", "CA", "CC", "CD", "CF", "CG", "CH", "CI", "CK",
-// 	"CL", "CM", "CN", "CO", "CR", "CU", "CV", "CW", "CX", "CY", "CZ", "DE", "DJ", "DK", "DM", "DO",
-// 	"DZ", "EC", "EE", "EG", "EH", "ER", "ES", "ET", "EU", "FI", "FJ", "FK", "FM", "FO", "FR", "GA",
-// 	"GB", "GD", "GE", "GF", "GH", "GI", "GG", "GL", "GM", "GN", "GP", "GQ", "GR", "GS", "GT", "GU",
-// 	"GW", "GY", "HK", "HM", "HN", "HR", "HT", "HU", "ID", "IE", "IL", "IM", "IN", "IO", "IQ", "IR",
-// 	"IS", "IT", "JE", "JM", "JO", "JP", "KE", "KG", "KH", "KI", "KM", "KN", "KP", "KR", "KW", "KY",
-// 	"KZ", "LA", "LB", "LC", "LI", "LK", "LR", "LS", "LT", "LU", "LV", "LY", "MA", "MC", "MD", "ME",
-// 	"MF", "MG", "MH", "MK", "ML", "MM", "MN", "MO", "MP", "MQ", "MR", "MS", "MT", "MU", "MV", "MW",
-// 	"MX", "MY", "MZ", "NA", "NC", "NE", "NF", "NG", "NI", "NL", "NO", "NP", "NR", "NU", "NZ", "OM",
-// 	"PA", "PE", "PF", "PG", "PH", "PK", "PL", "PM", "PN", "PR", "PS", "PT", "PW", "PY", "QA", "RE",
-// 	"RO", "RS", "RU", "RW", "SA", "SB", "SC", "SD", "SE", "SG", "SH", "SI", "SJ", "SK", "SL", "SM",
-// 	"SN", "SO", "SR", "SS", "ST", "SV", "SX", "SY", "SZ", "TC", "TD", "TF", "TG", "TH", "TJ", "TK",
-// 	"TM", "TN", "TO", "TL", "TR", "TT", "TV", "TW", "TZ", "UA", "UG", "UM", "US", "UY", "UZ", "VA",
-// 	"VC", "VE", "VG", "VI", "VN", "VU", "WF", "WS", "YE", "YT", "ZA", "ZM", "ZW"}
-
-func randomIP() (IPList []string) {
-	file, err := os.Open("data/ip_addresses")
+// readDesiredState parses a JSON desired state file used by netlist sync.
+// The elements are plain strings or, as written by netlist export, objects
+// holding a value and a comment
+func readDesiredState(path string) (*netlist.DesiredNetworkList, error) {
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		log.Fatalf("%v", err)
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		IPList = append(IPList, scanner.Text())
-	}
-
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("%v", err)
+		return nil, err
 	}
-	return
-}
 
-// ISOCountries returs the ISO list of the countries
-func ISOCountries() (countries []string) {
-	file, err := os.Open("data/countries")
-	if err != nil {
-		log.Fatalf("%v", err)
+	var state struct {
+		netlist.DesiredNetworkList
+		List []netlist.ListElement `json:"list"`
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		countries = append(countries, scanner.Text())
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("%v", err)
+	desired := state.DesiredNetworkList
+	desired.List = make([]string, 0, len(state.List))
+	for _, e := range state.List {
+		desired.List = append(desired.List, e.Value)
 	}
-	return
+	return &desired, nil
 }
@@ -6,4 +6,5 @@ require (
 	github.com/akamai/AkamaiOPEN-edgegrid-golang/v2 v2.0.1
 	github.com/apex/log v1.9.0
 	github.com/go-ozzo/ozzo-validation/v4 v4.2.2
+	gopkg.in/yaml.v3 v3.0.0-20200605160147-a5ece683394c
 )
@@ -0,0 +1,300 @@
+// Package input reads network list elements from files, the standard input,
+// file:// URLs and glob patterns, gzip compressed or not
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// ErrNoMatch is returned when a glob pattern matches no file
+	ErrNoMatch = errors.New("no file matches the pattern")
+	// ErrUnsupportedURL is returned for URLs other than file:// URLs to local files
+	ErrUnsupportedURL = errors.New("only file:// URLs to local files are supported")
+)
+
+// Stdin is the source reading the standard input
+const Stdin = "-"
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+type (
+	// Element is an element read from a source line, along with the annotation
+	// following it on that line
+	Element struct {
+		Value      string
+		Annotation string
+		Source     string
+		Line       int
+	}
+
+	// LineError describes a malformed line of a source
+	LineError struct {
+		Source string
+		Line   int
+		Text   string
+		Reason string
+	}
+
+	// LineErrors lists every malformed line of the sources read
+	LineErrors []LineError
+
+	// LineParser splits a trimmed line, neither blank nor a # or ; comment, into
+	// its element and its annotation. Lines with an empty element are skipped
+	LineParser func(line string) (value, annotation string, err error)
+
+	// Reader reads elements from sources, see Read
+	Reader struct {
+		stdin    io.Reader
+		validate func(string) error
+		split    LineParser
+	}
+
+	// Option configures a Reader
+	Option func(*Reader)
+)
+
+// NewReader returns a Reader reading the standard input from os.Stdin
+func NewReader(opts ...Option) *Reader {
+	r := &Reader{stdin: os.Stdin, split: SplitAnnotation}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithStdin sets the reader the Stdin source reads from
+func WithStdin(stdin io.Reader) Option {
+	return func(r *Reader) {
+		r.stdin = stdin
+	}
+}
+
+// WithValidator reports the elements rejected by validate as malformed lines
+func WithValidator(validate func(value string) error) Option {
+	return func(r *Reader) {
+		r.validate = validate
+	}
+}
+
+// WithLineParser replaces SplitAnnotation, e.g. to read a column of CSV lines
+func WithLineParser(parse LineParser) Option {
+	return func(r *Reader) {
+		r.split = parse
+	}
+}
+
+// SplitAnnotation is the default LineParser, it splits the line on
+// its first # or ; separator
+func SplitAnnotation(line string) (value, annotation string, err error) {
+	value = line
+	if idx := strings.IndexAny(line, "#;"); idx >= 0 {
+		value = strings.TrimSpace(line[:idx])
+		annotation = strings.TrimSpace(line[idx+1:])
+	}
+	if value == "" {
+		return "", "", errors.New("annotation without element")
+	}
+	return value, annotation, nil
+}
+
+// Read reads the elements of the sources with a default Reader, see Reader.Read
+func Read(sources ...string) ([]Element, error) {
+	return NewReader().Read(sources...)
+}
+
+// Values returns the values of the elements
+func Values(elements []Element) []string {
+	values := make([]string, 0, len(elements))
+	for _, e := range elements {
+		values = append(values, e.Value)
+	}
+	return values
+}
+
+// Read reads the elements of the sources in order. A source is Stdin, a file path,
+// a file:// URL or a glob pattern, whose files are read in lexical order.
+// Gzip compressed sources are decompressed whatever their name.
+//
+// Every line holds one element, optionally followed by an annotation after
+// a # or a ; separator. Blank lines and lines starting with # or ; are skipped,
+// CRLF line endings and a leading byte order mark are accepted.
+// Sources which cannot be opened or read are returned as soon as they fail,
+// malformed lines are collected over every source and returned as LineErrors
+func (r *Reader) Read(sources ...string) ([]Element, error) {
+	var (
+		elements []Element
+		lineErrs LineErrors
+	)
+	for _, source := range sources {
+		paths, err := resolve(source)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			read, errs, err := r.readSource(path)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, read...)
+			lineErrs = append(lineErrs, errs...)
+		}
+	}
+
+	if len(lineErrs) > 0 {
+		return nil, lineErrs
+	}
+	return elements, nil
+}
+
+// Parse reads the elements of in, naming it source in the elements and the errors
+func (r *Reader) Parse(in io.Reader, source string) ([]Element, error) {
+	elements, lineErrs, err := r.parse(in, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(lineErrs) > 0 {
+		return nil, lineErrs
+	}
+	return elements, nil
+}
+
+// resolve returns the paths a source stands for
+func resolve(source string) ([]string, error) {
+	if source == Stdin {
+		return []string{Stdin}, nil
+	}
+
+	if strings.Contains(source, "://") {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", source, err)
+		}
+		if u.Scheme != "file" || (u.Host != "" && u.Host != "localhost") {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedURL, source)
+		}
+		return []string{filepath.FromSlash(u.Path)}, nil
+	}
+
+	if !strings.ContainsAny(source, `*?[`) {
+		return []string{source}, nil
+	}
+	paths, err := filepath.Glob(source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoMatch, source)
+	}
+	return paths, nil
+}
+
+func (r *Reader) readSource(path string) ([]Element, LineErrors, error) {
+	if path == Stdin {
+		return r.parse(r.stdin, "stdin")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	return r.parse(file, path)
+}
+
+// parse reads the lines of in, decompressing it when it starts with the gzip magic number
+func (r *Reader) parse(in io.Reader, source string) ([]Element, LineErrors, error) {
+	br := bufio.NewReader(in)
+	if magic, err := br.Peek(len(gzipMagic)); err == nil && bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", source, err)
+		}
+		defer gz.Close()
+		in = gz
+	} else {
+		in = br
+	}
+
+	var (
+		elements []Element
+		lineErrs LineErrors
+	)
+	scanner := bufio.NewScanner(in)
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		if n == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		e, reason := r.parseLine(text)
+		switch {
+		case reason != "":
+			lineErrs = append(lineErrs, LineError{Source: source, Line: n, Text: strings.TrimSpace(text), Reason: reason})
+		case e.Value != "":
+			e.Source, e.Line = source, n
+			elements = append(elements, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", source, err)
+	}
+	return elements, lineErrs, nil
+}
+
+// parseLine returns the element of a line, an empty one for skipped lines,
+// or the reason why the line is malformed
+func (r *Reader) parseLine(text string) (Element, string) {
+	if !utf8.ValidString(text) {
+		return Element{}, "invalid UTF-8"
+	}
+	text = strings.TrimSpace(text)
+	if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+		return Element{}, ""
+	}
+
+	value, annotation, err := r.split(text)
+	if err != nil {
+		return Element{}, err.Error()
+	}
+	if value == "" {
+		return Element{}, ""
+	}
+
+	if fields := strings.Fields(value); len(fields) > 1 {
+		return Element{}, fmt.Sprintf("%d elements, want one per line", len(fields))
+	}
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return Element{}, "control character in element"
+	}
+	if r.validate != nil {
+		if err := r.validate(value); err != nil {
+			return Element{}, err.Error()
+		}
+	}
+	return Element{Value: value, Annotation: annotation}, ""
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("%s:%d: %q: %s", e.Source, e.Line, e.Text, e.Reason)
+}
+
+func (e LineErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, el := range e {
+		msgs = append(msgs, el.Error())
+	}
+	return fmt.Sprintf("%d malformed lines: %s", len(e), strings.Join(msgs, "; "))
+}
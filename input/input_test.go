@@ -0,0 +1,176 @@
+package input_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akamai-playground/input"
+)
+
+func writeFiles(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	dir := writeFiles(t, map[string][]byte{
+		"a.txt":   []byte("\ufeff# blocked addresses\r\n1.1.1.1\r\n\r\n  2.2.2.2  # abuse report\r\n"),
+		"b.txt":   []byte("; Spamhaus DROP List\n10.0.0.0/8 ; SBL123\n"),
+		"c.gz":    gzipped(t, "3.3.3.3\n4.4.4.4 # compressed\n"),
+		"d.other": []byte("5.5.5.5"),
+	})
+	defer os.RemoveAll(dir)
+	a, b, c := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), filepath.Join(dir, "c.gz")
+
+	tests := map[string]struct {
+		sources []string
+		stdin   string
+		want    []input.Element
+	}{
+		"comments, annotations and CRLF": {
+			sources: []string{a},
+			want: []input.Element{
+				{Value: "1.1.1.1", Source: a, Line: 2},
+				{Value: "2.2.2.2", Annotation: "abuse report", Source: a, Line: 4},
+			},
+		},
+		"gzip": {
+			sources: []string{c},
+			want: []input.Element{
+				{Value: "3.3.3.3", Source: c, Line: 1},
+				{Value: "4.4.4.4", Annotation: "compressed", Source: c, Line: 2},
+			},
+		},
+		"glob in lexical order": {
+			sources: []string{filepath.Join(dir, "[ab].txt")},
+			want: []input.Element{
+				{Value: "1.1.1.1", Source: a, Line: 2},
+				{Value: "2.2.2.2", Annotation: "abuse report", Source: a, Line: 4},
+				{Value: "10.0.0.0/8", Annotation: "SBL123", Source: b, Line: 2},
+			},
+		},
+		"file URL": {
+			sources: []string{"file://" + filepath.ToSlash(b)},
+			want:    []input.Element{{Value: "10.0.0.0/8", Annotation: "SBL123", Source: b, Line: 2}},
+		},
+		"stdin and files": {
+			sources: []string{input.Stdin, b},
+			stdin:   "FR\nUS # until further notice",
+			want: []input.Element{
+				{Value: "FR", Source: "stdin", Line: 1},
+				{Value: "US", Annotation: "until further notice", Source: "stdin", Line: 2},
+				{Value: "10.0.0.0/8", Annotation: "SBL123", Source: b, Line: 2},
+			},
+		},
+		"no source": {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := input.NewReader(input.WithStdin(strings.NewReader(test.stdin))).Read(test.sources...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("read %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	dir := writeFiles(t, map[string][]byte{
+		"a.txt": []byte("1.1.1.1\n1.1.1.2 1.1.1.3\n# fine\n; fine too\n"),
+		"b.txt": []byte("2.2.2.2\nbad\x00element\n\xff\xfe\n"),
+	})
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+
+	_, err := input.Read(a, b)
+	var lineErrs input.LineErrors
+	if !errors.As(err, &lineErrs) {
+		t.Fatalf("error = %v, want input.LineErrors", err)
+	}
+	want := input.LineErrors{
+		{Source: a, Line: 2, Text: "1.1.1.2 1.1.1.3", Reason: "2 elements, want one per line"},
+		{Source: b, Line: 2, Text: "bad\x00element", Reason: "control character in element"},
+		{Source: b, Line: 3, Text: "\xff\xfe", Reason: "invalid UTF-8"},
+	}
+	if !reflect.DeepEqual(lineErrs, want) {
+		t.Errorf("line errors %+v, want %+v", lineErrs, want)
+	}
+	if msg := fmt.Sprintf("%s:2: %q: 2 elements, want one per line", a, "1.1.1.2 1.1.1.3"); !strings.Contains(err.Error(), msg) {
+		t.Errorf("error %q does not contain %q", err, msg)
+	}
+
+	tests := map[string]struct {
+		source string
+		err    error
+	}{
+		"missing file":  {source: filepath.Join(dir, "missing.txt"), err: os.ErrNotExist},
+		"glob no match": {source: filepath.Join(dir, "*.csv"), err: input.ErrNoMatch},
+		"remote URL":    {source: "https://example.com/drop.txt", err: input.ErrUnsupportedURL},
+		"remote file":   {source: "file://example.com/drop.txt", err: input.ErrUnsupportedURL},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := input.Read(test.source); !errors.Is(err, test.err) {
+				t.Errorf("error = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestWithValidator(t *testing.T) {
+	r := input.NewReader(input.WithValidator(func(value string) error {
+		if len(value) != 2 {
+			return errors.New("not a country code")
+		}
+		return nil
+	}))
+
+	if _, err := r.Parse(strings.NewReader("FR\nFRA\nUS\n"), "countries"); err == nil ||
+		err.Error() != `1 malformed lines: countries:2: "FRA": not a country code` {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestReadData(t *testing.T) {
+	countries, err := input.Read("../data/countries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(countries) < 200 {
+		t.Errorf("read %d countries, want the whole ISO 3166 list", len(countries))
+	}
+}
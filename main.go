@@ -2,12 +2,20 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/akamai-playground/appsec"
 	"github.com/akamai-playground/netlist"
+	"github.com/akamai-playground/tools"
 
 	"github.com/akamai/AkamaiOPEN-edgegrid-golang/v2/pkg/edgegrid"
 	"github.com/akamai/AkamaiOPEN-edgegrid-golang/v2/pkg/papi"
@@ -27,31 +35,155 @@ func NewContractParams(contractID string, groupID int) ContractParams {
 	return ContractParams{ContractID: contractID, GroupID: groupID}
 }
 
+const (
+	// defaultEdgerc is looked up in the working directory, then in the home directory
+	defaultEdgerc  = ".edgerc"
+	defaultSection = "default"
+)
+
+// Credentials tells where the EdgeGrid credentials are loaded from
+type Credentials struct {
+	// Path of the edgerc file, ~ stands for the home directory
+	Path    string
+	Section string
+	// AccountSwitchKey, when set, runs the calls on behalf of another account,
+	// it is passed to the clients as an option. The account_key of the edgerc
+	// section is used when empty, see SwitchKey
+	AccountSwitchKey string
+	// CACert is the path of a PEM file with certificates trusted on top of the
+	// system ones, e.g. the one of a fakeapi server
+	CACert string
+}
+
+// NewCredentials returns the credentials selected by the AKAMAI_EDGERC,
+// AKAMAI_EDGERC_SECTION, AKAMAI_ACCOUNT_KEY and AKAMAI_CA_CERT environment variables, if any
+func NewCredentials() Credentials {
+	c := Credentials{
+		Path:             os.Getenv("AKAMAI_EDGERC"),
+		Section:          os.Getenv("AKAMAI_EDGERC_SECTION"),
+		AccountSwitchKey: os.Getenv("AKAMAI_ACCOUNT_KEY"),
+		CACert:           os.Getenv("AKAMAI_CA_CERT"),
+	}
+	if c.Path == "" {
+		c.Path = defaultEdgerc
+		if _, err := os.Stat(defaultEdgerc); err != nil {
+			c.Path = filepath.Join("~", defaultEdgerc)
+		}
+	}
+	if c.Section == "" {
+		c.Section = defaultSection
+	}
+	return c
+}
+
+// LoadConfig loads the EdgeGrid config. The AKAMAI_HOST, AKAMAI_CLIENT_TOKEN,
+// AKAMAI_CLIENT_SECRET and AKAMAI_ACCESS_TOKEN environment variables
+// (AKAMAI_{SECTION}_HOST etc. for other sections) take precedence over the edgerc file
+func LoadConfig(c Credentials) (*edgegrid.Config, error) {
+	path, err := expandHome(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	section := c.Section
+	if section == "" {
+		section = defaultSection
+	}
+
+	edgerc, err := edgegrid.New(edgegrid.WithEnv(true), edgegrid.WithFile(path), edgegrid.WithSection(section))
+	if err != nil {
+		return nil, fmt.Errorf("loading credentials from section %q of %s or environment: %w", section, path, err)
+	}
+	if edgerc.Host == "" || edgerc.ClientToken == "" || edgerc.ClientSecret == "" || edgerc.AccessToken == "" {
+		return nil, fmt.Errorf("incomplete credentials in section %q of %s or environment", section, path)
+	}
+	return edgerc, nil
+}
+
+// SwitchKey returns the account switch key to run the calls with,
+// the one of the credentials or else the account_key of the edgerc section
+func (c Credentials) SwitchKey(edgerc *edgegrid.Config) string {
+	if c.AccountSwitchKey != "" || edgerc == nil {
+		return c.AccountSwitchKey
+	}
+	return edgerc.AccountKey
+}
+
 // AuthSession loads edgerc config and set up the client
-func AuthSession() (session.Session, error) {
+func AuthSession(c Credentials) (session.Session, error) {
+	edgerc, err := LoadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return NewSession(edgerc, c)
+}
 
-	edgerc := edgegrid.Must(edgegrid.New(edgegrid.WithFile(".edgerc"), edgegrid.WithSection("default")))
+// NewSession sets up the client signing requests with the loaded edgerc config
+func NewSession(edgerc *edgegrid.Config, c Credentials) (session.Session, error) {
+	opts := []session.Option{session.WithLog(log.Log), session.WithSigner(edgerc)}
+	if c.CACert != "" {
+		client, err := trustingClient(c.CACert)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, session.WithClient(client))
+	}
 
-	session, err := session.New(session.WithLog(log.Log), session.WithSigner(edgerc))
+	session, err := session.New(opts...)
 	if err != nil {
 		return nil, err
 	}
 	return session, nil
 }
 
+// trustingClient returns an HTTP client trusting the certificates of the PEM file
+// on top of the system ones
+func trustingClient(caCert string) (*http.Client, error) {
+	path, err := expandHome(caCert)
+	if err != nil {
+		return nil, err
+	}
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA certificates: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificate found in %s", path)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &http.Client{Transport: transport}, nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("expanding %s: %w", path, err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
 // GetPAPIClient returns a client to work with PAPI APIs
 func GetPAPIClient(s session.Session) papi.PAPI {
 	return papi.Client(s, papi.WithUsePrefixes(true))
 }
 
 // GetAppSecClient returns a client to work with AppSec APIs
-func GetAppSecClient(s session.Session) appsec.APPSEC {
-	return appsec.Client(s)
+func GetAppSecClient(s session.Session, opts ...appsec.Option) appsec.APPSEC {
+	return appsec.Client(s, opts...)
 }
 
 // GetNetListClient returns a client to work with Network List APIs
-func GetNetListClient(s session.Session) netlist.NETLIST {
-	return netlist.Client(s)
+func GetNetListClient(s session.Session, opts ...netlist.Option) netlist.NETLIST {
+	return netlist.Client(s, opts...)
 }
 
 func getContractsGeneric(s session.Session) (string, error) {
@@ -131,7 +263,7 @@ func listSecConfigs(ctx context.Context, client appsec.APPSEC) error {
 
 func listSecConfigVersion(ctx context.Context, client appsec.APPSEC, configID int) error {
 
-	out, err := client.GetConfigVersions(ctx, configID, "-1", "50", "true")
+	out, err := client.GetConfigVersions(ctx, appsec.GetConfigVersionsRequest{ConfigID: configID, PageSize: -1, Detail: true})
 	if err != nil {
 		return err
 	}
@@ -194,347 +326,65 @@ func listRules(ctx context.Context, client appsec.APPSEC, configID, versionNumbe
 	return nil
 }
 
-func listNetworkLists(ctx context.Context, client netlist.NETLIST) error {
-
-	params := netlist.ListNetworkListsRequest{
-		OptionalParams: &netlist.OptionalParams{
-			Extended:        true,
-			IncludeElements: true,
-		},
-		ListType: netlist.IP,
-	}
-
-	out, err := client.ListNetworkLists(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	lists := out.NetworkLists
-	for _, l := range lists {
-		log.Infof("Unique ID: %[1]s, Link to activate on Staging: %[2]s", l.UniqueID, l.Links.ActivateInStaging)
-	}
-	return nil
-}
-
-func getNetworkList(ctx context.Context, client netlist.NETLIST, networkListID string) error {
-
-	params := netlist.GetNetworkListRequest{
-		OptionalParams: &netlist.OptionalParams{
-			Extended:        true,
-			IncludeElements: true,
-		},
-		NetworkListID: networkListID,
-	}
-
-	out, err := client.GetNetworkList(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	listType := out.Type
-	var logType string
-	if listType == "GEO" {
-		logType = "Country"
-	} else {
-		logType = "IP address"
-	}
-	listIP := out.List
-	for _, ip := range listIP {
-		log.Infof("%[1]s: %[2]s", logType, ip)
-	}
-	return nil
-}
-
-func createNetworkList(ctx context.Context, client netlist.NETLIST, NLType netlist.NetworkType, NList []string, contractDetails ...interface{}) (string, error) {
-
-	params := netlist.CreateNetworkListRequest{
-		BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
-			Name:        "My list created via Network List API",
-			Type:        NLType.String(),
-			Description: "The list is maintained by a Golang library",
-			List:        NList,
-		},
-	}
+func main() {
+	log.SetHandler(text.New(os.Stdout))
+	ctx := context.Background()
 
-	if contractDetails != nil && len(contractDetails) <= 1 {
-		switch c := contractDetails[0].(type) {
-		case ContractParams:
-			params.ContractID = c.ContractID
-			params.GroupID = c.GroupID
-		default:
-			log.Fatalf("incorrect parameters type: %v", c)
+	if err := run(ctx, os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
 		}
+		log.Fatalf("%v", err)
 	}
-
-	out, err := client.CreateNetworkList(ctx, params)
-	if err != nil {
-		return "", err
-	}
-
-	log.Infof("Unique ID os the created list: %[1]s, SyncPoint: %[2]d", out.UniqueID, out.SyncPoint)
-	return out.UniqueID, nil
-}
-
-func updateNetworkList(ctx context.Context, client netlist.NETLIST, listID string) error {
-
-	params := netlist.UpdateNetworkListRequest{
-		BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
-			GetNetworkListRequest: &netlist.GetNetworkListRequest{
-				OptionalParams: &netlist.OptionalParams{
-					Extended:        true,
-					IncludeElements: true,
-				},
-				NetworkListID: listID,
-			},
-			Name:        "Updated List of Countries",
-			Type:        netlist.GEO.String(),
-			Description: "Updated Notes",
-			List:        []string{"CH"},
-		},
-	}
-
-	out, err := client.UpdateNetworkList(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	countries := out.List
-	for _, c := range countries {
-		log.Infof("Country: %s", c)
-	}
-	return nil
-}
-
-func appendIPNetworkList(ctx context.Context, client netlist.NETLIST, listID string) error {
-
-	params := netlist.AppendListRequest{
-		NetworkListID: listID,
-		List:          []string{"1.1.1.1"},
-	}
-
-	out, err := client.AppendList(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	IPList := out.List
-	for _, c := range IPList {
-		log.Infof("IP address: %s", c)
-	}
-	return nil
-}
-
-func deleteNetworkList(ctx context.Context, client netlist.NETLIST, listID string) error {
-
-	params := netlist.DeleteNetworkListRequest{
-		GetNetworkListRequest: &netlist.GetNetworkListRequest{
-			NetworkListID: listID,
-		},
-	}
-
-	out, err := client.DeleteNetworkList(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	log.Infof("Delete Status: %d", out.Status)
-	return nil
-}
-
-func appendNetworkList(ctx context.Context, client netlist.NETLIST, listID string) error {
-
-	params := netlist.AppendListRequest{
-		NetworkListID: listID,
-		List:          []string{"RU", "US"},
-	}
-
-	out, err := client.AppendList(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	countries := out.List
-	for _, c := range countries {
-		log.Infof("Country: %s", c)
-	}
-	return nil
-}
-
-func addElement(ctx context.Context, client netlist.NETLIST, listID string) error {
-
-	params := netlist.AddElementRequest{
-		NetworkListID: listID,
-		Element:       "GB",
-	}
-
-	out, err := client.AddElement(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	countries := out.List
-	for _, c := range countries {
-		log.Infof("Country: %s", c)
-	}
-	return nil
-}
-
-func removeElement(ctx context.Context, client netlist.NETLIST, listID string) error {
-
-	params := netlist.RemoveElementRequest{
-		AddElementRequest: &netlist.AddElementRequest{
-			NetworkListID: listID,
-			Element:       "US",
-		},
-	}
-
-	out, err := client.RemoveElement(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	countries := out.List
-	for _, c := range countries {
-		log.Infof("Country: %s", c)
-	}
-	return nil
-}
-
-func activateNetworkList(ctx context.Context, client netlist.NETLIST, listID string) error {
-
-	params := netlist.ActivateNetworkListRequest{
-		NetworkListID:          listID,
-		Environment:            netlist.STAGING,
-		Comments:               "Update via code",
-		NotificationRecipients: []string{"roy.kaynagan@gmail.com"},
-	}
-
-	out, err := client.ActivateNetworkList(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	log.Infof("%d", out.ActivationID)
-	return nil
 }
 
-func getActivationNetworkList(ctx context.Context, client netlist.NETLIST, listID string) error {
-
-	params := netlist.ActivateNetworkListRequest{
-		NetworkListID: listID,
-		Environment:   netlist.STAGING,
-	}
-
-	out, err := client.GetActivationNetworkList(ctx, params)
-	if err != nil {
+func run(ctx context.Context, args []string) error {
+	creds := NewCredentials()
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.Usage = usage
+	fs.StringVar(&creds.Path, "edgerc", creds.Path, "path of the edgerc file (AKAMAI_EDGERC)")
+	fs.StringVar(&creds.Section, "section", creds.Section, "section of the edgerc file (AKAMAI_EDGERC_SECTION)")
+	fs.StringVar(&creds.AccountSwitchKey, "account-key", creds.AccountSwitchKey, "account switch key (AKAMAI_ACCOUNT_KEY)")
+	fs.StringVar(&creds.CACert, "ca-cert", creds.CACert, "PEM file of additional trusted CA certificates (AKAMAI_CA_CERT)")
+	retries := fs.Int("retries", tools.DefaultRetryPolicy.MaxRetries, "retries of rate limited and transiently failing calls")
+	if err := fs.Parse(args); err != nil {
 		return err
 	}
-
-	log.Infof("%s", out.ActivationStatus)
-	return nil
+	args = fs.Args()
+
+	if len(args) == 0 {
+		usage()
+		return errUsage
+	}
+
+	switch args[0] {
+	case "netlist":
+		return runNetlist(ctx, args[1:], func() (netlist.NETLIST, error) {
+			edgerc, err := LoadConfig(creds)
+			if err != nil {
+				return nil, err
+			}
+			session, err := NewSession(edgerc, creds)
+			if err != nil {
+				return nil, fmt.Errorf("session was not signed or executed with an error: %w", err)
+			}
+			retry := tools.DefaultRetryPolicy
+			retry.MaxRetries = *retries
+			return GetNetListClient(session,
+				netlist.WithAccountSwitchKey(creds.SwitchKey(edgerc)),
+				netlist.WithRetries(retry),
+			), nil
+		})
+	case "help", "-h", "--help":
+		usage()
+		return nil
+	}
+
+	usage()
+	return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
 }
 
-func getActivationSnapshot(ctx context.Context, client netlist.NETLIST, listID string) error {
-
-	params := netlist.GetActivationSnapshotRequest{
-		NetworkListID: listID,
-		SyncPoint:     0,
-		Extended:      true,
-	}
-
-	out, err := client.GetActivationSnapshot(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	log.Infof("%s", out.Name)
-	return nil
-}
-
-func updateNLDetails(ctx context.Context, client netlist.NETLIST, listID string) error {
-
-	params := netlist.UpdateNetworkListDetailsRequest{
-		NetworkListID: listID,
-		Name:          "Update Network List Details Name",
-		Description:   "Update Network List Details Description",
-	}
-
-	err := client.UpdateNetworkListDetails(ctx, params)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func main() {
-	log.SetHandler(text.New(os.Stdout))
-	ctx := context.Background()
-	session, err := AuthSession()
-	if err != nil {
-		log.Fatalf("session was not signed or executed with an error: %v", err)
-	}
-	// papiClient := GetPapiClient(session)
-	// appsecClient := GetAppSecClient(session)
-	netlistClient := GetNetListClient(session)
-
-	// listProducts(ctx, papiClient, contractID)
-	// listSecConfigsGeneric(session)
-	// listGroups(ctx, papiClient, contractID)
-	// listSecConfigs(ctx, appsecClient)
-	// listSecConfigVersion(ctx, appsecClient, 69058)
-	// listPolicies(ctx, appsecClient, 69058, 50)
-	// listRules(ctx, appsecClient, 69058, 50, "1234_112176")
-
-	// IPList := randomIP()
-	// countries := ISOCountries()
-
-	// listNetworkLists(ctx, netlistClient)
-	// listIP := createNetworkList(ctx, netlistClient, netlist.IP, IPList)
-	// contractID, err = getContractsGeneric(session)
-	// if err != nil {
-	// 	log.Fatalf("session was not signed or executed with an error: %v", err)
-	// }
-
-	// listNetworkLists(ctx, netlistClient)
-
-	// acg := 179988 // this is my ACG which I retrieved via listGroups
-	// params := NewContractParams(strings.TrimPrefix(contractID, "ctr_"), acg)
-	// listGEO, err := createNetworkList(ctx, netlistClient, netlist.GEO, countries, params)
-	// if err != nil {
-	// 	log.Fatalf("session was not signed or executed with an error: %v", err)
-	// }
-	// defer deleteNetworkList(ctx, netlistClient, listGEO)
-	// getNetworkList(ctx, netlistClient, listGEO)
-	// updateNetworkList(ctx, netlistClient, listGEO)
-	// if err := appendNetworkList(ctx, netlistClient, listGEO); err != nil {
-	// 	log.Errorf("%v", err)
-	// }
-
-	// if err := addElement(ctx, netlistClient, listGEO); err != nil {
-	// 	log.Errorf("%v", err)
-	// }
-	// if err := removeElement(ctx, netlistClient, listGEO); err != nil {
-	// 	log.Errorf("%v", err)
-	// }
-
-	listID := ""
-	if err := appendIPNetworkList(ctx, netlistClient, listID); err != nil {
-		log.Errorf("%v", err)
-	}
-	if err := activateNetworkList(ctx, netlistClient, listID); err != nil {
-		log.Errorf("%v", err)
-	}
-	if err := getActivationNetworkList(ctx, netlistClient, listID); err != nil {
-		log.Errorf("%v", err)
-	}
-
-	if err := getActivationSnapshot(ctx, netlistClient, listID); err != nil {
-		log.Errorf("%v", err)
-	}
-
-	if err := updateNLDetails(ctx, netlistClient, listID); err != nil {
-		log.Errorf("%v", err)
-	}
-
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [--edgerc path] [--section name] [--account-key key] [--ca-cert path] [--retries n] <command> [arguments]\n\nCommands:\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  netlist    manage network lists\n")
 }
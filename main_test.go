@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akamai-playground/fakeapi"
+	"github.com/akamai-playground/netlist"
+)
+
+func TestRunAgainstFakeAPI(t *testing.T) {
+	srv := fakeapi.NewServer()
+	defer srv.Close()
+	l := srv.NetList.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: netlist.IP.String(), List: []string{"1.1.1.1"}})
+
+	dir, err := ioutil.TempDir("", "edgerc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	edgerc, caCert := filepath.Join(dir, ".edgerc"), filepath.Join(dir, "ca.pem")
+	if err := srv.WriteEdgerc(edgerc, "fake"); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.WriteCACert(caCert); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		args    []string
+		wantErr bool
+	}{
+		"trusted certificate": {
+			args: []string{"--edgerc", edgerc, "--section", "fake", "--ca-cert", caCert, "--retries", "0", "netlist", "get", "--id", l.UniqueID},
+		},
+		"untrusted certificate": {
+			args:    []string{"--edgerc", edgerc, "--section", "fake", "--retries", "0", "netlist", "get", "--id", l.UniqueID},
+			wantErr: true,
+		},
+		"missing certificate file": {
+			args:    []string{"--edgerc", edgerc, "--section", "fake", "--ca-cert", filepath.Join(dir, "missing.pem"), "netlist", "get", "--id", l.UniqueID},
+			wantErr: true,
+		},
+		"file without certificate": {
+			args:    []string{"--edgerc", edgerc, "--section", "fake", "--ca-cert", edgerc, "netlist", "get", "--id", l.UniqueID},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := run(context.Background(), test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("run %v: %v, want error %t", test.args, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunAccountSwitchKey(t *testing.T) {
+	srv := fakeapi.NewServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "edgerc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	edgerc, caCert := filepath.Join(dir, ".edgerc"), filepath.Join(dir, "ca.pem")
+	if err := srv.WriteEdgerc(edgerc, "default"); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.WriteCACert(caCert); err != nil {
+		t.Fatal(err)
+	}
+	withKey := filepath.Join(dir, "with-key.edgerc")
+	content, err := ioutil.ReadFile(edgerc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(withKey, append(content, "account_key = 1-EDGERC\n"...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		edgerc string
+		args   []string
+		want   string
+	}{
+		"no key":           {edgerc: edgerc},
+		"edgerc key":       {edgerc: withKey, want: "1-EDGERC"},
+		"flag key":         {edgerc: edgerc, args: []string{"--account-key", "1-FLAG"}, want: "1-FLAG"},
+		"flag over edgerc": {edgerc: withKey, args: []string{"--account-key", "1-FLAG"}, want: "1-FLAG"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			before := len(srv.Requests())
+			args := append([]string{"--edgerc", test.edgerc, "--ca-cert", caCert}, test.args...)
+			if err := run(context.Background(), append(args, "netlist", "list")); err != nil {
+				t.Fatal(err)
+			}
+
+			requests := srv.Requests()[before:]
+			if len(requests) != 1 {
+				t.Fatalf("%d requests, want 1", len(requests))
+			}
+			if got := requests[0].Query.Get("accountSwitchKey"); got != test.want {
+				t.Errorf("accountSwitchKey = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
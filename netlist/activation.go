@@ -0,0 +1,149 @@
+package netlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/apex/log"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Activation statuses reported by the activation status endpoint
+const (
+	// StatusPendingActivation is reported while the activation is being propagated
+	StatusPendingActivation = "PENDING_ACTIVATION"
+	// StatusActive is reported once the list is live in the environment
+	StatusActive = "ACTIVE"
+	// StatusModified is reported when the list changed since its last activation
+	StatusModified = "MODIFIED"
+	// StatusPendingDeactivation is reported while the list is being deactivated
+	StatusPendingDeactivation = "PENDING_DEACTIVATION"
+	// StatusInactive is reported when the list is not active in the environment
+	StatusInactive = "INACTIVE"
+	// StatusFailed is reported when the activation did not succeed
+	StatusFailed = "FAILED"
+)
+
+const (
+	defaultPollInterval    = 10 * time.Second
+	defaultMaxPollInterval = time.Minute
+)
+
+var (
+	// ErrActivationFailed is returned when an activation ended in the FAILED status
+	ErrActivationFailed = errors.New("activation failed")
+)
+
+type (
+	// WaitForActivationRequest describes the activation to wait for
+	WaitForActivationRequest struct {
+		NetworkListID string
+		Environment   Environment
+		// SyncPoint is the sync point expected to become active,
+		// an ACTIVE status for an older sync point keeps the waiter polling
+		SyncPoint int
+		// PollInterval is the delay before the first status check, it grows
+		// with every poll up to MaxPollInterval
+		PollInterval    time.Duration
+		MaxPollInterval time.Duration
+	}
+
+	logProvider interface {
+		Log(ctx context.Context) log.Interface
+	}
+)
+
+// IsTerminalActivationStatus reports whether no further status transitions
+// are expected after the given activation status
+func IsTerminalActivationStatus(status string) bool {
+	return status == StatusActive || status == StatusFailed
+}
+
+// WaitForActivation polls the activation status of a network list until it
+// reaches a terminal status or ctx is done. Every status transition is logged.
+// An ACTIVE list is returned as is, a FAILED one with ErrActivationFailed.
+func WaitForActivation(ctx context.Context, client NetworkList, params WaitForActivationRequest) (*ActivationNetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debug("WaitForActivation")
+
+	interval := params.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	maxInterval := params.MaxPollInterval
+	if maxInterval < interval {
+		maxInterval = defaultMaxPollInterval
+		if maxInterval < interval {
+			maxInterval = interval
+		}
+	}
+
+	statusParams := ActivateNetworkListRequest{
+		NetworkListID: params.NetworkListID,
+		Environment:   params.Environment,
+	}
+
+	var last *ActivationNetworkListResponse
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if last != nil {
+				return last, fmt.Errorf("waiting for activation of %s on %s, last status %s: %w",
+					params.NetworkListID, params.Environment, last.ActivationStatus, ctx.Err())
+			}
+			return nil, fmt.Errorf("waiting for activation of %s on %s: %w", params.NetworkListID, params.Environment, ctx.Err())
+		case <-timer.C:
+		}
+
+		status, err := client.GetActivationNetworkList(ctx, statusParams)
+		if err != nil {
+			return last, err
+		}
+
+		if last == nil || last.ActivationStatus != status.ActivationStatus || last.SyncPoint != status.SyncPoint {
+			logger.Infof("%[1]s on %[2]s: %[3]s (sync point %[4]d)",
+				params.NetworkListID, params.Environment, status.ActivationStatus, status.SyncPoint)
+		}
+		last = status
+
+		switch status.ActivationStatus {
+		case StatusActive:
+			if status.SyncPoint >= params.SyncPoint {
+				return status, nil
+			}
+		case StatusFailed:
+			return status, fmt.Errorf("%w: %s on %s", ErrActivationFailed, params.NetworkListID, params.Environment)
+		}
+
+		if interval = interval * 3 / 2; interval > maxInterval {
+			interval = maxInterval
+		}
+		timer.Reset(interval)
+	}
+}
+
+// loggerFor returns the client logger when it exposes one,
+// otherwise the logger attached to ctx
+func loggerFor(ctx context.Context, client interface{}) log.Interface {
+	if l, ok := client.(logProvider); ok {
+		return l.Log(ctx)
+	}
+	return log.FromContext(ctx)
+}
+
+// Validate validates WaitForActivationRequest
+func (v WaitForActivationRequest) Validate() error {
+	return validation.Errors{
+		"networkListId": validation.Validate(v.NetworkListID, validation.Required),
+		"environment":   validation.Validate(v.Environment, validation.Required),
+	}.Filter()
+}
@@ -0,0 +1,115 @@
+package netlist_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akamai-playground/netlist"
+)
+
+// statusClient answers status checks with the given statuses in turn, repeating the last one
+type statusClient struct {
+	netlist.NetworkList
+	statuses []netlist.ActivationNetworkListResponse
+	err      error
+	calls    int
+}
+
+func (c *statusClient) GetActivationNetworkList(_ context.Context, _ netlist.ActivateNetworkListRequest) (*netlist.ActivationNetworkListResponse, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	i := c.calls - 1
+	if i >= len(c.statuses) {
+		i = len(c.statuses) - 1
+	}
+	status := c.statuses[i]
+	return &status, nil
+}
+
+func TestWaitForActivation(t *testing.T) {
+	pending := netlist.ActivationNetworkListResponse{ActivationStatus: netlist.StatusPendingActivation, SyncPoint: 1}
+	active := netlist.ActivationNetworkListResponse{ActivationStatus: netlist.StatusActive, SyncPoint: 1}
+	errStatus := errors.New("status check failed")
+
+	tests := map[string]struct {
+		statuses  []netlist.ActivationNetworkListResponse
+		syncPoint int
+		fault     error
+		timeout   time.Duration
+		want      string
+		err       error
+		calls     int
+	}{
+		"active at once": {
+			statuses: []netlist.ActivationNetworkListResponse{active},
+			want:     netlist.StatusActive,
+			calls:    1,
+		},
+		"pending first": {
+			statuses: []netlist.ActivationNetworkListResponse{pending, pending, active},
+			want:     netlist.StatusActive,
+			calls:    3,
+		},
+		"failed": {
+			statuses: []netlist.ActivationNetworkListResponse{pending, {ActivationStatus: netlist.StatusFailed, SyncPoint: 1}},
+			want:     netlist.StatusFailed,
+			err:      netlist.ErrActivationFailed,
+			calls:    2,
+		},
+		"older sync point active": {
+			statuses:  []netlist.ActivationNetworkListResponse{active},
+			syncPoint: 2,
+			timeout:   50 * time.Millisecond,
+			want:      netlist.StatusActive,
+			err:       context.DeadlineExceeded,
+		},
+		"status check failure": {
+			fault: errStatus,
+			err:   errStatus,
+			calls: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := &statusClient{statuses: test.statuses, err: test.fault}
+			ctx := context.Background()
+			if test.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				defer cancel()
+			}
+
+			status, err := netlist.WaitForActivation(ctx, client, netlist.WaitForActivationRequest{
+				NetworkListID: "1001_BLOCKED",
+				Environment:   netlist.STAGING,
+				SyncPoint:     test.syncPoint,
+				PollInterval:  time.Millisecond,
+			})
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("error = %v, want %v", err, test.err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if test.want != "" && (status == nil || status.ActivationStatus != test.want) {
+				t.Fatalf("status = %+v, want %s", status, test.want)
+			}
+			if test.calls > 0 && client.calls != test.calls {
+				t.Errorf("%d status checks, want %d", client.calls, test.calls)
+			}
+		})
+	}
+}
+
+func TestWaitForActivationValidation(t *testing.T) {
+	_, err := netlist.WaitForActivation(context.Background(), &statusClient{}, netlist.WaitForActivationRequest{Environment: netlist.STAGING})
+	if !errors.Is(err, netlist.ErrStructValidation) {
+		t.Errorf("error = %v, want %v", err, netlist.ErrStructValidation)
+	}
+}
@@ -0,0 +1,246 @@
+package netlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	// DefaultChunkSize is the number of elements sent by BulkAppend in one AppendList call
+	DefaultChunkSize = 1000
+)
+
+type (
+	// BulkAppendRequest describes a large set of elements appended to a list
+	// in chunks, see BulkAppend
+	BulkAppendRequest struct {
+		NetworkListID string
+		List          []string
+		// ChunkSize is the number of elements per AppendList call,
+		// DefaultChunkSize is used when unset
+		ChunkSize int
+		// Concurrency is the number of chunks in flight at once, 1 when unset
+		Concurrency int
+		// StartChunk skips the chunks before it, it is used to resume
+		// a failed run from BulkAppendError.NextChunk. Chunks are numbered from 0
+		StartChunk int
+		// Type, when set, validates every element against the list type
+		// before the first chunk is sent
+		Type   NetworkType
+		Policy *ElementPolicy
+		// Progress, when set, is called after every appended chunk.
+		// Calls are serialized even when chunks are sent concurrently
+		Progress func(BulkAppendProgress)
+	}
+
+	// BulkAppendProgress reports the state of a running BulkAppend
+	BulkAppendProgress struct {
+		Chunk     int `json:"chunk"`
+		Chunks    int `json:"chunks"`
+		Completed int `json:"completed"`
+		Appended  int `json:"appended"`
+		Total     int `json:"total"`
+		SyncPoint int `json:"syncPoint"`
+	}
+
+	// BulkAppendResponse summarizes a completed BulkAppend
+	BulkAppendResponse struct {
+		// List is the response of the AppendList call which reported the highest sync point
+		List      *NetworkListResponse
+		SyncPoint int
+		Chunks    int
+		Appended  int
+	}
+
+	// BulkAppendError is returned when a chunk could not be appended.
+	// Every chunk before NextChunk has been appended, chunks after it may have
+	// been appended as well, appending them again on resume is harmless.
+	// Err is the first failure which is not the cancellation of the chunks
+	// in flight, the error of the lowest failed chunk when there is none
+	BulkAppendError struct {
+		NetworkListID string
+		NextChunk     int
+		Chunks        int
+		SyncPoint     int
+		Err           error
+	}
+
+	chunkResult struct {
+		chunk int
+		size  int
+		list  *NetworkListResponse
+		err   error
+	}
+)
+
+func (e *BulkAppendError) Error() string {
+	return fmt.Sprintf("appending chunk %d of chunks 0-%d to %s: %s", e.NextChunk, e.Chunks-1, e.NetworkListID, e.Err)
+}
+
+func (e *BulkAppendError) Unwrap() error {
+	return e.Err
+}
+
+// ChunkElements splits elements into consecutive chunks of at most size elements
+func ChunkElements(elements []string, size int) [][]string {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	chunks := make([][]string, 0, (len(elements)+size-1)/size)
+	for start := 0; start < len(elements); start += size {
+		end := start + size
+		if end > len(elements) {
+			end = len(elements)
+		}
+		chunks = append(chunks, elements[start:end])
+	}
+	return chunks
+}
+
+// BulkAppend appends a large set of elements to a list through AppendList,
+// one chunk at a time or with up to Concurrency chunks in flight.
+// The first failure stops the remaining chunks and is returned as
+// *BulkAppendError telling which chunk to resume from.
+func BulkAppend(ctx context.Context, client NetworkList, params BulkAppendRequest) (*BulkAppendResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debug("BulkAppend")
+	if params.Type != 0 {
+		logElementWarnings(logger, params.Type.String(), params.List, params.Policy)
+	}
+
+	chunks := ChunkElements(params.List, params.ChunkSize)
+	concurrency := params.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	rval := BulkAppendResponse{Chunks: len(chunks)}
+	progress := BulkAppendProgress{Chunks: len(chunks), Total: len(params.List)}
+	for i := 0; i < params.StartChunk && i < len(chunks); i++ {
+		progress.Appended += len(chunks[i])
+		progress.Completed++
+	}
+	if params.StartChunk > 0 {
+		logger.Infof("%[1]s: resuming from chunk %[2]d of chunks 0-%[3]d", params.NetworkListID, params.StartChunk, len(chunks)-1)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pending := make(chan int)
+	results := make(chan chunkResult)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range pending {
+				list, err := client.AppendList(ctx, AppendListRequest{
+					NetworkListID: params.NetworkListID,
+					List:          chunks[i],
+				})
+				results <- chunkResult{chunk: i, size: len(chunks[i]), list: list, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(pending)
+		for i := params.StartChunk; i < len(chunks); i++ {
+			select {
+			case pending <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// failed holds the lowest failed chunk, every chunk below it must have
+	// succeeded for NextChunk to be a safe resume point. The chunks in flight
+	// when a chunk fails are cancelled, cause keeps the failure behind that
+	failed := -1
+	var failure, cause error
+	for res := range results {
+		if res.err != nil {
+			logger.Errorf("%[1]s: chunk %[2]d of chunks 0-%[3]d failed: %[4]s", params.NetworkListID, res.chunk, len(chunks)-1, res.err)
+			if failed == -1 || res.chunk < failed {
+				failed, failure = res.chunk, res.err
+			}
+			if cause == nil && !errors.Is(res.err, context.Canceled) {
+				cause = res.err
+			}
+			cancel()
+			continue
+		}
+
+		if res.list != nil && res.list.SyncPoint >= rval.SyncPoint {
+			rval.SyncPoint = res.list.SyncPoint
+			rval.List = res.list
+		}
+		progress.Chunk = res.chunk
+		progress.Completed++
+		progress.Appended += res.size
+		progress.SyncPoint = rval.SyncPoint
+		logger.Debugf("%[1]s: chunk %[2]d of chunks 0-%[3]d appended, sync point %[4]d",
+			params.NetworkListID, res.chunk, len(chunks)-1, rval.SyncPoint)
+		if params.Progress != nil {
+			params.Progress(progress)
+		}
+	}
+
+	rval.Appended = progress.Appended
+	if failure != nil {
+		if cause == nil {
+			cause = failure
+		}
+		return &rval, &BulkAppendError{
+			NetworkListID: params.NetworkListID,
+			NextChunk:     failed,
+			Chunks:        len(chunks),
+			SyncPoint:     rval.SyncPoint,
+			Err:           cause,
+		}
+	}
+	if err := ctx.Err(); err != nil && rval.Appended < len(params.List) {
+		return &rval, &BulkAppendError{
+			NetworkListID: params.NetworkListID,
+			NextChunk:     progress.Completed,
+			Chunks:        len(chunks),
+			SyncPoint:     rval.SyncPoint,
+			Err:           err,
+		}
+	}
+
+	logger.Infof("%[1]s: appended %[2]d elements in %[3]d chunks, sync point %[4]d",
+		params.NetworkListID, rval.Appended, len(chunks), rval.SyncPoint)
+	return &rval, nil
+}
+
+// Validate validates BulkAppendRequest
+func (v BulkAppendRequest) Validate() error {
+	listErr := validation.Validate(v.List, validation.Required)
+	if listErr == nil {
+		listErr = validateTypedElements(v.Type, v.List, v.Policy)
+	}
+	return validation.Errors{
+		"networkListId": validation.Validate(v.NetworkListID, validation.Required),
+		"list":          listErr,
+		"chunkSize":     validation.Validate(v.ChunkSize, validation.Min(0)),
+		"concurrency":   validation.Validate(v.Concurrency, validation.Min(0)),
+		"startChunk":    validation.Validate(v.StartChunk, validation.Min(0)),
+	}.Filter()
+}
@@ -0,0 +1,193 @@
+package netlist_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/akamai-playground/netlist"
+	"github.com/akamai-playground/netlist/fake"
+)
+
+// failingChunks fails the AppendList calls of the chunks starting with one of the elements,
+// the calls of the blocked chunks wait for their context to be done
+type failingChunks struct {
+	*fake.NetList
+	mu    sync.Mutex
+	fail  map[string]bool
+	block map[string]bool
+}
+
+func (f *failingChunks) AppendList(ctx context.Context, params netlist.AppendListRequest) (*netlist.NetworkListResponse, error) {
+	f.mu.Lock()
+	fail, block := f.fail[params.List[0]], f.block[params.List[0]]
+	f.mu.Unlock()
+	if block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if fail {
+		return nil, fake.NewError(http.StatusServiceUnavailable, "try again")
+	}
+	return f.NetList.AppendList(ctx, params)
+}
+
+func TestChunkElements(t *testing.T) {
+	tests := map[string]struct {
+		elements int
+		size     int
+		sizes    []int
+	}{
+		"empty":          {elements: 0, size: 10},
+		"single chunk":   {elements: 5, size: 10, sizes: []int{5}},
+		"exact chunks":   {elements: 20, size: 10, sizes: []int{10, 10}},
+		"last chunk":     {elements: 25, size: 10, sizes: []int{10, 10, 5}},
+		"default size":   {elements: 1001, size: 0, sizes: []int{1000, 1}},
+		"negative size":  {elements: 3, size: -1, sizes: []int{3}},
+		"size of one":    {elements: 3, size: 1, sizes: []int{1, 1, 1}},
+		"larger than it": {elements: 3, size: 100, sizes: []int{3}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			chunks := netlist.ChunkElements(ips(test.elements), test.size)
+			if len(chunks) != len(test.sizes) {
+				t.Fatalf("%d chunks, want %d", len(chunks), len(test.sizes))
+			}
+			for i, c := range chunks {
+				if len(c) != test.sizes[i] {
+					t.Errorf("chunk %d has %d elements, want %d", i, len(c), test.sizes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBulkAppendResume(t *testing.T) {
+	tests := map[string]struct {
+		concurrency int
+	}{
+		"sequential": {concurrency: 1},
+		"concurrent": {concurrency: 3},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			client := &failingChunks{NetList: fake.New(), fail: map[string]bool{"11.0.0.50": true}}
+			l := client.Seed(netlist.NetworkListResponse{Name: "Bulk", Type: netlist.IP.String(), List: []string{"1.1.1.1"}})
+			elements := ips(95)
+
+			var progress []netlist.BulkAppendProgress
+			_, err := netlist.BulkAppend(ctx, client, netlist.BulkAppendRequest{
+				NetworkListID: l.UniqueID,
+				List:          elements,
+				ChunkSize:     10,
+				Concurrency:   test.concurrency,
+				Type:          netlist.IP,
+				Progress:      func(p netlist.BulkAppendProgress) { progress = append(progress, p) },
+			})
+
+			var bulkErr *netlist.BulkAppendError
+			if !errors.As(err, &bulkErr) {
+				t.Fatalf("error = %v, want *BulkAppendError", err)
+			}
+			if bulkErr.NextChunk != 5 || bulkErr.Chunks != 10 {
+				t.Errorf("NextChunk, Chunks = %d, %d, want 5, 10", bulkErr.NextChunk, bulkErr.Chunks)
+			}
+			if !strings.Contains(err.Error(), "chunk 5 of chunks 0-9") {
+				t.Errorf("error %q does not name the chunk to resume from", err)
+			}
+			if !errors.Is(err, netlist.ErrServerError) {
+				t.Errorf("errors.Is(%v, ErrServerError) = false", err)
+			}
+			if len(progress) < 5 || progress[len(progress)-1].Completed < 5 {
+				t.Errorf("progress %+v does not report the 5 first chunks", progress)
+			}
+
+			client.fail = nil
+			out, err := netlist.BulkAppend(ctx, client, netlist.BulkAppendRequest{
+				NetworkListID: l.UniqueID,
+				List:          elements,
+				ChunkSize:     10,
+				StartChunk:    bulkErr.NextChunk,
+			})
+			if err != nil {
+				t.Fatalf("resume: %v", err)
+			}
+			if out.Appended != len(elements) || out.Chunks != 10 {
+				t.Errorf("Appended, Chunks = %d, %d, want %d, 10", out.Appended, out.Chunks, len(elements))
+			}
+
+			got, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+				OptionalParams: &netlist.OptionalParams{IncludeElements: true},
+				NetworkListID:  l.UniqueID,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ElementCount != len(elements)+1 {
+				t.Errorf("%d elements, want %d", got.ElementCount, len(elements)+1)
+			}
+		})
+	}
+}
+
+func TestBulkAppendCancelledChunks(t *testing.T) {
+	// chunk 0 is in flight when chunk 1 fails, it is cancelled and becomes the resume point
+	client := &failingChunks{
+		NetList: fake.New(),
+		fail:    map[string]bool{"11.0.0.10": true},
+		block:   map[string]bool{"11.0.0.0": true},
+	}
+	l := client.Seed(netlist.NetworkListResponse{Name: "Bulk", Type: netlist.IP.String()})
+
+	_, err := netlist.BulkAppend(context.Background(), client, netlist.BulkAppendRequest{
+		NetworkListID: l.UniqueID,
+		List:          ips(30),
+		ChunkSize:     10,
+		Concurrency:   2,
+	})
+
+	var bulkErr *netlist.BulkAppendError
+	if !errors.As(err, &bulkErr) {
+		t.Fatalf("error = %v, want *BulkAppendError", err)
+	}
+	if bulkErr.NextChunk != 0 {
+		t.Errorf("NextChunk = %d, want 0", bulkErr.NextChunk)
+	}
+	if !errors.Is(err, netlist.ErrServerError) || errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want the failure of chunk 1", err)
+	}
+}
+
+func TestBulkAppendValidation(t *testing.T) {
+	client := fake.New()
+	l := client.Seed(netlist.NetworkListResponse{Name: "Bulk", Type: netlist.IP.String()})
+
+	_, err := netlist.BulkAppend(context.Background(), client, netlist.BulkAppendRequest{
+		NetworkListID: l.UniqueID,
+		List:          append(ips(20), "not-an-ip"),
+		ChunkSize:     10,
+		Type:          netlist.IP,
+	})
+	if !errors.Is(err, netlist.ErrStructValidation) {
+		t.Fatalf("error = %v, want ErrStructValidation", err)
+	}
+	if calls := client.Calls(fake.MethodAppendList); calls != 0 {
+		t.Errorf("%d AppendList calls, want none", calls)
+	}
+}
+
+// ips returns n distinct addresses of 11.0.0.0/16
+func ips(n int) []string {
+	elements := make([]string, n)
+	for i := range elements {
+		elements[i] = fmt.Sprintf("11.0.%d.%d", i/256, i%256)
+	}
+	return elements
+}
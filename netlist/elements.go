@@ -0,0 +1,199 @@
+package netlist
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// Policies applied to IP elements falling into special purpose ranges
+const (
+	// PolicyAllow accepts the element silently
+	PolicyAllow RangePolicy = iota
+	// PolicyWarn accepts the element and reports it as a warning
+	PolicyWarn
+	// PolicyReject reports the element as invalid
+	PolicyReject
+)
+
+type (
+	// RangePolicy tells what to do with an IP element in a special purpose range
+	RangePolicy int
+
+	// ElementPolicy tells how IP elements in special purpose ranges are handled
+	ElementPolicy struct {
+		Private   RangePolicy
+		Reserved  RangePolicy
+		Multicast RangePolicy
+	}
+
+	specialRange struct {
+		name   string
+		prefix ipPrefix
+	}
+)
+
+// DefaultElementPolicy flags private, reserved and multicast ranges as warnings
+var DefaultElementPolicy = ElementPolicy{
+	Private:   PolicyWarn,
+	Reserved:  PolicyWarn,
+	Multicast: PolicyWarn,
+}
+
+// isoCountries holds the ISO 3166 codes accepted in GEO lists, EU included
+var isoCountries = map[string]struct{}{
+	"AD": {}, "AE": {}, "AF": {}, "AG": {}, "AI": {}, "AL": {}, "AM": {}, "AO": {}, "AQ": {}, "AR": {}, "AS": {}, "AT": {},
+	"AU": {}, "AW": {}, "AZ": {}, "BA": {}, "BB": {}, "BD": {}, "BE": {}, "BF": {}, "BG": {}, "BH": {}, "BI": {}, "BJ": {},
+	"BL": {}, "BM": {}, "BN": {}, "BO": {}, "BQ": {}, "BR": {}, "BS": {}, "BT": {}, "BV": {}, "BW": {}, "BY": {}, "BZ": {},
+	"CA": {}, "CC": {}, "CD": {}, "CF": {}, "CG": {}, "CH": {}, "CI": {}, "CK": {}, "CL": {}, "CM": {}, "CN": {}, "CO": {},
+	"CR": {}, "CU": {}, "CV": {}, "CW": {}, "CX": {}, "CY": {}, "CZ": {}, "DE": {}, "DJ": {}, "DK": {}, "DM": {}, "DO": {},
+	"DZ": {}, "EC": {}, "EE": {}, "EG": {}, "EH": {}, "ER": {}, "ES": {}, "ET": {}, "EU": {}, "FI": {}, "FJ": {}, "FK": {},
+	"FM": {}, "FO": {}, "FR": {}, "GA": {}, "GB": {}, "GD": {}, "GE": {}, "GF": {}, "GH": {}, "GI": {}, "GG": {}, "GL": {},
+	"GM": {}, "GN": {}, "GP": {}, "GQ": {}, "GR": {}, "GS": {}, "GT": {}, "GU": {}, "GW": {}, "GY": {}, "HK": {}, "HM": {},
+	"HN": {}, "HR": {}, "HT": {}, "HU": {}, "ID": {}, "IE": {}, "IL": {}, "IM": {}, "IN": {}, "IO": {}, "IQ": {}, "IR": {},
+	"IS": {}, "IT": {}, "JE": {}, "JM": {}, "JO": {}, "JP": {}, "KE": {}, "KG": {}, "KH": {}, "KI": {}, "KM": {}, "KN": {},
+	"KP": {}, "KR": {}, "KW": {}, "KY": {}, "KZ": {}, "LA": {}, "LB": {}, "LC": {}, "LI": {}, "LK": {}, "LR": {}, "LS": {},
+	"LT": {}, "LU": {}, "LV": {}, "LY": {}, "MA": {}, "MC": {}, "MD": {}, "ME": {}, "MF": {}, "MG": {}, "MH": {}, "MK": {},
+	"ML": {}, "MM": {}, "MN": {}, "MO": {}, "MP": {}, "MQ": {}, "MR": {}, "MS": {}, "MT": {}, "MU": {}, "MV": {}, "MW": {},
+	"MX": {}, "MY": {}, "MZ": {}, "NA": {}, "NC": {}, "NE": {}, "NF": {}, "NG": {}, "NI": {}, "NL": {}, "NO": {}, "NP": {},
+	"NR": {}, "NU": {}, "NZ": {}, "OM": {}, "PA": {}, "PE": {}, "PF": {}, "PG": {}, "PH": {}, "PK": {}, "PL": {}, "PM": {},
+	"PN": {}, "PR": {}, "PS": {}, "PT": {}, "PW": {}, "PY": {}, "QA": {}, "RE": {}, "RO": {}, "RS": {}, "RU": {}, "RW": {},
+	"SA": {}, "SB": {}, "SC": {}, "SD": {}, "SE": {}, "SG": {}, "SH": {}, "SI": {}, "SJ": {}, "SK": {}, "SL": {}, "SM": {},
+	"SN": {}, "SO": {}, "SR": {}, "SS": {}, "ST": {}, "SV": {}, "SX": {}, "SY": {}, "SZ": {}, "TC": {}, "TD": {}, "TF": {},
+	"TG": {}, "TH": {}, "TJ": {}, "TK": {}, "TM": {}, "TN": {}, "TO": {}, "TL": {}, "TR": {}, "TT": {}, "TV": {}, "TW": {},
+	"TZ": {}, "UA": {}, "UG": {}, "UM": {}, "US": {}, "UY": {}, "UZ": {}, "VA": {}, "VC": {}, "VE": {}, "VG": {}, "VI": {},
+	"VN": {}, "VU": {}, "WF": {}, "WS": {}, "YE": {}, "YT": {}, "ZA": {}, "ZM": {}, "ZW": {},
+}
+
+var (
+	privateRanges   = mustRanges("private", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7")
+	multicastRanges = mustRanges("multicast", "224.0.0.0/4", "ff00::/8")
+	reservedRanges  = mustRanges("reserved", "0.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+		"192.0.0.0/24", "192.0.2.0/24", "198.18.0.0/15", "198.51.100.0/24", "203.0.113.0/24", "240.0.0.0/4",
+		"::/128", "::1/128", "fe80::/10", "2001:db8::/32")
+)
+
+// IsCountryCode reports whether code is an ISO 3166 country code accepted in GEO lists
+func IsCountryCode(code string) bool {
+	_, ok := isoCountries[code]
+	return ok
+}
+
+// ParseRangePolicy returns the RangePolicy matching the given name: allow, warn or reject
+func ParseRangePolicy(name string) (RangePolicy, error) {
+	switch strings.ToLower(name) {
+	case "allow":
+		return PolicyAllow, nil
+	case "warn":
+		return PolicyWarn, nil
+	case "reject":
+		return PolicyReject, nil
+	}
+	return 0, fmt.Errorf("unknown range policy: %q", name)
+}
+
+// ValidateElements checks every element against the list type. Elements which
+// are invalid or rejected by the policy are returned in err, elements the policy
+// only flags are returned as warnings, both with their index in elements
+func ValidateElements(listType NetworkType, elements []string, policy ElementPolicy) (warnings ElementsError, err error) {
+	var errs ElementsError
+
+	for i, e := range elements {
+		switch listType {
+		case GEO:
+			if !IsCountryCode(e) {
+				errs = append(errs, ElementError{Index: i, Element: e, Reason: "not an ISO 3166 country code"})
+			}
+		case IP:
+			p, perr := parsePrefix(e)
+			if perr != nil {
+				errs = append(errs, ElementError{Index: i, Element: e, Reason: perr.Error()})
+				continue
+			}
+			for _, check := range []struct {
+				ranges []specialRange
+				policy RangePolicy
+			}{
+				{multicastRanges, policy.Multicast},
+				{reservedRanges, policy.Reserved},
+				{privateRanges, policy.Private},
+			} {
+				name, ok := overlappedRange(p, check.ranges)
+				if !ok || check.policy == PolicyAllow {
+					continue
+				}
+				elErr := ElementError{Index: i, Element: e, Reason: fmt.Sprintf("%s address range", name)}
+				if check.policy == PolicyReject {
+					errs = append(errs, elErr)
+				} else {
+					warnings = append(warnings, elErr)
+				}
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return warnings, errs
+	}
+	return warnings, nil
+}
+
+// validateElements validates elements of the list type given by name,
+// unknown types are left to the type validation rule
+func validateElements(listType string, elements []string, policy *ElementPolicy) error {
+	t, err := ParseNetworkType(listType)
+	if err != nil {
+		return nil
+	}
+	return validateTypedElements(t, elements, policy)
+}
+
+func validateTypedElements(listType NetworkType, elements []string, policy *ElementPolicy) error {
+	if listType == 0 {
+		return nil
+	}
+	if policy == nil {
+		policy = &DefaultElementPolicy
+	}
+	if _, err := ValidateElements(listType, elements, *policy); err != nil {
+		return err
+	}
+	return nil
+}
+
+// logElementWarnings logs the elements flagged by the policy
+func logElementWarnings(logger log.Interface, listType string, elements []string, policy *ElementPolicy) {
+	t, err := ParseNetworkType(listType)
+	if err != nil {
+		return
+	}
+	if policy == nil {
+		policy = &DefaultElementPolicy
+	}
+	if warnings, _ := ValidateElements(t, elements, *policy); len(warnings) > 0 {
+		logger.Warnf("%d flagged elements: %s", len(warnings), warnings.join())
+	}
+}
+
+func overlappedRange(p ipPrefix, ranges []specialRange) (string, bool) {
+	for _, r := range ranges {
+		if r.prefix.covers(p) || p.covers(r.prefix) {
+			return r.name, true
+		}
+	}
+	return "", false
+}
+
+func mustRanges(name string, cidrs ...string) []specialRange {
+	ranges := make([]specialRange, 0, len(cidrs))
+	for _, c := range cidrs {
+		p, err := parsePrefix(c)
+		if err != nil {
+			panic(err)
+		}
+		ranges = append(ranges, specialRange{name: name, prefix: p})
+	}
+	return ranges
+}
@@ -0,0 +1,136 @@
+package netlist_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/akamai-playground/netlist"
+)
+
+func TestValidateElements(t *testing.T) {
+	tests := map[string]struct {
+		listType netlist.NetworkType
+		elements []string
+		policy   netlist.ElementPolicy
+		invalid  []int
+		warnings []int
+	}{
+		"countries": {
+			listType: netlist.GEO,
+			elements: []string{"FR", "EU", "US"},
+			policy:   netlist.DefaultElementPolicy,
+		},
+		"invalid countries": {
+			listType: netlist.GEO,
+			elements: []string{"FR", "us", "XX", "1.1.1.1"},
+			policy:   netlist.DefaultElementPolicy,
+			invalid:  []int{1, 2, 3},
+		},
+		"public addresses": {
+			listType: netlist.IP,
+			elements: []string{"1.2.3.4", "8.8.8.0/24", "2606:4700::/32"},
+			policy:   netlist.DefaultElementPolicy,
+		},
+		"invalid addresses": {
+			listType: netlist.IP,
+			elements: []string{"1.2.3.4", "bad", "1.2.3.4/33", "FR"},
+			policy:   netlist.DefaultElementPolicy,
+			invalid:  []int{1, 2, 3},
+		},
+		"special ranges are flagged": {
+			listType: netlist.IP,
+			elements: []string{"10.0.0.0/8", "230.1.1.1", "240.0.0.0/4", "fe80::1", "1.1.1.1"},
+			policy:   netlist.DefaultElementPolicy,
+			warnings: []int{0, 1, 2, 3},
+		},
+		"block overlapping a special range is flagged": {
+			listType: netlist.IP,
+			elements: []string{"0.0.0.0/0"},
+			policy:   netlist.DefaultElementPolicy,
+			warnings: []int{0},
+		},
+		"private ranges are rejected": {
+			listType: netlist.IP,
+			elements: []string{"1.2.3.4", "10.0.0.0/8", "192.168.1.1"},
+			policy:   netlist.ElementPolicy{Private: netlist.PolicyReject, Reserved: netlist.PolicyWarn},
+			invalid:  []int{1, 2},
+		},
+		"special ranges are allowed": {
+			listType: netlist.IP,
+			elements: []string{"10.0.0.0/8", "224.0.0.1", "127.0.0.1"},
+			policy:   netlist.ElementPolicy{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			warnings, err := netlist.ValidateElements(test.listType, test.elements, test.policy)
+
+			var errs netlist.ElementsError
+			if err != nil && !errors.As(err, &errs) {
+				t.Fatalf("error = %v, want ElementsError", err)
+			}
+			if got := indexes(errs); !reflect.DeepEqual(got, test.invalid) {
+				t.Errorf("invalid elements %v, want %v", got, test.invalid)
+			}
+			if got := indexes(warnings); !reflect.DeepEqual(got, test.warnings) {
+				t.Errorf("flagged elements %v, want %v", got, test.warnings)
+			}
+		})
+	}
+}
+
+func TestRequestsValidateElements(t *testing.T) {
+	reject := &netlist.ElementPolicy{Private: netlist.PolicyReject}
+
+	tests := map[string]struct {
+		validate func() error
+		invalid  bool
+	}{
+		"create with a valid list": {
+			validate: netlist.CreateNetworkListRequest{BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+				Name: "a", Type: "GEO", List: []string{"FR"},
+			}}.Validate,
+		},
+		"create with an invalid country": {
+			validate: netlist.CreateNetworkListRequest{BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+				Name: "a", Type: "GEO", List: []string{"XX"},
+			}}.Validate,
+			invalid: true,
+		},
+		"create with a rejected range": {
+			validate: netlist.CreateNetworkListRequest{BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+				Name: "a", Type: "IP", List: []string{"10.0.0.1"}, Policy: reject,
+			}}.Validate,
+			invalid: true,
+		},
+		"append of an unknown type": {
+			validate: netlist.AppendListRequest{NetworkListID: "1_A", List: []string{"anything"}}.Validate,
+		},
+		"append of an invalid address": {
+			validate: netlist.AppendListRequest{NetworkListID: "1_A", List: []string{"bad"}, Type: netlist.IP}.Validate,
+			invalid:  true,
+		},
+		"add of an invalid country": {
+			validate: netlist.AddElementRequest{NetworkListID: "1_A", Element: "XX", Type: netlist.GEO}.Validate,
+			invalid:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.validate(); (err != nil) != test.invalid {
+				t.Errorf("Validate() = %v, want invalid %t", err, test.invalid)
+			}
+		})
+	}
+}
+
+func indexes(errs netlist.ElementsError) []int {
+	var indexes []int
+	for _, e := range errs {
+		indexes = append(indexes, e.Index)
+	}
+	return indexes
+}
@@ -6,11 +6,28 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+
+	"github.com/akamai-playground/tools"
 )
 
 var (
 	// ErrBadRequest is returned when a required parameter is missing
 	ErrBadRequest = errors.New("missing argument")
+
+	// ErrNotFound matches API errors about missing lists, elements or sync points
+	ErrNotFound = tools.ErrNotFound
+	// ErrConflict matches API errors reporting that the list changed since
+	// the sync point a request was based on
+	ErrConflict = tools.ErrConflict
+	// ErrValidation matches API errors rejecting the request content,
+	// ErrStructValidation is returned instead when the request is rejected before being sent
+	ErrValidation = tools.ErrValidation
+	// ErrForbidden matches API errors about missing credentials or grants
+	ErrForbidden = tools.ErrForbidden
+	// ErrRateLimited matches API errors reporting that too many requests were sent
+	ErrRateLimited = tools.ErrRateLimited
+	// ErrServerError matches API errors reporting a failure of the API itself
+	ErrServerError = tools.ErrServerError
 )
 
 type (
@@ -23,6 +40,19 @@ type (
 		BehaviorName  string `json:"behaviorName,omitempty"`
 		ErrorLocation string `json:"errorLocation,omitempty"`
 		StatusCode    int    `json:"-"`
+		// Raw is the problem JSON as returned by the API
+		Raw json.RawMessage `json:"-"`
+	}
+
+	// ConflictError is returned by MutateNetworkList when every attempt
+	// was rejected because the list kept changing concurrently
+	ConflictError struct {
+		NetworkListID string
+		// SyncPoint is the sync point the last attempt was based on
+		SyncPoint int
+		Attempts  int
+		// Err is the API error of the last attempt
+		Err *Error
 	}
 )
 
@@ -41,6 +71,7 @@ func (n *netlist) Error(r *http.Response) error {
 		return &e
 	}
 
+	e.Raw = body
 	if err := json.Unmarshal(body, &e); err != nil {
 		n.Log(r.Request.Context()).Errorf("could not unmarshal API error: %s", err)
 		e.Title = fmt.Sprintf("Failed to unmarshal error body")
@@ -56,8 +87,18 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("Title: %s; Type: %s; Detail: %s", e.Title, e.Type, e.Detail)
 }
 
+// Kind returns the kind of the error, one of ErrNotFound, ErrConflict, ErrValidation,
+// ErrForbidden, ErrRateLimited and ErrServerError, or nil
+func (e *Error) Kind() error {
+	return tools.ErrorKind(e.StatusCode, e.Type)
+}
+
 // Is handles error comparisons
 func (e *Error) Is(target error) bool {
+	if kind := e.Kind(); kind != nil && kind == target {
+		return true
+	}
+
 	var t *Error
 	if !errors.As(target, &t) {
 		return false
@@ -73,3 +114,18 @@ func (e *Error) Is(target error) bool {
 
 	return e.Error() == t.Error()
 }
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s changed concurrently, giving up after %d attempts (last sync point %d): %s",
+		e.NetworkListID, e.Attempts, e.SyncPoint, e.Err)
+}
+
+// Unwrap returns the API error of the last attempt
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrConflict
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
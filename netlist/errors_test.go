@@ -0,0 +1,149 @@
+package netlist
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akamai/AkamaiOPEN-edgegrid-golang/v2/pkg/session"
+)
+
+func TestErrorKinds(t *testing.T) {
+	kinds := []error{ErrNotFound, ErrConflict, ErrValidation, ErrForbidden, ErrRateLimited, ErrServerError}
+
+	tests := map[string]struct {
+		status int
+		body   string
+		want   error
+	}{
+		"not found": {
+			status: http.StatusNotFound,
+			body:   `{"type":"https://problems.luna.akamaiapis.net/network-lists/error-types/not-found","title":"Not Found","detail":"Network list 1_X not found"}`,
+			want:   ErrNotFound,
+		},
+		"stale sync point": {
+			status: http.StatusConflict,
+			body:   `{"type":"https://problems.luna.akamaiapis.net/network-lists/error-types/conflict","title":"Conflict","detail":"sync point 2 is stale"}`,
+			want:   ErrConflict,
+		},
+		"conflict with an unexpected type": {
+			status: http.StatusConflict,
+			body:   `{"type":"https://problems.luna.akamaiapis.net/network-lists/error-types/unexpected","title":"Oops"}`,
+			want:   ErrConflict,
+		},
+		"precondition failed": {
+			status: http.StatusPreconditionFailed,
+			body:   `{"title":"Precondition Failed"}`,
+			want:   ErrConflict,
+		},
+		"unauthorized with an invalid type": {
+			status: http.StatusUnauthorized,
+			body:   `{"type":"https://problems.luna.akamaiapis.net/-/pep-authn/request-error/invalid-token","title":"Not authorized"}`,
+			want:   ErrForbidden,
+		},
+		"forbidden": {
+			status: http.StatusForbidden,
+			body:   `{"title":"Forbidden"}`,
+			want:   ErrForbidden,
+		},
+		"bad request": {
+			status: http.StatusBadRequest,
+			body:   `{"type":"https://problems.luna.akamaiapis.net/network-lists/error-types/invalid-element","title":"Invalid Element"}`,
+			want:   ErrValidation,
+		},
+		"bad request about a sync point": {
+			status: http.StatusBadRequest,
+			body:   `{"type":"https://problems.luna.akamaiapis.net/network-lists/error-types/sync-point-mismatch","title":"Sync point mismatch"}`,
+			want:   ErrConflict,
+		},
+		"rate limited": {
+			status: http.StatusTooManyRequests,
+			body:   `{"title":"Too Many Requests"}`,
+			want:   ErrRateLimited,
+		},
+		"server error": {
+			status: http.StatusBadGateway,
+			body:   `{"title":"Bad Gateway"}`,
+			want:   ErrServerError,
+		},
+		"server error with a malformed body": {
+			status: http.StatusInternalServerError,
+			body:   `<html>oops</html>`,
+			want:   ErrServerError,
+		},
+		"unknown status with a known type": {
+			status: http.StatusMethodNotAllowed,
+			body:   `{"type":"https://problems.luna.akamaiapis.net/network-lists/error-types/validation-error"}`,
+			want:   ErrValidation,
+		},
+		"unknown status": {
+			status: http.StatusMethodNotAllowed,
+			body:   `{"title":"Method Not Allowed"}`,
+		},
+	}
+
+	sess, err := session.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &netlist{Session: sess}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := client.Error(response(test.status, test.body))
+
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("errors.As(%v, *Error) = false", err)
+			}
+			if apiErr.StatusCode != test.status {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, test.status)
+			}
+			if string(apiErr.Raw) != test.body {
+				t.Errorf("Raw = %s, want %s", apiErr.Raw, test.body)
+			}
+			if apiErr.Kind() != test.want {
+				t.Errorf("Kind() = %v, want %v", apiErr.Kind(), test.want)
+			}
+
+			wrapped := &ConflictError{NetworkListID: "1_X", Err: apiErr}
+			for _, kind := range kinds {
+				if got := errors.Is(err, kind); got != (kind == test.want) {
+					t.Errorf("errors.Is(err, %v) = %t", kind, got)
+				}
+				if kind == ErrConflict {
+					continue
+				}
+				if got := errors.Is(wrapped, kind); got != (kind == test.want) {
+					t.Errorf("errors.Is(wrapped, %v) = %t", kind, got)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorIsSameError(t *testing.T) {
+	client := &netlist{}
+	body := `{"title":"Not Found","detail":"gone"}`
+	a := client.Error(response(http.StatusNotFound, body))
+	b := client.Error(response(http.StatusNotFound, body))
+	c := client.Error(response(http.StatusNotFound, `{"title":"Not Found","detail":"other"}`))
+
+	if !errors.Is(a, b) {
+		t.Errorf("errors.Is(a, b) = false, want true")
+	}
+	if errors.Is(a, c) {
+		t.Errorf("errors.Is(a, c) = true, want false")
+	}
+}
+
+func response(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    httptest.NewRequest(http.MethodGet, "/network-list/v2/network-lists", nil),
+	}
+}
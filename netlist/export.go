@@ -0,0 +1,364 @@
+package netlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats a ListDocument is written and read in
+const (
+	// FormatJSON is a JSON object, readable by SyncNetworkList desired state files as well
+	FormatJSON Format = "json"
+	// FormatYAML is the YAML counterpart of FormatJSON
+	FormatYAML Format = "yaml"
+	// FormatCSV is an element,comment table below a commented header holding the list details
+	FormatCSV Format = "csv"
+	// FormatText is one element per line, optionally followed by a # comment,
+	// below a commented header holding the list details
+	FormatText Format = "text"
+)
+
+// ErrUnknownFormat is returned for formats other than json, yaml, csv and text
+var ErrUnknownFormat = errors.New("unknown network list format")
+
+var csvHeader = []string{"element", "comment"}
+
+type (
+	// Format is the serialization of a ListDocument
+	Format string
+
+	// ListDocument is the portable form of a network list: its details and its
+	// elements, each one with an optional comment. See WriteListDocument
+	ListDocument struct {
+		UniqueID    string        `json:"uniqueId,omitempty" yaml:"uniqueId,omitempty"`
+		Name        string        `json:"name" yaml:"name"`
+		Type        string        `json:"type" yaml:"type"`
+		Description string        `json:"description" yaml:"description"`
+		SyncPoint   int           `json:"syncPoint" yaml:"syncPoint"`
+		List        []ListElement `json:"list" yaml:"list"`
+	}
+
+	// ListElement is an element of a ListDocument. In JSON and YAML it is
+	// written as a plain string unless it has a comment
+	ListElement struct {
+		Value   string `json:"value" yaml:"value"`
+		Comment string `json:"comment,omitempty" yaml:"comment,omitempty"`
+	}
+
+	// listElement is the object form of ListElement, without its marshalers
+	listElement ListElement
+)
+
+// ParseFormat returns the Format matching the given name (case insensitive), yml and txt included
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "csv":
+		return FormatCSV, nil
+	case "text", "txt":
+		return FormatText, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+}
+
+// FormatOf returns the Format matching the extension of path, FormatText when there is none
+func FormatOf(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return FormatText, nil
+	}
+	return ParseFormat(ext)
+}
+
+// NewListDocument returns the document of a list, without element comments
+func NewListDocument(list *NetworkListResponse) *ListDocument {
+	doc := &ListDocument{
+		UniqueID:    list.UniqueID,
+		Name:        list.Name,
+		Type:        list.Type,
+		Description: list.Description,
+		SyncPoint:   list.SyncPoint,
+		List:        make([]ListElement, 0, len(list.List)),
+	}
+	for _, e := range list.List {
+		doc.List = append(doc.List, ListElement{Value: e})
+	}
+	return doc
+}
+
+// Elements returns the values of the document elements
+func (d *ListDocument) Elements() []string {
+	elements := make([]string, 0, len(d.List))
+	for _, e := range d.List {
+		elements = append(elements, e.Value)
+	}
+	return elements
+}
+
+// WriteListDocument writes the document to w in the given format
+func WriteListDocument(w io.Writer, doc *ListDocument, format Format) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatCSV:
+		bw := bufio.NewWriter(w)
+		writeHeader(bw, doc)
+		cw := csv.NewWriter(bw)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, e := range doc.List {
+			if err := cw.Write([]string{e.Value, oneLine(e.Comment)}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		return bw.Flush()
+	case FormatText:
+		bw := bufio.NewWriter(w)
+		writeHeader(bw, doc)
+		for _, e := range doc.List {
+			if e.Comment != "" {
+				fmt.Fprintf(bw, "%s # %s\n", e.Value, oneLine(e.Comment))
+				continue
+			}
+			fmt.Fprintln(bw, e.Value)
+		}
+		return bw.Flush()
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// ReadListDocument reads a document written by WriteListDocument in the given format.
+// The details and the elements are validated, not the elements against the list type
+func ReadListDocument(r io.Reader, format Format) (*ListDocument, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc ListDocument
+	switch format {
+	case FormatJSON:
+		err = json.Unmarshal(data, &doc)
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &doc)
+	case FormatCSV:
+		err = doc.readCSV(data)
+	case FormatText:
+		err = doc.readText(data)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s network list: %w", format, err)
+	}
+
+	if err := doc.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+	doc.Type = strings.ToUpper(doc.Type)
+	return &doc, nil
+}
+
+// writeHeader writes the details of the list as comments, read back by readHeader
+func writeHeader(w io.Writer, doc *ListDocument) {
+	if doc.UniqueID != "" {
+		fmt.Fprintf(w, "# uniqueId: %s\n", doc.UniqueID)
+	}
+	fmt.Fprintf(w, "# name: %s\n", oneLine(doc.Name))
+	fmt.Fprintf(w, "# type: %s\n", doc.Type)
+	if doc.Description != "" {
+		fmt.Fprintf(w, "# description: %s\n", oneLine(doc.Description))
+	}
+	fmt.Fprintf(w, "# syncPoint: %d\n", doc.SyncPoint)
+}
+
+// readHeader reads a detail from a comment line, other comments are ignored
+func (d *ListDocument) readHeader(line string, n int) error {
+	kv := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(line, "#")), ":", 2)
+	if len(kv) != 2 {
+		return nil
+	}
+	value := strings.TrimSpace(kv[1])
+	switch strings.TrimSpace(kv[0]) {
+	case "uniqueId":
+		d.UniqueID = value
+	case "name":
+		d.Name = value
+	case "type":
+		d.Type = value
+	case "description":
+		d.Description = value
+	case "syncPoint":
+		syncPoint, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid sync point %q", n, value)
+		}
+		d.SyncPoint = syncPoint
+	}
+	return nil
+}
+
+func (d *ListDocument) readText(data []byte) error {
+	d.List = []ListElement{}
+	header := true
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			if header {
+				if err := d.readHeader(line, i+1); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		header = false
+
+		e := ListElement{Value: line}
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			e.Value = strings.TrimSpace(line[:idx])
+			e.Comment = strings.TrimSpace(line[idx+1:])
+		}
+		d.List = append(d.List, e)
+	}
+	return nil
+}
+
+func (d *ListDocument) readCSV(data []byte) error {
+	d.List = []ListElement{}
+	header, table := true, false
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			if header {
+				if err := d.readHeader(line, i+1); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		header = false
+
+		// every record is written on its own line, comments are kept on one line
+		cr := csv.NewReader(strings.NewReader(line))
+		cr.TrimLeadingSpace = true
+		record, err := cr.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				err = parseErr.Err
+			}
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if !table {
+			table = true
+			if strings.EqualFold(strings.TrimSpace(record[0]), csvHeader[0]) {
+				continue
+			}
+		}
+		if len(record) > len(csvHeader) {
+			return fmt.Errorf("line %d: %d fields, want at most %d", i+1, len(record), len(csvHeader))
+		}
+
+		e := ListElement{Value: strings.TrimSpace(record[0])}
+		if len(record) > 1 {
+			e.Comment = strings.TrimSpace(record[1])
+		}
+		if e.Value == "" {
+			return fmt.Errorf("line %d: blank element", i+1)
+		}
+		d.List = append(d.List, e)
+	}
+	return nil
+}
+
+// MarshalJSON writes the element as a string unless it has a comment
+func (e ListElement) MarshalJSON() ([]byte, error) {
+	if e.Comment == "" {
+		return json.Marshal(e.Value)
+	}
+	return json.Marshal(listElement(e))
+}
+
+// UnmarshalJSON reads the element from a string or from an object
+func (e *ListElement) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		*e = ListElement{}
+		return json.Unmarshal(data, &e.Value)
+	}
+	return json.Unmarshal(data, (*listElement)(e))
+}
+
+// MarshalYAML writes the element as a string unless it has a comment
+func (e ListElement) MarshalYAML() (interface{}, error) {
+	if e.Comment == "" {
+		return e.Value, nil
+	}
+	return listElement(e), nil
+}
+
+// UnmarshalYAML reads the element from a string or from a mapping
+func (e *ListElement) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*e = ListElement{}
+		return node.Decode(&e.Value)
+	}
+	return node.Decode((*listElement)(e))
+}
+
+// oneLine keeps the header and the comments of the text formats on a single line
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Validate validates ListDocument
+func (d ListDocument) Validate() error {
+	return validation.Errors{
+		"name": validation.Validate(d.Name, validation.Required),
+		"type": validation.Validate(strings.ToUpper(d.Type), validation.Required,
+			validation.In(IP.String(), GEO.String())),
+		"syncPoint": validation.Validate(d.SyncPoint, validation.Min(0)),
+		"list": validation.Validate(d.List, validation.By(func(interface{}) error {
+			for i, e := range d.List {
+				if strings.TrimSpace(e.Value) == "" {
+					return fmt.Errorf("element %d is blank", i)
+				}
+			}
+			return nil
+		})),
+	}.Filter()
+}
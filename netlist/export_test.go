@@ -0,0 +1,218 @@
+package netlist_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akamai-playground/netlist"
+)
+
+func TestListDocumentRoundTrip(t *testing.T) {
+	doc := &netlist.ListDocument{
+		UniqueID:    "1001_BLOCKED",
+		Name:        "Blocked IPs",
+		Type:        "IP",
+		Description: "maintained in git",
+		SyncPoint:   7,
+		List: []netlist.ListElement{
+			{Value: "1.1.1.1"},
+			{Value: "10.0.0.0/8", Comment: "internal, see ticket 42"},
+			{Value: "2001:db8::/32", Comment: "documentation"},
+		},
+	}
+
+	for _, format := range []netlist.Format{netlist.FormatJSON, netlist.FormatYAML, netlist.FormatCSV, netlist.FormatText} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := netlist.WriteListDocument(&buf, doc, format); err != nil {
+				t.Fatal(err)
+			}
+			got, err := netlist.ReadListDocument(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, doc) {
+				t.Errorf("read back %+v, want %+v", got, doc)
+			}
+		})
+	}
+}
+
+func TestWriteListDocument(t *testing.T) {
+	doc := netlist.NewListDocument(&netlist.NetworkListResponse{
+		UniqueID: "1001_COUNTRIES", Name: "Countries", Type: "GEO", SyncPoint: 2, List: []string{"FR", "US"},
+	})
+	doc.List[1].Comment = "until the end of the campaign"
+
+	tests := map[netlist.Format]string{
+		netlist.FormatJSON: `{
+  "uniqueId": "1001_COUNTRIES",
+  "name": "Countries",
+  "type": "GEO",
+  "description": "",
+  "syncPoint": 2,
+  "list": [
+    "FR",
+    {
+      "value": "US",
+      "comment": "until the end of the campaign"
+    }
+  ]
+}
+`,
+		netlist.FormatYAML: `uniqueId: 1001_COUNTRIES
+name: Countries
+type: GEO
+description: ""
+syncPoint: 2
+list:
+  - FR
+  - value: US
+    comment: until the end of the campaign
+`,
+		netlist.FormatCSV: `# uniqueId: 1001_COUNTRIES
+# name: Countries
+# type: GEO
+# syncPoint: 2
+element,comment
+FR,
+US,until the end of the campaign
+`,
+		netlist.FormatText: `# uniqueId: 1001_COUNTRIES
+# name: Countries
+# type: GEO
+# syncPoint: 2
+FR
+US # until the end of the campaign
+`,
+	}
+
+	for format, want := range tests {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := netlist.WriteListDocument(&buf, doc, format); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != want {
+				t.Errorf("wrote\n%s\nwant\n%s", buf.String(), want)
+			}
+		})
+	}
+}
+
+func TestReadListDocument(t *testing.T) {
+	tests := map[string]struct {
+		format netlist.Format
+		input  string
+		want   *netlist.ListDocument
+		err    string
+	}{
+		"json desired state file": {
+			format: netlist.FormatJSON,
+			input:  `{"name": "Blocked", "type": "ip", "list": ["1.1.1.1", {"value": "2.2.2.2", "comment": "abuse"}]}`,
+			want: &netlist.ListDocument{Name: "Blocked", Type: "IP", List: []netlist.ListElement{
+				{Value: "1.1.1.1"}, {Value: "2.2.2.2", Comment: "abuse"},
+			}},
+		},
+		"yaml without elements": {
+			format: netlist.FormatYAML,
+			input:  "name: Blocked\ntype: GEO\n",
+			want:   &netlist.ListDocument{Name: "Blocked", Type: "GEO"},
+		},
+		"csv without table header and with CRLF": {
+			format: netlist.FormatCSV,
+			input:  "# name: Blocked\r\n# type: IP\r\n\r\n1.1.1.1\r\n# later comments are ignored\r\n\"2.2.2.2\", \"abuse, reported twice\"\r\n",
+			want: &netlist.ListDocument{Name: "Blocked", Type: "IP", List: []netlist.ListElement{
+				{Value: "1.1.1.1"}, {Value: "2.2.2.2", Comment: "abuse, reported twice"},
+			}},
+		},
+		"text with free comments": {
+			format: netlist.FormatText,
+			input:  "# exported for the audit\n# name: Blocked\n# type: IP\n1.1.1.1  # abuse\n\n# name: not a header anymore\n2.2.2.2\n",
+			want: &netlist.ListDocument{Name: "Blocked", Type: "IP", List: []netlist.ListElement{
+				{Value: "1.1.1.1", Comment: "abuse"}, {Value: "2.2.2.2"},
+			}},
+		},
+		"missing name": {
+			format: netlist.FormatText,
+			input:  "# type: IP\n1.1.1.1\n",
+			err:    "name: cannot be blank",
+		},
+		"unknown type": {
+			format: netlist.FormatYAML,
+			input:  "name: Blocked\ntype: ASN\n",
+			err:    "type: must be a valid value",
+		},
+		"invalid sync point": {
+			format: netlist.FormatText,
+			input:  "# name: Blocked\n# type: IP\n# syncPoint: latest\n",
+			err:    `line 3: invalid sync point "latest"`,
+		},
+		"extra csv field": {
+			format: netlist.FormatCSV,
+			input:  "# name: Blocked\n# type: IP\nelement,comment\n1.1.1.1,abuse,extra\n",
+			err:    "line 4: 3 fields, want at most 2",
+		},
+		"malformed csv line": {
+			format: netlist.FormatCSV,
+			input:  "# name: Blocked\n# type: IP\n\"1.1.1.1,abuse\n",
+			err:    "line 3:",
+		},
+		"blank json element": {
+			format: netlist.FormatJSON,
+			input:  `{"name": "Blocked", "type": "IP", "list": ["1.1.1.1", " "]}`,
+			err:    "element 1 is blank",
+		},
+		"unknown format": {
+			format: "xml",
+			err:    netlist.ErrUnknownFormat.Error(),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := netlist.ReadListDocument(strings.NewReader(test.input), test.format)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.want.List == nil {
+				test.want.List = got.List
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("read %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	tests := map[string]struct {
+		format netlist.Format
+		err    error
+	}{
+		"blocked.json":   {format: netlist.FormatJSON},
+		"blocked.YML":    {format: netlist.FormatYAML},
+		"blocked.csv":    {format: netlist.FormatCSV},
+		"blocked.txt":    {format: netlist.FormatText},
+		"data/countries": {format: netlist.FormatText},
+		"blocked.xml":    {err: netlist.ErrUnknownFormat},
+	}
+
+	for path, test := range tests {
+		t.Run(path, func(t *testing.T) {
+			format, err := netlist.FormatOf(path)
+			if !errors.Is(err, test.err) || format != test.format {
+				t.Errorf("FormatOf(%q) = %q, %v, want %q, %v", path, format, err, test.format, test.err)
+			}
+		})
+	}
+}
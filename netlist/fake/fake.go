@@ -0,0 +1,716 @@
+// Package fake provides a stateful in-memory implementation of netlist.NETLIST
+// to unit test tooling built on the netlist package without reaching the API
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akamai-playground/netlist"
+	"github.com/akamai/AkamaiOPEN-edgegrid-golang/v2/pkg/session"
+)
+
+// Names of the faked methods, used to inject failures and latency
+const (
+	MethodListNetworkLists         = "ListNetworkLists"
+	MethodGetNetworkList           = "GetNetworkList"
+	MethodUpdateNetworkList        = "UpdateNetworkList"
+	MethodCreateNetworkList        = "CreateNetworkList"
+	MethodDeleteNetworkList        = "DeleteNetworkList"
+	MethodAppendList               = "AppendList"
+	MethodAddElement               = "AddElement"
+	MethodRemoveElement            = "RemoveElement"
+	MethodActivateNetworkList      = "ActivateNetworkList"
+	MethodGetActivationNetworkList = "GetActivationNetworkList"
+	MethodGetActivationSnapshot    = "GetActivationSnapshot"
+	MethodUpdateNetworkListDetails = "UpdateNetworkListDetails"
+)
+
+const errorTypePrefix = "https://problems.luna.akamaiapis.net/network-lists/error-types/"
+
+var idChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+type (
+	// NetList is an in-memory netlist.NETLIST. It keeps every sync point of
+	// every list, so snapshots can be retrieved, and models activations per
+	// environment which become ACTIVE after a number of status checks.
+	// It is safe for concurrent use.
+	NetList struct {
+		mu          sync.Mutex
+		lists       map[string]*list
+		nextID      int
+		activations map[activationKey]*netlist.ActivationNetworkListResponse
+		nextAct     int
+		faults      map[string]*Fault
+		calls       map[string]int
+
+		user            string
+		activationPolls int
+		now             func() time.Time
+	}
+
+	// Fault is injected into a method call
+	Fault struct {
+		// Err is returned instead of calling the method when set,
+		// see NewError to build API errors
+		Err error
+		// Latency delays the call, a done context interrupts it
+		Latency time.Duration
+		// Times limits the number of calls the fault applies to, 0 means every call
+		Times int
+	}
+
+	// Option configures a NetList
+	Option func(*NetList)
+
+	list struct {
+		current netlist.NetworkListResponse
+		// history holds the list as of every sync point
+		history []netlist.NetworkListResponse
+	}
+
+	activationKey struct {
+		id  string
+		env netlist.Environment
+	}
+)
+
+var _ netlist.NETLIST = (*NetList)(nil)
+
+// WithUser sets the user reported as the author of every change, "fake" by default
+func WithUser(user string) Option {
+	return func(n *NetList) {
+		n.user = user
+	}
+}
+
+// WithActivationPolls sets the number of status checks an activation stays
+// PENDING_ACTIVATION for, 0 activates immediately
+func WithActivationPolls(polls int) Option {
+	return func(n *NetList) {
+		n.activationPolls = polls
+	}
+}
+
+// WithClock sets the clock used for create and update dates
+func WithClock(now func() time.Time) Option {
+	return func(n *NetList) {
+		n.now = now
+	}
+}
+
+// New returns an empty NetList
+func New(opts ...Option) *NetList {
+	n := &NetList{
+		lists:           make(map[string]*list),
+		activations:     make(map[activationKey]*netlist.ActivationNetworkListResponse),
+		faults:          make(map[string]*Fault),
+		calls:           make(map[string]int),
+		user:            "fake",
+		activationPolls: 1,
+		now:             time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// ClientFunc returns a netlist.ClientFunc handing out the NetList whatever the session
+func (n *NetList) ClientFunc() netlist.ClientFunc {
+	return func(session.Session, ...netlist.Option) netlist.NETLIST {
+		return n
+	}
+}
+
+// NewError returns an API error shaped like those of the network lists API
+func NewError(status int, detail string) *netlist.Error {
+	title := http.StatusText(status)
+	return &netlist.Error{
+		Type:       errorTypePrefix + strings.ToLower(strings.Replace(title, " ", "-", -1)),
+		Title:      title,
+		Detail:     detail,
+		StatusCode: status,
+	}
+}
+
+// Inject sets the fault of a method, replacing the previous one
+func (n *NetList) Inject(method string, fault Fault) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f := fault
+	n.faults[method] = &f
+}
+
+// Fail makes the next calls of a method return err, every call when times is 0
+func (n *NetList) Fail(method string, err error, times int) {
+	n.Inject(method, Fault{Err: err, Times: times})
+}
+
+// Delay slows every call of a method down
+func (n *NetList) Delay(method string, latency time.Duration) {
+	n.Inject(method, Fault{Latency: latency})
+}
+
+// Reset removes the faults of the given methods, of every method when none is given
+func (n *NetList) Reset(methods ...string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(methods) == 0 {
+		n.faults = make(map[string]*Fault)
+		return
+	}
+	for _, m := range methods {
+		delete(n.faults, m)
+	}
+}
+
+// Calls returns the number of calls of a method, including failed ones
+func (n *NetList) Calls(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.calls[method]
+}
+
+// Seed stores a list as is, e.g. to start a test from an existing list.
+// A UniqueID is generated when missing, the list starts at its SyncPoint
+func (n *NetList) Seed(l netlist.NetworkListResponse) *netlist.NetworkListResponse {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if l.UniqueID == "" {
+		l.UniqueID = n.newID(l.Name)
+	}
+	l.List = copyElements(l.List)
+	l.ElementCount = len(l.List)
+	stored := &list{current: l, history: make([]netlist.NetworkListResponse, l.SyncPoint+1)}
+	for i := range stored.history {
+		stored.history[i] = l
+		stored.history[i].SyncPoint = i
+	}
+	n.lists[l.UniqueID] = stored
+	return n.response(stored.current, true, true)
+}
+
+// call counts a call and applies the fault of the method, if any
+func (n *NetList) call(ctx context.Context, method string) error {
+	n.mu.Lock()
+	n.calls[method]++
+	var fault Fault
+	if f, ok := n.faults[method]; ok {
+		fault = *f
+		if f.Times > 0 {
+			if f.Times--; f.Times == 0 {
+				delete(n.faults, method)
+			}
+		}
+	}
+	n.mu.Unlock()
+
+	if fault.Latency > 0 {
+		timer := time.NewTimer(fault.Latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s request failed: %w", strings.ToLower(method), ctx.Err())
+		case <-timer.C:
+		}
+	}
+	return fault.Err
+}
+
+// ListNetworkLists returns the lists of the given type whose name contains
+// Search or which hold an element equal to it
+func (n *NetList) ListNetworkLists(ctx context.Context, params netlist.ListNetworkListsRequest) (*netlist.ListNetworkListsResponse, error) {
+	if err := n.call(ctx, MethodListNetworkLists); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ids := make([]string, 0, len(n.lists))
+	for id := range n.lists {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var rval netlist.ListNetworkListsResponse
+	extended, includeElements := optional(params.OptionalParams)
+	for _, id := range ids {
+		l := n.lists[id].current
+		if params.ListType != 0 && l.Type != params.ListType.String() {
+			continue
+		}
+		if params.Search != "" && !matches(l, params.Search) {
+			continue
+		}
+		rval.NetworkLists = append(rval.NetworkLists, struct {
+			*netlist.NetworkListResponse
+		}{n.response(l, extended, includeElements)})
+	}
+	return &rval, nil
+}
+
+// GetNetworkList returns a list, its elements only when IncludeElements is set
+func (n *NetList) GetNetworkList(ctx context.Context, params netlist.GetNetworkListRequest) (*netlist.NetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+	if err := n.call(ctx, MethodGetNetworkList); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l, err := n.get(params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+	extended, includeElements := optional(params.OptionalParams)
+	return n.response(l.current, extended, includeElements), nil
+}
+
+// UpdateNetworkList replaces a list, it fails with 409 Conflict when
+// SyncPoint is not the current sync point of the list
+func (n *NetList) UpdateNetworkList(ctx context.Context, params netlist.UpdateNetworkListRequest) (*netlist.NetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+	if err := n.call(ctx, MethodUpdateNetworkList); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l, err := n.get(params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+	if params.SyncPoint != l.current.SyncPoint {
+		return nil, NewError(http.StatusConflict, fmt.Sprintf(
+			"sync point %d is stale, network list %s is at sync point %d", params.SyncPoint, l.current.UniqueID, l.current.SyncPoint))
+	}
+	if params.Type != l.current.Type {
+		return nil, NewError(http.StatusBadRequest, fmt.Sprintf("the type of network list %s cannot be changed", l.current.UniqueID))
+	}
+
+	next := l.current
+	next.Name = params.Name
+	next.Description = params.Description
+	next.List = dedupe(params.List)
+	n.commit(l, next)
+
+	extended, includeElements := optional(params.OptionalParams)
+	return n.response(l.current, extended, includeElements), nil
+}
+
+// CreateNetworkList creates a list at sync point 0
+func (n *NetList) CreateNetworkList(ctx context.Context, params netlist.CreateNetworkListRequest) (*netlist.NetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+	if err := n.call(ctx, MethodCreateNetworkList); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.timestamp()
+	created := netlist.NetworkListResponse{
+		Name:            params.Name,
+		UniqueID:        n.newID(params.Name),
+		Description:     params.Description,
+		Type:            params.Type,
+		NetworkListType: "networkListResponse",
+		List:            dedupe(params.List),
+		CreateDate:      now,
+		CreatedBy:       n.user,
+		UpdateDate:      now,
+		UpdatedBy:       n.user,
+	}
+	created.ElementCount = len(created.List)
+
+	l := &list{current: created, history: []netlist.NetworkListResponse{created}}
+	n.lists[created.UniqueID] = l
+	return n.response(l.current, true, true), nil
+}
+
+// DeleteNetworkList deletes a list, it fails with 409 Conflict while the list
+// is active or being activated in an environment
+func (n *NetList) DeleteNetworkList(ctx context.Context, params netlist.DeleteNetworkListRequest) (*netlist.MessageNetworkList, error) {
+	if params.GetNetworkListRequest == nil {
+		return nil, fmt.Errorf("%w: networkListId: cannot be blank.", netlist.ErrStructValidation)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+	if err := n.call(ctx, MethodDeleteNetworkList); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l, err := n.get(params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range []netlist.Environment{netlist.STAGING, netlist.PRODUCTION} {
+		if a, ok := n.activations[activationKey{l.current.UniqueID, env}]; ok && a.ActivationStatus != netlist.StatusInactive {
+			return nil, NewError(http.StatusConflict, fmt.Sprintf(
+				"network list %s is %s on %s and cannot be deleted", l.current.UniqueID, a.ActivationStatus, env))
+		}
+	}
+
+	delete(n.lists, l.current.UniqueID)
+	return &netlist.MessageNetworkList{
+		Status:   http.StatusOK,
+		Name:     l.current.Name,
+		UniqueID: l.current.UniqueID,
+	}, nil
+}
+
+// AppendList adds the elements missing from a list, creating a new sync point
+func (n *NetList) AppendList(ctx context.Context, params netlist.AppendListRequest) (*netlist.NetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+	if err := n.call(ctx, MethodAppendList); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l, err := n.get(params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateElements(l.current.Type, params.List); err != nil {
+		return nil, err
+	}
+
+	next := l.current
+	next.List = dedupe(append(copyElements(l.current.List), params.List...))
+	n.commit(l, next)
+	return n.response(l.current, true, true), nil
+}
+
+// AddElement adds an element to a list, creating a new sync point
+func (n *NetList) AddElement(ctx context.Context, params netlist.AddElementRequest) (*netlist.NetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+	if err := n.call(ctx, MethodAddElement); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l, err := n.get(params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateElements(l.current.Type, []string{params.Element}); err != nil {
+		return nil, err
+	}
+
+	next := l.current
+	next.List = dedupe(append(copyElements(l.current.List), params.Element))
+	n.commit(l, next)
+	return n.response(l.current, true, true), nil
+}
+
+// RemoveElement removes an element from a list, creating a new sync point.
+// It fails with 404 Not Found when the list does not hold the element
+func (n *NetList) RemoveElement(ctx context.Context, params netlist.RemoveElementRequest) (*netlist.NetworkListResponse, error) {
+	if params.AddElementRequest == nil {
+		return nil, fmt.Errorf("%w: element: cannot be blank.", netlist.ErrStructValidation)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+	if err := n.call(ctx, MethodRemoveElement); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l, err := n.get(params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+
+	next := l.current
+	next.List = make([]string, 0, len(l.current.List))
+	for _, e := range l.current.List {
+		if e != params.Element {
+			next.List = append(next.List, e)
+		}
+	}
+	if len(next.List) == len(l.current.List) {
+		return nil, NewError(http.StatusNotFound, fmt.Sprintf(
+			"element %s not found in network list %s", params.Element, l.current.UniqueID))
+	}
+	n.commit(l, next)
+	return n.response(l.current, true, true), nil
+}
+
+// ActivateNetworkList starts the activation of the current sync point of a list
+func (n *NetList) ActivateNetworkList(ctx context.Context, params netlist.ActivateNetworkListRequest) (*netlist.ActivationNetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+	if err := n.call(ctx, MethodActivateNetworkList); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l, err := n.get(params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+
+	n.nextAct++
+	status := netlist.StatusPendingActivation
+	if n.activationPolls <= 0 {
+		status = netlist.StatusActive
+	}
+	a := &netlist.ActivationNetworkListResponse{
+		ActivationID:       n.nextAct,
+		ActivationComments: params.Comments,
+		ActivationStatus:   status,
+		SyncPoint:          l.current.SyncPoint,
+		UniqueID:           l.current.UniqueID,
+		DispatchCount:      n.activationPolls,
+	}
+	n.activations[activationKey{l.current.UniqueID, params.Environment}] = a
+
+	rval := *a
+	return &rval, nil
+}
+
+// GetActivationNetworkList returns the activation status of a list. Pending
+// activations become ACTIVE once polled as many times as set by WithActivationPolls,
+// an ACTIVE list changed since is reported as MODIFIED
+func (n *NetList) GetActivationNetworkList(ctx context.Context, params netlist.ActivateNetworkListRequest) (*netlist.ActivationNetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+	if err := n.call(ctx, MethodGetActivationNetworkList); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l, err := n.get(params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+
+	a, ok := n.activations[activationKey{l.current.UniqueID, params.Environment}]
+	if !ok {
+		return &netlist.ActivationNetworkListResponse{
+			ActivationStatus: netlist.StatusInactive,
+			UniqueID:         l.current.UniqueID,
+		}, nil
+	}
+
+	if a.ActivationStatus == netlist.StatusPendingActivation {
+		if a.DispatchCount--; a.DispatchCount <= 0 {
+			a.DispatchCount = 0
+			a.ActivationStatus = netlist.StatusActive
+		}
+	}
+
+	rval := *a
+	if rval.ActivationStatus == netlist.StatusActive && rval.SyncPoint < l.current.SyncPoint {
+		rval.ActivationStatus = netlist.StatusModified
+	}
+	return &rval, nil
+}
+
+// SetActivationStatus forces the activation status of a list in an environment,
+// e.g. to test how FAILED activations are handled
+func (n *NetList) SetActivationStatus(listID string, env netlist.Environment, status string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l, err := n.get(listID)
+	if err != nil {
+		return err
+	}
+
+	key := activationKey{l.current.UniqueID, env}
+	a, ok := n.activations[key]
+	if !ok {
+		n.nextAct++
+		a = &netlist.ActivationNetworkListResponse{
+			ActivationID: n.nextAct,
+			SyncPoint:    l.current.SyncPoint,
+			UniqueID:     l.current.UniqueID,
+		}
+		n.activations[key] = a
+	}
+	a.ActivationStatus = status
+	return nil
+}
+
+// GetActivationSnapshot returns a list as of one of its sync points
+func (n *NetList) GetActivationSnapshot(ctx context.Context, params netlist.GetActivationSnapshotRequest) (*netlist.NetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+	if err := n.call(ctx, MethodGetActivationSnapshot); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l, err := n.get(params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+	if params.SyncPoint < 0 || params.SyncPoint >= len(l.history) {
+		return nil, NewError(http.StatusNotFound, fmt.Sprintf(
+			"sync point %d of network list %s not found", params.SyncPoint, l.current.UniqueID))
+	}
+	return n.response(l.history[params.SyncPoint], params.Extended, true), nil
+}
+
+// UpdateNetworkListDetails changes the name and the description of a list,
+// the sync point stays the same
+func (n *NetList) UpdateNetworkListDetails(ctx context.Context, params netlist.UpdateNetworkListDetailsRequest) error {
+	if err := params.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+	}
+	if err := n.call(ctx, MethodUpdateNetworkListDetails); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l, err := n.get(params.NetworkListID)
+	if err != nil {
+		return err
+	}
+
+	l.current.Name = params.Name
+	l.current.Description = params.Description
+	l.current.UpdateDate = n.timestamp()
+	l.current.UpdatedBy = n.user
+	l.history[len(l.history)-1] = l.current
+	return nil
+}
+
+func (n *NetList) get(id string) (*list, error) {
+	l, ok := n.lists[id]
+	if !ok {
+		return nil, NewError(http.StatusNotFound, fmt.Sprintf("network list %s not found", id))
+	}
+	return l, nil
+}
+
+// commit stores next as the new sync point of the list
+func (n *NetList) commit(l *list, next netlist.NetworkListResponse) {
+	next.SyncPoint = l.current.SyncPoint + 1
+	next.ElementCount = len(next.List)
+	next.UpdateDate = n.timestamp()
+	next.UpdatedBy = n.user
+	l.current = next
+	l.history = append(l.history, next)
+}
+
+// response returns a copy of the list shaped by the optional parameters
+func (n *NetList) response(l netlist.NetworkListResponse, extended, includeElements bool) *netlist.NetworkListResponse {
+	rval := l
+	rval.List = nil
+	if includeElements {
+		rval.List = copyElements(l.List)
+	}
+	if !extended {
+		rval.CreateDate, rval.CreatedBy, rval.UpdateDate, rval.UpdatedBy = "", "", "", ""
+	}
+	return &rval
+}
+
+func (n *NetList) newID(name string) string {
+	n.nextID++
+	return fmt.Sprintf("%d_%s", 1000+n.nextID, idChars.ReplaceAllString(strings.ToUpper(name), ""))
+}
+
+func (n *NetList) timestamp() string {
+	return n.now().UTC().Format(time.RFC3339)
+}
+
+func optional(params *netlist.OptionalParams) (extended, includeElements bool) {
+	if params == nil {
+		return false, false
+	}
+	return params.Extended, params.IncludeElements
+}
+
+func matches(l netlist.NetworkListResponse, search string) bool {
+	if strings.Contains(strings.ToLower(l.Name), strings.ToLower(search)) {
+		return true
+	}
+	for _, e := range l.List {
+		if e == search {
+			return true
+		}
+	}
+	return false
+}
+
+// validateElements rejects the elements which do not fit the list type like the API does
+func validateElements(listType string, elements []string) error {
+	t, err := netlist.ParseNetworkType(listType)
+	if err != nil {
+		return nil
+	}
+	allow := netlist.ElementPolicy{Private: netlist.PolicyAllow, Reserved: netlist.PolicyAllow, Multicast: netlist.PolicyAllow}
+	if _, err := netlist.ValidateElements(t, elements, allow); err != nil {
+		return NewError(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+func dedupe(elements []string) []string {
+	seen := make(map[string]bool, len(elements))
+	rval := make([]string, 0, len(elements))
+	for _, e := range elements {
+		if !seen[e] {
+			seen[e] = true
+			rval = append(rval, e)
+		}
+	}
+	return rval
+}
+
+func copyElements(elements []string) []string {
+	if elements == nil {
+		return nil
+	}
+	return append([]string(nil), elements...)
+}
@@ -0,0 +1,352 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akamai-playground/netlist"
+	"github.com/akamai-playground/netlist/fake"
+)
+
+func TestNetList(t *testing.T) {
+	ctx := context.Background()
+	n := fake.New(fake.WithUser("tester"), fake.WithClock(func() time.Time {
+		return time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	}))
+
+	created, err := n.CreateNetworkList(ctx, netlist.CreateNetworkListRequest{
+		BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+			Name: "Blocked IPs", Type: "IP", Description: "blocked", List: []string{"1.1.1.1", "1.1.1.1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.UniqueID != "1001_BLOCKEDIPS" || created.SyncPoint != 0 || created.ElementCount != 1 {
+		t.Errorf("created %s at sync point %d with %d elements", created.UniqueID, created.SyncPoint, created.ElementCount)
+	}
+	if created.CreatedBy != "tester" || created.CreateDate != "2020-06-01T12:00:00Z" {
+		t.Errorf("created by %s on %s", created.CreatedBy, created.CreateDate)
+	}
+	id := created.UniqueID
+
+	tests := map[string]struct {
+		call      func() (*netlist.NetworkListResponse, error)
+		want      []string
+		syncPoint int
+		err       error
+	}{
+		"append": {
+			call: func() (*netlist.NetworkListResponse, error) {
+				return n.AppendList(ctx, netlist.AppendListRequest{NetworkListID: id, List: []string{"1.1.1.1", "2.2.2.2"}})
+			},
+			want:      []string{"1.1.1.1", "2.2.2.2"},
+			syncPoint: 1,
+		},
+		"add": {
+			call: func() (*netlist.NetworkListResponse, error) {
+				return n.AddElement(ctx, netlist.AddElementRequest{NetworkListID: id, Element: "3.3.3.3"})
+			},
+			want:      []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"},
+			syncPoint: 2,
+		},
+		"add of an element not fitting the type": {
+			call: func() (*netlist.NetworkListResponse, error) {
+				return n.AddElement(ctx, netlist.AddElementRequest{NetworkListID: id, Element: "FR"})
+			},
+			err: netlist.ErrValidation,
+		},
+		"remove": {
+			call: func() (*netlist.NetworkListResponse, error) {
+				return n.RemoveElement(ctx, netlist.RemoveElementRequest{
+					AddElementRequest: &netlist.AddElementRequest{NetworkListID: id, Element: "1.1.1.1"},
+				})
+			},
+			want:      []string{"2.2.2.2", "3.3.3.3"},
+			syncPoint: 3,
+		},
+		"remove of a missing element": {
+			call: func() (*netlist.NetworkListResponse, error) {
+				return n.RemoveElement(ctx, netlist.RemoveElementRequest{
+					AddElementRequest: &netlist.AddElementRequest{NetworkListID: id, Element: "1.1.1.1"},
+				})
+			},
+			err: netlist.ErrNotFound,
+		},
+		"update at a stale sync point": {
+			call: func() (*netlist.NetworkListResponse, error) {
+				return n.UpdateNetworkList(ctx, update(id, 2, "4.4.4.4"))
+			},
+			err: netlist.ErrConflict,
+		},
+		"update at the current sync point": {
+			call: func() (*netlist.NetworkListResponse, error) {
+				return n.UpdateNetworkList(ctx, update(id, 3, "4.4.4.4"))
+			},
+			want:      []string{"4.4.4.4"},
+			syncPoint: 4,
+		},
+		"snapshot": {
+			call: func() (*netlist.NetworkListResponse, error) {
+				return n.GetActivationSnapshot(ctx, netlist.GetActivationSnapshotRequest{NetworkListID: id, SyncPoint: 1})
+			},
+			want:      []string{"1.1.1.1", "2.2.2.2"},
+			syncPoint: 1,
+		},
+		"missing snapshot": {
+			call: func() (*netlist.NetworkListResponse, error) {
+				return n.GetActivationSnapshot(ctx, netlist.GetActivationSnapshotRequest{NetworkListID: id, SyncPoint: 5})
+			},
+			err: netlist.ErrNotFound,
+		},
+		"missing list": {
+			call: func() (*netlist.NetworkListResponse, error) {
+				return n.GetNetworkList(ctx, netlist.GetNetworkListRequest{NetworkListID: "1_MISSING"})
+			},
+			err: netlist.ErrNotFound,
+		},
+	}
+
+	// the cases run in order, each one building on the previous sync point
+	for _, name := range []string{"append", "add", "add of an element not fitting the type", "remove",
+		"remove of a missing element", "update at a stale sync point", "update at the current sync point",
+		"snapshot", "missing snapshot", "missing list"} {
+		test := tests[name]
+		t.Run(name, func(t *testing.T) {
+			list, err := test.call()
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(list.List, test.want) || list.SyncPoint != test.syncPoint {
+				t.Errorf("list at sync point %d with %v, want %d with %v", list.SyncPoint, list.List, test.syncPoint, test.want)
+			}
+		})
+	}
+}
+
+func TestNetListSeed(t *testing.T) {
+	n := fake.New()
+	seeded := n.Seed(netlist.NetworkListResponse{Name: "Countries", Type: "GEO", List: []string{"FR"}, SyncPoint: 5})
+	if seeded.UniqueID != "1001_COUNTRIES" || seeded.SyncPoint != 5 {
+		t.Errorf("seeded %s at sync point %d", seeded.UniqueID, seeded.SyncPoint)
+	}
+
+	snapshot, err := n.GetActivationSnapshot(context.Background(), netlist.GetActivationSnapshotRequest{
+		NetworkListID: seeded.UniqueID,
+		SyncPoint:     2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.SyncPoint != 2 || !reflect.DeepEqual(snapshot.List, []string{"FR"}) {
+		t.Errorf("snapshot at sync point %d with %v", snapshot.SyncPoint, snapshot.List)
+	}
+
+	list, err := n.GetNetworkList(context.Background(), netlist.GetNetworkListRequest{NetworkListID: seeded.UniqueID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.List != nil {
+		t.Errorf("elements returned without IncludeElements: %v", list.List)
+	}
+}
+
+func TestNetListActivation(t *testing.T) {
+	ctx := context.Background()
+	n := fake.New(fake.WithActivationPolls(2))
+	l := n.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: "IP", List: []string{"1.1.1.1"}})
+	status := netlist.ActivateNetworkListRequest{NetworkListID: l.UniqueID, Environment: netlist.PRODUCTION}
+
+	steps := []struct {
+		name   string
+		action func() error
+		want   string
+	}{
+		{name: "never activated", want: netlist.StatusInactive},
+		{
+			name: "activation requested",
+			action: func() error {
+				_, err := n.ActivateNetworkList(ctx, status)
+				return err
+			},
+			want: netlist.StatusPendingActivation,
+		},
+		{name: "polled twice", want: netlist.StatusActive},
+		{
+			name: "changed since",
+			action: func() error {
+				_, err := n.AddElement(ctx, netlist.AddElementRequest{NetworkListID: l.UniqueID, Element: "2.2.2.2"})
+				return err
+			},
+			want: netlist.StatusModified,
+		},
+		{
+			name: "forced status",
+			action: func() error {
+				return n.SetActivationStatus(l.UniqueID, netlist.PRODUCTION, netlist.StatusFailed)
+			},
+			want: netlist.StatusFailed,
+		},
+	}
+
+	for _, step := range steps {
+		if step.action != nil {
+			if err := step.action(); err != nil {
+				t.Fatalf("%s: %s", step.name, err)
+			}
+		}
+		got, err := n.GetActivationNetworkList(ctx, status)
+		if err != nil {
+			t.Fatalf("%s: %s", step.name, err)
+		}
+		if got.ActivationStatus != step.want {
+			t.Errorf("%s: status = %s, want %s", step.name, got.ActivationStatus, step.want)
+		}
+	}
+
+	staging, err := n.GetActivationNetworkList(ctx, netlist.ActivateNetworkListRequest{
+		NetworkListID: l.UniqueID,
+		Environment:   netlist.STAGING,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staging.ActivationStatus != netlist.StatusInactive {
+		t.Errorf("staging status = %s, want %s", staging.ActivationStatus, netlist.StatusInactive)
+	}
+}
+
+func TestNetListDelete(t *testing.T) {
+	tests := map[string]struct {
+		status string
+		err    error
+	}{
+		"inactive list": {},
+		"active list":   {status: netlist.StatusActive, err: netlist.ErrConflict},
+		"pending list":  {status: netlist.StatusPendingActivation, err: netlist.ErrConflict},
+		"deactivated":   {status: netlist.StatusInactive},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			n := fake.New()
+			l := n.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: "IP"})
+			if test.status != "" {
+				if err := n.SetActivationStatus(l.UniqueID, netlist.STAGING, test.status); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := n.DeleteNetworkList(ctx, netlist.DeleteNetworkListRequest{
+				GetNetworkListRequest: &netlist.GetNetworkListRequest{NetworkListID: l.UniqueID},
+			})
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := n.GetNetworkList(ctx, netlist.GetNetworkListRequest{NetworkListID: l.UniqueID}); !errors.Is(err, netlist.ErrNotFound) {
+				t.Errorf("deleted list fetched, error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNetListFaults(t *testing.T) {
+	boom := fake.NewError(http.StatusInternalServerError, "boom")
+
+	tests := map[string]struct {
+		fault    fake.Fault
+		timeout  time.Duration
+		failures int
+		err      error
+	}{
+		"limited failures": {
+			fault:    fake.Fault{Err: boom, Times: 2},
+			failures: 2,
+			err:      netlist.ErrServerError,
+		},
+		"every call fails": {
+			fault:    fake.Fault{Err: boom},
+			failures: 4,
+			err:      netlist.ErrServerError,
+		},
+		"latency within the deadline": {
+			fault:   fake.Fault{Latency: time.Millisecond},
+			timeout: time.Second,
+		},
+		"latency beyond the deadline": {
+			fault:    fake.Fault{Latency: time.Second},
+			timeout:  10 * time.Millisecond,
+			failures: 4,
+			err:      context.DeadlineExceeded,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			n := fake.New()
+			l := n.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: "IP"})
+			n.Inject(fake.MethodGetNetworkList, test.fault)
+
+			failures := 0
+			for i := 0; i < 4; i++ {
+				ctx := context.Background()
+				if test.timeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, test.timeout)
+					defer cancel()
+				}
+				_, err := n.GetNetworkList(ctx, netlist.GetNetworkListRequest{NetworkListID: l.UniqueID})
+				if err != nil {
+					if !errors.Is(err, test.err) {
+						t.Fatalf("error = %v, want %v", err, test.err)
+					}
+					failures++
+				}
+			}
+			if failures != test.failures {
+				t.Errorf("%d failed calls, want %d", failures, test.failures)
+			}
+			if calls := n.Calls(fake.MethodGetNetworkList); calls != 4 {
+				t.Errorf("%d calls counted, want 4", calls)
+			}
+
+			n.Reset()
+			if _, err := n.GetNetworkList(context.Background(), netlist.GetNetworkListRequest{NetworkListID: l.UniqueID}); err != nil {
+				t.Errorf("call failed after Reset: %s", err)
+			}
+		})
+	}
+}
+
+func update(id string, syncPoint int, elements ...string) netlist.UpdateNetworkListRequest {
+	return netlist.UpdateNetworkListRequest{
+		BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+			GetNetworkListRequest: &netlist.GetNetworkListRequest{
+				OptionalParams: &netlist.OptionalParams{IncludeElements: true},
+				NetworkListID:  id,
+			},
+			Name:        "Blocked IPs",
+			Type:        "IP",
+			Description: "blocked",
+			List:        elements,
+		},
+		SyncPoint: syncPoint,
+	}
+}
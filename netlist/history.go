@@ -0,0 +1,186 @@
+package netlist
+
+import (
+	"context"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type (
+	// SyncPointHistoryRequest selects the range of sync points to walk
+	SyncPointHistoryRequest struct {
+		NetworkListID string
+		// From is the first sync point reported, 0 being the creation of the list
+		From int
+		// To is the last sync point reported, the current one when nil
+		To *int
+	}
+
+	// SyncPointChange describes what changed at a sync point compared to the previous one
+	SyncPointChange struct {
+		SyncPoint   int          `json:"syncPoint"`
+		UpdatedBy   string       `json:"updatedBy,omitempty"`
+		UpdateDate  string       `json:"updateDate,omitempty"`
+		Name        *FieldChange `json:"name,omitempty"`
+		Description *FieldChange `json:"description,omitempty"`
+		ElementsDiff
+		// ElementCount is the number of elements the list held at the sync point
+		ElementCount int `json:"elementCount"`
+	}
+
+	// RestoreNetworkListRequest describes the sync point a list is rolled back to
+	RestoreNetworkListRequest struct {
+		NetworkListID string
+		SyncPoint     int
+		// MaxRetries is passed to MutateNetworkList
+		MaxRetries int
+		Policy     *ElementPolicy
+	}
+)
+
+// GetSyncPointHistory walks the snapshots of a list from From to To and reports,
+// for every sync point, who changed the list and the element diff to the
+// previous snapshot. The first sync point of the range is compared to the one
+// before it, or to an empty list for sync point 0.
+func GetSyncPointHistory(ctx context.Context, client NetworkList, params SyncPointHistoryRequest) ([]SyncPointChange, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debug("GetSyncPointHistory")
+
+	var to int
+	if params.To != nil {
+		to = *params.To
+	} else {
+		current, err := client.GetNetworkList(ctx, GetNetworkListRequest{
+			OptionalParams: &OptionalParams{Extended: true},
+			NetworkListID:  params.NetworkListID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		to = current.SyncPoint
+	}
+	if params.From > to {
+		return nil, fmt.Errorf("%w: from: sync point %d is after %d", ErrStructValidation, params.From, to)
+	}
+
+	previous := &NetworkListResponse{}
+	if params.From > 0 {
+		var err error
+		if previous, err = snapshot(ctx, client, params.NetworkListID, params.From-1); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make([]SyncPointChange, 0, to-params.From+1)
+	for syncPoint := params.From; syncPoint <= to; syncPoint++ {
+		current, err := snapshot(ctx, client, params.NetworkListID, syncPoint)
+		if err != nil {
+			return changes, err
+		}
+
+		change := SyncPointChange{
+			SyncPoint:    syncPoint,
+			UpdatedBy:    current.UpdatedBy,
+			UpdateDate:   current.UpdateDate,
+			ElementsDiff: DiffElements(previous.List, current.List),
+			ElementCount: len(current.List),
+		}
+		if syncPoint > 0 {
+			change.Name = changeOf(previous.Name, current.Name)
+			change.Description = changeOf(previous.Description, current.Description)
+		} else if change.UpdatedBy == "" {
+			change.UpdatedBy = current.CreatedBy
+		}
+		changes = append(changes, change)
+		previous = current
+	}
+	return changes, nil
+}
+
+// RestoreNetworkList replaces the elements of a list by those it held at an older
+// sync point. The update is based on the current sync point and retried on
+// conflicts, see MutateNetworkList. The restore creates a new sync point.
+func RestoreNetworkList(ctx context.Context, client NetworkList, params RestoreNetworkListRequest) (*NetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debug("RestoreNetworkList")
+
+	old, err := snapshot(ctx, client, params.NetworkListID, params.SyncPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := MutateNetworkList(ctx, client, MutateNetworkListRequest{
+		NetworkListID: params.NetworkListID,
+		Mutate: func(list *NetworkListResponse) error {
+			list.List = old.List
+			return nil
+		},
+		MaxRetries: params.MaxRetries,
+		Policy:     params.Policy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("%[1]s: restored the %[2]d elements of sync point %[3]d, new sync point %[4]d",
+		params.NetworkListID, len(old.List), params.SyncPoint, list.SyncPoint)
+	return list, nil
+}
+
+// PlanRestoreNetworkList describes what RestoreNetworkList would change
+func PlanRestoreNetworkList(ctx context.Context, client NetworkList, params RestoreNetworkListRequest) (*Plan, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	old, err := snapshot(ctx, client, params.NetworkListID, params.SyncPoint)
+	if err != nil {
+		return nil, err
+	}
+	current, err := fetchForPlan(ctx, client, params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := newPlan(PlanRestore, current)
+	plan.ElementsDiff = DiffElements(current.List, old.List)
+	return plan, nil
+}
+
+func snapshot(ctx context.Context, client NetworkList, listID string, syncPoint int) (*NetworkListResponse, error) {
+	list, err := client.GetActivationSnapshot(ctx, GetActivationSnapshotRequest{
+		NetworkListID: listID,
+		Extended:      true,
+		SyncPoint:     syncPoint,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching sync point %d of %s: %w", syncPoint, listID, err)
+	}
+	return list, nil
+}
+
+// Validate validates SyncPointHistoryRequest
+func (v SyncPointHistoryRequest) Validate() error {
+	return validation.Errors{
+		"networkListId": validation.Validate(v.NetworkListID, validation.Required),
+		"from":          validation.Validate(v.From, validation.Min(0)),
+		"to":            validation.Validate(v.To, validation.Min(0)),
+	}.Filter()
+}
+
+// Validate validates RestoreNetworkListRequest
+func (v RestoreNetworkListRequest) Validate() error {
+	return validation.Errors{
+		"networkListId": validation.Validate(v.NetworkListID, validation.Required),
+		"syncPoint":     validation.Validate(v.SyncPoint, validation.Min(0)),
+	}.Filter()
+}
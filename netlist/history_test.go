@@ -0,0 +1,146 @@
+package netlist_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akamai-playground/netlist"
+	"github.com/akamai-playground/netlist/fake"
+)
+
+// historyList creates a list with 3 sync points: created with 1.1.1.1,
+// 2.2.2.2 appended, then renamed with 1.1.1.1 removed
+func historyList(t *testing.T, client *fake.NetList) string {
+	ctx := context.Background()
+	l, err := client.CreateNetworkList(ctx, netlist.CreateNetworkListRequest{
+		BodyNetworkListRequest: &netlist.BodyNetworkListRequest{Name: "Blocked", Type: netlist.IP.String(), List: []string{"1.1.1.1"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.AppendList(ctx, netlist.AppendListRequest{NetworkListID: l.UniqueID, List: []string{"2.2.2.2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.UpdateNetworkList(ctx, netlist.UpdateNetworkListRequest{
+		BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+			GetNetworkListRequest: &netlist.GetNetworkListRequest{NetworkListID: l.UniqueID},
+			Name:                  "Renamed",
+			Type:                  netlist.IP.String(),
+			List:                  []string{"2.2.2.2"},
+		},
+		SyncPoint: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return l.UniqueID
+}
+
+func TestGetSyncPointHistory(t *testing.T) {
+	tests := map[string]struct {
+		from       int
+		to         *int
+		syncPoints []int
+		added      [][]string
+		removed    [][]string
+		err        error
+	}{
+		"whole history": {
+			from:       0,
+			syncPoints: []int{0, 1, 2},
+			added:      [][]string{{"1.1.1.1"}, {"2.2.2.2"}, nil},
+			removed:    [][]string{nil, nil, {"1.1.1.1"}},
+		},
+		"creation only": {
+			from:       0,
+			to:         syncPoint(0),
+			syncPoints: []int{0},
+			added:      [][]string{{"1.1.1.1"}},
+			removed:    [][]string{nil},
+		},
+		"from a sync point": {
+			from:       2,
+			syncPoints: []int{2},
+			added:      [][]string{nil},
+			removed:    [][]string{{"1.1.1.1"}},
+		},
+		"from after to": {
+			from: 2,
+			to:   syncPoint(1),
+			err:  netlist.ErrStructValidation,
+		},
+		"invalid to": {
+			to:  syncPoint(-1),
+			err: netlist.ErrStructValidation,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := fake.New()
+			id := historyList(t, client)
+
+			changes, err := netlist.GetSyncPointHistory(context.Background(), client, netlist.SyncPointHistoryRequest{
+				NetworkListID: id,
+				From:          test.from,
+				To:            test.to,
+			})
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(changes) != len(test.syncPoints) {
+				t.Fatalf("%d changes, want %d", len(changes), len(test.syncPoints))
+			}
+			for i, c := range changes {
+				if c.SyncPoint != test.syncPoints[i] {
+					t.Errorf("change %d: sync point %d, want %d", i, c.SyncPoint, test.syncPoints[i])
+				}
+				if !equalElements(c.Added, test.added[i]) || !equalElements(c.Removed, test.removed[i]) {
+					t.Errorf("sync point %d: added %v removed %v, want %v %v", c.SyncPoint, c.Added, c.Removed, test.added[i], test.removed[i])
+				}
+				if c.UpdatedBy != "fake" {
+					t.Errorf("sync point %d: updated by %q, want fake", c.SyncPoint, c.UpdatedBy)
+				}
+				if c.SyncPoint == 2 && (c.Name == nil || c.Name.From != "Blocked" || c.Name.To != "Renamed") {
+					t.Errorf("sync point 2: name change %+v, want Blocked -> Renamed", c.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreNetworkList(t *testing.T) {
+	ctx := context.Background()
+	client := fake.New()
+	id := historyList(t, client)
+
+	plan, err := netlist.PlanRestoreNetworkList(ctx, client, netlist.RestoreNetworkListRequest{NetworkListID: id, SyncPoint: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.HasChanges() {
+		t.Errorf("plan of the restore has no changes")
+	}
+
+	out, err := netlist.RestoreNetworkList(ctx, client, netlist.RestoreNetworkListRequest{NetworkListID: id, SyncPoint: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.SyncPoint != 3 || !equalElements(out.List, []string{"1.1.1.1", "2.2.2.2"}) {
+		t.Errorf("restored sync point %d with %v, want 3 with [1.1.1.1 2.2.2.2]", out.SyncPoint, out.List)
+	}
+	if out.Name != "Renamed" {
+		t.Errorf("restore changed the name to %q", out.Name)
+	}
+}
+
+func syncPoint(p int) *int {
+	return &p
+}
@@ -0,0 +1,249 @@
+package netlist
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+type (
+	// IPElementSet is a set of IPv4 and IPv6 addresses and CIDR blocks.
+	// Elements are kept canonical: host bits are cleared, duplicates and
+	// prefixes covered by a larger prefix of the set are dropped
+	IPElementSet struct {
+		prefixes []ipPrefix
+		dirty    bool
+	}
+
+	// ElementError describes an invalid element of a list
+	ElementError struct {
+		Index   int    `json:"index"`
+		Element string `json:"element"`
+		Reason  string `json:"reason"`
+	}
+
+	// ElementsError lists every invalid element of a list
+	ElementsError []ElementError
+
+	ipPrefix struct {
+		ip   net.IP
+		bits int
+	}
+)
+
+func (e ElementError) Error() string {
+	return fmt.Sprintf("element %d (%q): %s", e.Index, e.Element, e.Reason)
+}
+
+func (e ElementsError) Error() string {
+	return fmt.Sprintf("%d invalid elements: %s", len(e), e.join())
+}
+
+func (e ElementsError) join() string {
+	msgs := make([]string, 0, len(e))
+	for _, el := range e {
+		msgs = append(msgs, el.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// NewIPElementSet returns an empty IPElementSet
+func NewIPElementSet() *IPElementSet {
+	return &IPElementSet{}
+}
+
+// ParseIPElements returns the set of the given elements. Every element
+// which is neither an IP address nor a CIDR block is reported in ElementsError
+func ParseIPElements(elements []string) (*IPElementSet, error) {
+	s := NewIPElementSet()
+
+	var errs ElementsError
+	for i, e := range elements {
+		if err := s.Add(e); err != nil {
+			errs = append(errs, ElementError{Index: i, Element: e, Reason: err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		return s, errs
+	}
+	return s, nil
+}
+
+// NormalizeIPElements canonicalizes and deduplicates the given elements,
+// adjacent prefixes are merged as well when aggregate is set
+func NormalizeIPElements(elements []string, aggregate bool) ([]string, error) {
+	s, err := ParseIPElements(elements)
+	if err != nil {
+		return nil, err
+	}
+	if aggregate {
+		s.Aggregate()
+	}
+	return s.Elements(), nil
+}
+
+// ParseIPElement parses an IP address or a CIDR block and returns it
+// as a network with the host bits cleared
+func ParseIPElement(element string) (*net.IPNet, error) {
+	p, err := parsePrefix(element)
+	if err != nil {
+		return nil, err
+	}
+	return p.ipNet(), nil
+}
+
+// Add adds an IP address or a CIDR block to the set
+func (s *IPElementSet) Add(element string) error {
+	p, err := parsePrefix(element)
+	if err != nil {
+		return err
+	}
+	s.prefixes = append(s.prefixes, p)
+	s.dirty = true
+	return nil
+}
+
+// Len returns the number of elements in the set
+func (s *IPElementSet) Len() int {
+	s.normalize()
+	return len(s.prefixes)
+}
+
+// Contains reports whether the address or the whole block given is covered by the set
+func (s *IPElementSet) Contains(element string) bool {
+	p, err := parsePrefix(element)
+	if err != nil {
+		return false
+	}
+	s.normalize()
+
+	i := sort.Search(len(s.prefixes), func(i int) bool {
+		return comparePrefix(s.prefixes[i], p) > 0
+	})
+	return i > 0 && s.prefixes[i-1].covers(p)
+}
+
+// Aggregate merges sibling prefixes into their parent prefix,
+// e.g. 10.0.0.0/25 and 10.0.0.128/25 become 10.0.0.0/24
+func (s *IPElementSet) Aggregate() {
+	s.normalize()
+
+	merged := make([]ipPrefix, 0, len(s.prefixes))
+	for _, p := range s.prefixes {
+		merged = append(merged, p)
+		for len(merged) > 1 {
+			last := len(merged) - 1
+			parent, ok := merged[last-1].siblingParent(merged[last])
+			if !ok {
+				break
+			}
+			merged = append(merged[:last-1], parent)
+		}
+	}
+	s.prefixes = merged
+}
+
+// Elements returns the canonical elements of the set, sorted by address.
+// Single addresses are rendered without a prefix length
+func (s *IPElementSet) Elements() []string {
+	s.normalize()
+
+	elements := make([]string, 0, len(s.prefixes))
+	for _, p := range s.prefixes {
+		elements = append(elements, p.String())
+	}
+	return elements
+}
+
+// normalize sorts the prefixes and drops duplicates and covered prefixes
+func (s *IPElementSet) normalize() {
+	if !s.dirty {
+		return
+	}
+	s.dirty = false
+
+	sort.Slice(s.prefixes, func(i, j int) bool {
+		return comparePrefix(s.prefixes[i], s.prefixes[j]) < 0
+	})
+
+	kept := s.prefixes[:0]
+	for _, p := range s.prefixes {
+		if len(kept) > 0 && kept[len(kept)-1].covers(p) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	s.prefixes = kept
+}
+
+func parsePrefix(element string) (ipPrefix, error) {
+	element = strings.TrimSpace(element)
+
+	if strings.Contains(element, "/") {
+		ip, ipNet, err := net.ParseCIDR(element)
+		if err != nil {
+			return ipPrefix{}, fmt.Errorf("invalid CIDR block")
+		}
+		bits, _ := ipNet.Mask.Size()
+		if ip4 := ip.To4(); ip4 != nil && len(ipNet.IP) == net.IPv4len {
+			return ipPrefix{ip: ip4.Mask(ipNet.Mask), bits: bits}, nil
+		}
+		return ipPrefix{ip: ipNet.IP.To16(), bits: bits}, nil
+	}
+
+	ip := net.ParseIP(element)
+	if ip == nil {
+		return ipPrefix{}, fmt.Errorf("invalid IP address")
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ipPrefix{ip: ip4, bits: 8 * net.IPv4len}, nil
+	}
+	return ipPrefix{ip: ip, bits: 8 * net.IPv6len}, nil
+}
+
+// comparePrefix orders IPv4 before IPv6, then by address, then larger prefixes first
+func comparePrefix(a, b ipPrefix) int {
+	if len(a.ip) != len(b.ip) {
+		return len(a.ip) - len(b.ip)
+	}
+	if c := bytes.Compare(a.ip, b.ip); c != 0 {
+		return c
+	}
+	return a.bits - b.bits
+}
+
+func (p ipPrefix) ipNet() *net.IPNet {
+	return &net.IPNet{IP: p.ip, Mask: net.CIDRMask(p.bits, 8*len(p.ip))}
+}
+
+// covers reports whether o lies within p
+func (p ipPrefix) covers(o ipPrefix) bool {
+	if len(p.ip) != len(o.ip) || p.bits > o.bits {
+		return false
+	}
+	return p.ipNet().Contains(o.ip)
+}
+
+// siblingParent returns the parent prefix when p and o are its two halves
+func (p ipPrefix) siblingParent(o ipPrefix) (ipPrefix, bool) {
+	if len(p.ip) != len(o.ip) || p.bits != o.bits || p.bits == 0 {
+		return ipPrefix{}, false
+	}
+
+	parent := ipPrefix{bits: p.bits - 1}
+	mask := net.CIDRMask(parent.bits, 8*len(p.ip))
+	parent.ip = p.ip.Mask(mask)
+	if !parent.ip.Equal(p.ip) || !parent.ip.Equal(o.ip.Mask(mask)) || p.ip.Equal(o.ip) {
+		return ipPrefix{}, false
+	}
+	return parent, true
+}
+
+func (p ipPrefix) String() string {
+	if p.bits == 8*len(p.ip) {
+		return p.ip.String()
+	}
+	return fmt.Sprintf("%s/%d", p.ip, p.bits)
+}
@@ -0,0 +1,123 @@
+package netlist_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/akamai-playground/netlist"
+)
+
+func TestNormalizeIPElements(t *testing.T) {
+	tests := map[string]struct {
+		elements  []string
+		aggregate bool
+		want      []string
+		invalid   []int
+	}{
+		"host bits are cleared": {
+			elements: []string{"10.0.0.5/8", "2001:db8::ffff:1/64"},
+			want:     []string{"10.0.0.0/8", "2001:db8::/64"},
+		},
+		"duplicates are dropped": {
+			elements: []string{"192.168.1.1", " 192.168.1.1 ", "192.168.1.1/32"},
+			want:     []string{"192.168.1.1"},
+		},
+		"covered elements are dropped": {
+			elements: []string{"10.0.0.1", "10.0.0.0/25", "10.0.0.0/8", "2001:db8::1", "2001:db8::/127"},
+			want:     []string{"10.0.0.0/8", "2001:db8::/127"},
+		},
+		"elements are sorted": {
+			elements: []string{"2.2.2.2", "1.1.1.1", "::1"},
+			want:     []string{"1.1.1.1", "2.2.2.2", "::1"},
+		},
+		"siblings are kept apart": {
+			elements: []string{"10.0.0.0/25", "10.0.0.128/25"},
+			want:     []string{"10.0.0.0/25", "10.0.0.128/25"},
+		},
+		"siblings are aggregated": {
+			elements:  []string{"10.0.0.0/25", "10.0.0.128/25"},
+			aggregate: true,
+			want:      []string{"10.0.0.0/24"},
+		},
+		"aggregation cascades": {
+			elements:  []string{"172.16.0.0/24", "172.16.1.0/24", "172.16.2.0/24", "172.16.3.0/24", "172.16.4.0/24"},
+			aggregate: true,
+			want:      []string{"172.16.0.0/22", "172.16.4.0/24"},
+		},
+		"aggregation up to the whole space": {
+			elements:  []string{"0.0.0.0/1", "128.0.0.0/1", "1.1.1.1"},
+			aggregate: true,
+			want:      []string{"0.0.0.0/0"},
+		},
+		"unaligned neighbours are not aggregated": {
+			elements:  []string{"10.0.1.0/24", "10.0.2.0/24"},
+			aggregate: true,
+			want:      []string{"10.0.1.0/24", "10.0.2.0/24"},
+		},
+		"invalid elements": {
+			elements: []string{"1.1.1.1", "bad", "1.2.3.4/33", "FR"},
+			invalid:  []int{1, 2, 3},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := netlist.NormalizeIPElements(test.elements, test.aggregate)
+			if test.invalid != nil {
+				var errs netlist.ElementsError
+				if !errors.As(err, &errs) {
+					t.Fatalf("error = %v, want ElementsError", err)
+				}
+				var indexes []int
+				for _, e := range errs {
+					indexes = append(indexes, e.Index)
+				}
+				if !reflect.DeepEqual(indexes, test.invalid) {
+					t.Errorf("invalid elements %v, want %v", indexes, test.invalid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("NormalizeIPElements(%v) = %v, want %v", test.elements, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIPElementSetContains(t *testing.T) {
+	set, err := netlist.ParseIPElements([]string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		element string
+		want    bool
+	}{
+		"address in a block":       {"10.3.3.3", true},
+		"block in a block":         {"10.1.0.0/16", true},
+		"larger block":             {"10.0.0.0/7", false},
+		"address outside":          {"11.0.0.0", false},
+		"single address":           {"192.168.1.1", true},
+		"neighbour address":        {"192.168.1.2", false},
+		"IPv6 address in a block":  {"2001:db8::1", true},
+		"IPv6 address outside":     {"2001:db9::1", false},
+		"invalid element":          {"bad", false},
+		"IPv4 mapped IPv6 address": {"::ffff:10.1.1.1", true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := set.Contains(test.element); got != test.want {
+				t.Errorf("Contains(%q) = %t, want %t", test.element, got, test.want)
+			}
+		})
+	}
+	if set.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", set.Len())
+	}
+}
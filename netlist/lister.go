@@ -0,0 +1,282 @@
+package netlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type (
+	// ListFilter selects network lists. Type and Search are sent to the API,
+	// the other criteria are checked against the lists it returns
+	ListFilter struct {
+		Type NetworkType
+		// Search matches the lists whose name contains it or which hold it as an element
+		Search string
+		// Name is a case insensitive glob pattern matched against the whole name,
+		// see path.Match. A name without wildcards is sent as Search when Search is empty
+		Name     string
+		Shared   *bool
+		ReadOnly *bool
+		// MinElements and MaxElements bound the number of elements, MaxElements only when positive
+		MinElements int
+		MaxElements int
+		// ActivationStatus keeps the lists whose activation status on Environment
+		// is one of the given ones. It costs a status call per list matching the other criteria
+		ActivationStatus []string
+		Environment      Environment
+	}
+
+	// FindNetworkListsRequest describes the lists FindNetworkLists and
+	// IterateNetworkLists return
+	FindNetworkListsRequest struct {
+		Filter   ListFilter
+		Extended bool
+		// IncludeElements fetches the elements of the returned lists one list at a time,
+		// the lists are always listed without their elements
+		IncludeElements bool
+		// Page is the page returned by FindNetworkLists, numbered from 1, 1 when unset
+		Page int
+		// PageSize is the number of lists per page, every list is returned on one page when unset
+		PageSize int
+	}
+
+	// NetworkListsPage is a page of the lists matching a FindNetworkListsRequest
+	NetworkListsPage struct {
+		Lists     []*NetworkListResponse `json:"networkLists"`
+		Page      int                    `json:"page"`
+		PageSize  int                    `json:"pageSize"`
+		TotalSize int                    `json:"totalSize"`
+	}
+
+	// NetworkListIterator walks the lists matching a FindNetworkListsRequest,
+	// see IterateNetworkLists
+	NetworkListIterator struct {
+		ctx     context.Context
+		client  NetworkList
+		params  FindNetworkListsRequest
+		lists   []*NetworkListResponse
+		listed  bool
+		next    int
+		current *NetworkListResponse
+		err     error
+	}
+)
+
+// IterateNetworkLists returns an iterator over the lists matching params.
+// Lists are listed once without their elements, the activation status and
+// the elements of a list are only fetched when the iterator reaches it:
+//
+//	it := netlist.IterateNetworkLists(ctx, client, params)
+//	for it.Next() {
+//		list := it.List()
+//	}
+//	if err := it.Err(); err != nil {
+func IterateNetworkLists(ctx context.Context, client NetworkList, params FindNetworkListsRequest) *NetworkListIterator {
+	it := &NetworkListIterator{ctx: ctx, client: client, params: params}
+	if err := params.Validate(); err != nil {
+		it.err = fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+	return it
+}
+
+// Next advances to the next matching list, it returns false once every list
+// was walked or a call failed, see Err
+func (it *NetworkListIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.listed {
+		if it.err = it.list(); it.err != nil {
+			return false
+		}
+	}
+
+	for it.next < len(it.lists) {
+		l := it.lists[it.next]
+		it.next++
+
+		ok, err := it.params.Filter.matchesStatus(it.ctx, it.client, l)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if !ok {
+			continue
+		}
+
+		if it.params.IncludeElements {
+			if l, err = it.client.GetNetworkList(it.ctx, GetNetworkListRequest{
+				OptionalParams: &OptionalParams{Extended: it.params.Extended, IncludeElements: true},
+				NetworkListID:  l.UniqueID,
+			}); err != nil {
+				it.err = err
+				return false
+			}
+		}
+		it.current = l
+		return true
+	}
+
+	it.current = nil
+	return false
+}
+
+// List returns the list the iterator is at
+func (it *NetworkListIterator) List() *NetworkListResponse {
+	return it.current
+}
+
+// Err returns the error which stopped the iterator, if any
+func (it *NetworkListIterator) Err() error {
+	return it.err
+}
+
+// list fetches the lists matching the criteria which need no extra call
+func (it *NetworkListIterator) list() error {
+	logger := loggerFor(it.ctx, it.client)
+	logger.Debug("IterateNetworkLists")
+
+	filter := it.params.Filter
+	search := filter.Search
+	if search == "" && filter.Name != "" && !hasGlobMeta(filter.Name) {
+		search = filter.Name
+	}
+	out, err := it.client.ListNetworkLists(it.ctx, ListNetworkListsRequest{
+		OptionalParams: &OptionalParams{Extended: it.params.Extended},
+		ListType:       filter.Type,
+		Search:         search,
+	})
+	if err != nil {
+		return err
+	}
+
+	it.listed = true
+	for _, l := range out.NetworkLists {
+		if l.NetworkListResponse != nil && filter.matches(l.NetworkListResponse) {
+			it.lists = append(it.lists, l.NetworkListResponse)
+		}
+	}
+	logger.Debugf("%d of %d network lists match the filter", len(it.lists), len(out.NetworkLists))
+	return nil
+}
+
+// FindNetworkLists returns a page of the lists matching params. TotalSize
+// counts every matching list, the elements are only fetched for the lists of the page
+func FindNetworkLists(ctx context.Context, client NetworkList, params FindNetworkListsRequest) (*NetworkListsPage, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debug("FindNetworkLists")
+
+	matching := params
+	matching.IncludeElements = false
+	it := IterateNetworkLists(ctx, client, matching)
+
+	rval := NetworkListsPage{Page: params.Page, PageSize: params.PageSize, Lists: []*NetworkListResponse{}}
+	if rval.Page == 0 {
+		rval.Page = 1
+	}
+	start, end := 0, -1
+	if params.PageSize > 0 {
+		start = (rval.Page - 1) * params.PageSize
+		end = start + params.PageSize
+	}
+	for it.Next() {
+		if rval.TotalSize >= start && (end < 0 || rval.TotalSize < end) {
+			rval.Lists = append(rval.Lists, it.List())
+		}
+		rval.TotalSize++
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	if params.IncludeElements {
+		for i, l := range rval.Lists {
+			full, err := client.GetNetworkList(ctx, GetNetworkListRequest{
+				OptionalParams: &OptionalParams{Extended: params.Extended, IncludeElements: true},
+				NetworkListID:  l.UniqueID,
+			})
+			if err != nil {
+				return nil, err
+			}
+			rval.Lists[i] = full
+		}
+	}
+	return &rval, nil
+}
+
+// matches checks the criteria which need no extra call
+func (f ListFilter) matches(l *NetworkListResponse) bool {
+	switch {
+	case f.Type != 0 && !strings.EqualFold(l.Type, f.Type.String()):
+		return false
+	case f.Shared != nil && l.Shared != *f.Shared:
+		return false
+	case f.ReadOnly != nil && l.ReadOnly != *f.ReadOnly:
+		return false
+	case l.ElementCount < f.MinElements:
+		return false
+	case f.MaxElements > 0 && l.ElementCount > f.MaxElements:
+		return false
+	}
+	if f.Name != "" {
+		if ok, _ := path.Match(strings.ToLower(f.Name), strings.ToLower(l.Name)); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesStatus checks the activation status of l when the filter asks for it
+func (f ListFilter) matchesStatus(ctx context.Context, client NetworkList, l *NetworkListResponse) (bool, error) {
+	if len(f.ActivationStatus) == 0 {
+		return true, nil
+	}
+
+	status, err := client.GetActivationNetworkList(ctx, ActivateNetworkListRequest{
+		NetworkListID: l.UniqueID,
+		Environment:   f.Environment,
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, s := range f.ActivationStatus {
+		if strings.EqualFold(s, status.ActivationStatus) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// Validate validates FindNetworkListsRequest
+func (v FindNetworkListsRequest) Validate() error {
+	f := v.Filter
+	return validation.Errors{
+		"name": validation.Validate(f.Name, validation.By(func(interface{}) error {
+			if _, err := path.Match(f.Name, ""); err != nil {
+				return errors.New("must be a valid glob pattern")
+			}
+			return nil
+		})),
+		"type":        validation.Validate(int(f.Type), validation.In(int(IP), int(GEO))),
+		"minElements": validation.Validate(f.MinElements, validation.Min(0)),
+		"maxElements": validation.Validate(f.MaxElements, validation.Min(0), validation.When(f.MaxElements > 0,
+			validation.Min(f.MinElements).Error("must be no less than minElements"))),
+		"environment": validation.Validate(f.Environment, validation.When(len(f.ActivationStatus) > 0, validation.Required),
+			validation.In(STAGING, PRODUCTION)),
+		"page":     validation.Validate(v.Page, validation.Min(0)),
+		"pageSize": validation.Validate(v.PageSize, validation.Min(0)),
+	}.Filter()
+}
@@ -0,0 +1,209 @@
+package netlist_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/akamai-playground/netlist"
+	"github.com/akamai-playground/netlist/fake"
+)
+
+func seedLists(client *fake.NetList) {
+	client.Seed(netlist.NetworkListResponse{Name: "Blocked IPs", Type: "IP", List: []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"}})
+	client.Seed(netlist.NetworkListResponse{Name: "Allowed IPs", Type: "IP", List: []string{"4.4.4.4"}, Shared: true})
+	client.Seed(netlist.NetworkListResponse{Name: "Blocked countries", Type: "GEO", List: []string{"FR", "US"}, ReadOnly: true})
+	client.Seed(netlist.NetworkListResponse{Name: "Empty", Type: "IP"})
+}
+
+func TestFindNetworkLists(t *testing.T) {
+	yes, no := true, false
+
+	tests := map[string]struct {
+		params netlist.FindNetworkListsRequest
+		active []string
+		want   []string
+		total  int
+		err    error
+	}{
+		"every list": {
+			want:  []string{"1001_BLOCKEDIPS", "1002_ALLOWEDIPS", "1003_BLOCKEDCOUNTRIES", "1004_EMPTY"},
+			total: 4,
+		},
+		"by type": {
+			params: netlist.FindNetworkListsRequest{Filter: netlist.ListFilter{Type: netlist.GEO}},
+			want:   []string{"1003_BLOCKEDCOUNTRIES"},
+			total:  1,
+		},
+		"by name pattern": {
+			params: netlist.FindNetworkListsRequest{Filter: netlist.ListFilter{Name: "blocked*"}},
+			want:   []string{"1001_BLOCKEDIPS", "1003_BLOCKEDCOUNTRIES"},
+			total:  2,
+		},
+		"by exact name": {
+			params: netlist.FindNetworkListsRequest{Filter: netlist.ListFilter{Name: "Blocked IPs"}},
+			want:   []string{"1001_BLOCKEDIPS"},
+			total:  1,
+		},
+		"by element": {
+			params: netlist.FindNetworkListsRequest{Filter: netlist.ListFilter{Search: "4.4.4.4"}},
+			want:   []string{"1002_ALLOWEDIPS"},
+			total:  1,
+		},
+		"shared": {
+			params: netlist.FindNetworkListsRequest{Filter: netlist.ListFilter{Shared: &yes}},
+			want:   []string{"1002_ALLOWEDIPS"},
+			total:  1,
+		},
+		"writable": {
+			params: netlist.FindNetworkListsRequest{Filter: netlist.ListFilter{ReadOnly: &no, Type: netlist.IP}},
+			want:   []string{"1001_BLOCKEDIPS", "1002_ALLOWEDIPS", "1004_EMPTY"},
+			total:  3,
+		},
+		"element count range": {
+			params: netlist.FindNetworkListsRequest{Filter: netlist.ListFilter{MinElements: 1, MaxElements: 2}},
+			want:   []string{"1002_ALLOWEDIPS", "1003_BLOCKEDCOUNTRIES"},
+			total:  2,
+		},
+		"activation status": {
+			params: netlist.FindNetworkListsRequest{Filter: netlist.ListFilter{
+				ActivationStatus: []string{netlist.StatusActive},
+				Environment:      netlist.PRODUCTION,
+			}},
+			active: []string{"1002_ALLOWEDIPS", "1004_EMPTY"},
+			want:   []string{"1002_ALLOWEDIPS", "1004_EMPTY"},
+			total:  2,
+		},
+		"first page": {
+			params: netlist.FindNetworkListsRequest{PageSize: 3},
+			want:   []string{"1001_BLOCKEDIPS", "1002_ALLOWEDIPS", "1003_BLOCKEDCOUNTRIES"},
+			total:  4,
+		},
+		"last page": {
+			params: netlist.FindNetworkListsRequest{Page: 2, PageSize: 3},
+			want:   []string{"1004_EMPTY"},
+			total:  4,
+		},
+		"page past the end": {
+			params: netlist.FindNetworkListsRequest{Page: 3, PageSize: 3},
+			total:  4,
+		},
+		"invalid pattern": {
+			params: netlist.FindNetworkListsRequest{Filter: netlist.ListFilter{Name: "[blocked"}},
+			err:    netlist.ErrStructValidation,
+		},
+		"status without environment": {
+			params: netlist.FindNetworkListsRequest{Filter: netlist.ListFilter{ActivationStatus: []string{netlist.StatusActive}}},
+			err:    netlist.ErrStructValidation,
+		},
+		"inverted element count range": {
+			params: netlist.FindNetworkListsRequest{Filter: netlist.ListFilter{MinElements: 3, MaxElements: 2}},
+			err:    netlist.ErrStructValidation,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := fake.New()
+			seedLists(client)
+			for _, id := range test.active {
+				if err := client.SetActivationStatus(id, netlist.PRODUCTION, netlist.StatusActive); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			page, err := netlist.FindNetworkLists(context.Background(), client, test.params)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []string
+			for _, l := range page.Lists {
+				ids = append(ids, l.UniqueID)
+				if l.List != nil {
+					t.Errorf("%s listed with its elements", l.UniqueID)
+				}
+			}
+			if !reflect.DeepEqual(ids, test.want) {
+				t.Errorf("lists = %v, want %v", ids, test.want)
+			}
+			if page.TotalSize != test.total {
+				t.Errorf("total = %d, want %d", page.TotalSize, test.total)
+			}
+		})
+	}
+}
+
+func TestFindNetworkListsIncludeElements(t *testing.T) {
+	client := fake.New()
+	seedLists(client)
+
+	page, err := netlist.FindNetworkLists(context.Background(), client, netlist.FindNetworkListsRequest{
+		IncludeElements: true,
+		Page:            2,
+		PageSize:        1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Lists) != 1 || !reflect.DeepEqual(page.Lists[0].List, []string{"4.4.4.4"}) {
+		t.Errorf("page = %+v, want 1002_ALLOWEDIPS with its elements", page.Lists)
+	}
+	if calls := client.Calls(fake.MethodGetNetworkList); calls != 1 {
+		t.Errorf("elements fetched for %d lists, want only the page", calls)
+	}
+}
+
+func TestIterateNetworkLists(t *testing.T) {
+	client := fake.New()
+	seedLists(client)
+
+	it := netlist.IterateNetworkLists(context.Background(), client, netlist.FindNetworkListsRequest{
+		Filter:          netlist.ListFilter{Type: netlist.IP},
+		IncludeElements: true,
+	})
+
+	var counts []int
+	for it.Next() {
+		counts = append(counts, len(it.List().List))
+		if calls := client.Calls(fake.MethodGetNetworkList); calls != len(counts) {
+			t.Errorf("%d lists fetched after %d iterations", calls, len(counts))
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{3, 1, 0}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("element counts = %v, want %v", counts, want)
+	}
+	if calls := client.Calls(fake.MethodListNetworkLists); calls != 1 {
+		t.Errorf("lists listed %d times, want once", calls)
+	}
+}
+
+func TestIterateNetworkListsFailure(t *testing.T) {
+	client := fake.New()
+	seedLists(client)
+	unavailable := errors.New("status unavailable")
+	client.Fail(fake.MethodGetActivationNetworkList, unavailable, 0)
+
+	it := netlist.IterateNetworkLists(context.Background(), client, netlist.FindNetworkListsRequest{
+		Filter: netlist.ListFilter{ActivationStatus: []string{netlist.StatusInactive}, Environment: netlist.STAGING},
+	})
+	if it.Next() {
+		t.Fatal("Next() = true after a failed status call")
+	}
+	if !errors.Is(it.Err(), unavailable) {
+		t.Errorf("Err() = %v, want %v", it.Err(), unavailable)
+	}
+	if it.Next() {
+		t.Error("Next() = true once stopped")
+	}
+}
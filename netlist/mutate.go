@@ -0,0 +1,111 @@
+package netlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const defaultMutateRetries = 3
+
+type (
+	// MutateFunc changes the name, description or elements of the fetched list in place.
+	// It may be called several times, once per attempt, with a freshly fetched list
+	MutateFunc func(list *NetworkListResponse) error
+
+	// MutateNetworkListRequest describes a read-modify-write of a list
+	MutateNetworkListRequest struct {
+		NetworkListID string
+		Mutate        MutateFunc
+		// MaxRetries is the number of times a conflicting update is retried,
+		// 3 when unset and none when negative
+		MaxRetries int
+		Policy     *ElementPolicy
+	}
+)
+
+// MutateNetworkList fetches a list, applies the mutation to it and submits the
+// result based on the fetched SyncPoint, so concurrent changes are never overwritten.
+// A conflicting update is retried with the list fetched again, once MaxRetries
+// are exhausted a *ConflictError is returned. A mutation which changes nothing
+// returns the fetched list without updating it.
+func MutateNetworkList(ctx context.Context, client NetworkList, params MutateNetworkListRequest) (*NetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debug("MutateNetworkList")
+
+	retries := params.MaxRetries
+	if retries == 0 {
+		retries = defaultMutateRetries
+	} else if retries < 0 {
+		retries = 0
+	}
+
+	for attempt := 1; ; attempt++ {
+		current, err := client.GetNetworkList(ctx, GetNetworkListRequest{
+			OptionalParams: &OptionalParams{Extended: true, IncludeElements: true},
+			NetworkListID:  params.NetworkListID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		mutated := *current
+		mutated.List = append([]string(nil), current.List...)
+		if err := params.Mutate(&mutated); err != nil {
+			return nil, err
+		}
+		if mutated.Name == current.Name && mutated.Description == current.Description &&
+			DiffElements(current.List, mutated.List).Empty() {
+			logger.Debugf("%s: nothing to update", params.NetworkListID)
+			return current, nil
+		}
+
+		list, err := client.UpdateNetworkList(ctx, UpdateNetworkListRequest{
+			BodyNetworkListRequest: &BodyNetworkListRequest{
+				GetNetworkListRequest: &GetNetworkListRequest{
+					OptionalParams: &OptionalParams{Extended: true, IncludeElements: true},
+					NetworkListID:  params.NetworkListID,
+				},
+				Name:        mutated.Name,
+				Type:        current.Type,
+				Description: mutated.Description,
+				List:        mutated.List,
+				Policy:      params.Policy,
+			},
+			SyncPoint: current.SyncPoint,
+		})
+		if err == nil {
+			return list, nil
+		}
+		if !errors.Is(err, ErrConflict) {
+			return nil, err
+		}
+
+		if attempt > retries {
+			var apiErr *Error
+			errors.As(err, &apiErr)
+			return nil, &ConflictError{
+				NetworkListID: params.NetworkListID,
+				SyncPoint:     current.SyncPoint,
+				Attempts:      attempt,
+				Err:           apiErr,
+			}
+		}
+		logger.Warnf("%[1]s changed since sync point %[2]d, retrying (%[3]d/%[4]d)",
+			params.NetworkListID, current.SyncPoint, attempt, retries)
+	}
+}
+
+// Validate validates MutateNetworkListRequest
+func (v MutateNetworkListRequest) Validate() error {
+	return validation.Errors{
+		"networkListId": validation.Validate(v.NetworkListID, validation.Required),
+		"mutate":        validation.Validate(v.Mutate, validation.NotNil),
+	}.Filter()
+}
@@ -0,0 +1,145 @@
+package netlist_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/akamai-playground/netlist"
+	"github.com/akamai-playground/netlist/fake"
+)
+
+func TestMutateNetworkList(t *testing.T) {
+	addElement := func(list *netlist.NetworkListResponse) error {
+		list.List = append(list.List, "2.2.2.2")
+		return nil
+	}
+
+	tests := map[string]struct {
+		mutate     netlist.MutateFunc
+		maxRetries int
+		conflicts  int
+		fault      error
+		updates    int
+		want       []string
+		err        error
+		attempts   int
+	}{
+		"no conflict": {
+			mutate:  addElement,
+			updates: 1,
+			want:    []string{"1.1.1.1", "2.2.2.2"},
+		},
+		"conflicts within the retries": {
+			mutate:    addElement,
+			conflicts: 3,
+			updates:   4,
+			want:      []string{"1.1.1.1", "2.2.2.2"},
+		},
+		"conflicts exhausting the retries": {
+			mutate:    addElement,
+			conflicts: 4,
+			updates:   4,
+			err:       netlist.ErrConflict,
+			attempts:  4,
+		},
+		"retries disabled": {
+			mutate:     addElement,
+			maxRetries: -1,
+			conflicts:  1,
+			updates:    1,
+			err:        netlist.ErrConflict,
+			attempts:   1,
+		},
+		"other errors are not retried": {
+			mutate:  addElement,
+			fault:   fake.NewError(http.StatusBadRequest, "bad element"),
+			updates: 1,
+			err:     netlist.ErrValidation,
+		},
+		"nothing to update": {
+			mutate: func(list *netlist.NetworkListResponse) error { return nil },
+			want:   []string{"1.1.1.1"},
+		},
+		"mutation failure": {
+			mutate: func(list *netlist.NetworkListResponse) error { return errMutate },
+			err:    errMutate,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := fake.New()
+			l := client.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: "IP", List: []string{"1.1.1.1"}})
+			if test.conflicts > 0 {
+				client.Fail(fake.MethodUpdateNetworkList, fake.NewError(http.StatusConflict, "stale sync point"), test.conflicts)
+			}
+			if test.fault != nil {
+				client.Fail(fake.MethodUpdateNetworkList, test.fault, 1)
+			}
+
+			list, err := netlist.MutateNetworkList(context.Background(), client, netlist.MutateNetworkListRequest{
+				NetworkListID: l.UniqueID,
+				Mutate:        test.mutate,
+				MaxRetries:    test.maxRetries,
+			})
+			if calls := client.Calls(fake.MethodUpdateNetworkList); calls != test.updates {
+				t.Errorf("%d updates, want %d", calls, test.updates)
+			}
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("error = %v, want %v", err, test.err)
+				}
+				var conflict *netlist.ConflictError
+				if errors.As(err, &conflict) != (test.attempts > 0) {
+					t.Fatalf("error = %v, ConflictError expected %t", err, test.attempts > 0)
+				}
+				if conflict != nil && (conflict.Attempts != test.attempts || conflict.Err == nil) {
+					t.Errorf("ConflictError = %+v, want %d attempts", conflict, test.attempts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalElements(list.List, test.want) {
+				t.Errorf("elements = %v, want %v", list.List, test.want)
+			}
+		})
+	}
+}
+
+func TestMutateNetworkListConcurrentChange(t *testing.T) {
+	client := fake.New()
+	l := client.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: "IP", List: []string{"1.1.1.1"}})
+
+	// another client appends an element between the first read and the update
+	attempts := 0
+	list, err := netlist.MutateNetworkList(context.Background(), client, netlist.MutateNetworkListRequest{
+		NetworkListID: l.UniqueID,
+		Mutate: func(list *netlist.NetworkListResponse) error {
+			attempts++
+			if attempts == 1 {
+				if _, err := client.AddElement(context.Background(), netlist.AddElementRequest{
+					NetworkListID: l.UniqueID, Element: "3.3.3.3",
+				}); err != nil {
+					return err
+				}
+			}
+			list.List = append(list.List, "2.2.2.2")
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("%d attempts, want 2", attempts)
+	}
+	if want := []string{"1.1.1.1", "3.3.3.3", "2.2.2.2"}; !equalElements(list.List, want) {
+		t.Errorf("elements = %v, want %v", list.List, want)
+	}
+}
+
+var errMutate = errors.New("mutation failed")
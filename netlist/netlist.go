@@ -3,7 +3,9 @@ package netlist
 
 import (
 	"errors"
+	"net/http"
 
+	"github.com/akamai-playground/tools"
 	"github.com/akamai/AkamaiOPEN-edgegrid-golang/v2/pkg/session"
 )
 
@@ -20,6 +22,8 @@ type (
 
 	netlist struct {
 		session.Session
+		accountSwitchKey string
+		retry            tools.RetryPolicy
 	}
 
 	// Option defines a PAPI option
@@ -40,3 +44,25 @@ func Client(sess session.Session, opts ...Option) NETLIST {
 	}
 	return n
 }
+
+// WithAccountSwitchKey runs every call on behalf of the account of the given key,
+// tools.ContextWithAccountSwitchKey overrides it per call
+func WithAccountSwitchKey(key string) Option {
+	return func(n *netlist) {
+		n.accountSwitchKey = key
+	}
+}
+
+// WithRetries retries rate limited and transiently failing calls according to
+// the policy, see tools.DefaultRetryPolicy
+func WithRetries(policy tools.RetryPolicy) Option {
+	return func(n *netlist) {
+		n.retry = policy
+	}
+}
+
+// Exec overrides the session.Exec to add netlist options
+func (n *netlist) Exec(r *http.Request, out interface{}, in ...interface{}) (*http.Response, error) {
+	tools.CheckAccountID(tools.AccountSwitchKey(r, n.accountSwitchKey), r)
+	return n.retry.Exec(n.Log(r.Context()), n.Session.Exec, r, out, in...)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
@@ -30,13 +31,35 @@ const (
 )
 
 func (n NetworkType) String() string {
-	return [...]string{"IP", "GEO"}[n]
+	return [...]string{"IP", "GEO"}[n-1]
 }
 
 func (e Environment) String() string {
 	return [...]string{"STAGING", "PRODUCTION"}[e-1]
 }
 
+// ParseNetworkType returns the NetworkType matching the given name (case insensitive)
+func ParseNetworkType(name string) (NetworkType, error) {
+	switch strings.ToUpper(name) {
+	case IP.String():
+		return IP, nil
+	case GEO.String():
+		return GEO, nil
+	}
+	return 0, fmt.Errorf("unknown network list type: %q", name)
+}
+
+// ParseEnvironment returns the Environment matching the given name (case insensitive)
+func ParseEnvironment(name string) (Environment, error) {
+	switch strings.ToUpper(name) {
+	case STAGING.String():
+		return STAGING, nil
+	case PRODUCTION.String():
+		return PRODUCTION, nil
+	}
+	return 0, fmt.Errorf("unknown environment: %q", name)
+}
+
 type (
 	// NetworkList contains operations available on NetworkList resource
 	// See: // netlist v2
@@ -78,7 +101,8 @@ type (
 		UniqueID string `json:"uniqueId"`
 	}
 
-	// ListNetworkListsRequest is a wrapper for List call
+	// ListNetworkListsRequest is a wrapper for List call, see FindNetworkLists
+	// and IterateNetworkLists to filter and page the lists
 	ListNetworkListsRequest struct {
 		*OptionalParams
 		ListType NetworkType
@@ -126,6 +150,9 @@ type (
 		List        []string `json:"list"`
 		ContractID  string   `json:"contractId,omitempty"`
 		GroupID     int      `json:"groupId,omitempty"`
+		// Policy tells how IP elements in special purpose ranges are validated,
+		// DefaultElementPolicy is used when nil
+		Policy *ElementPolicy `json:"-"`
 	}
 
 	// CreateNetworkListRequest is a JSON body for creating of a NL
@@ -143,6 +170,7 @@ type (
 	NetworkListResponse struct {
 		Name            string   `json:"name"`
 		UniqueID        string   `json:"uniqueId"`
+		Description     string   `json:"description"`
 		SyncPoint       int      `json:"syncPoint"`
 		Type            string   `json:"type"`
 		NetworkListType string   `json:"networkListType"`
@@ -150,7 +178,12 @@ type (
 		ReadOnly        bool     `json:"readOnly"`
 		Shared          bool     `json:"shared"`
 		List            []string `json:"list"`
-		Links           struct {
+		// CreateDate, CreatedBy, UpdateDate and UpdatedBy are only set on extended responses
+		CreateDate string `json:"createDate,omitempty"`
+		CreatedBy  string `json:"createdBy,omitempty"`
+		UpdateDate string `json:"updateDate,omitempty"`
+		UpdatedBy  string `json:"updatedBy,omitempty"`
+		Links      struct {
 			ActivateInProduction struct {
 				Href   string `json:"href"`
 				Method string `json:"method"`
@@ -184,12 +217,18 @@ type (
 	AppendListRequest struct {
 		List          []string `json:"list"`
 		NetworkListID string
+		// Type, when set, validates the elements against the list type
+		Type   NetworkType    `json:"-"`
+		Policy *ElementPolicy `json:"-"`
 	}
 
 	// AddElementRequest contains an element to add to the list
 	AddElementRequest struct {
 		NetworkListID string
 		Element       string
+		// Type, when set, validates the element against the list type
+		Type   NetworkType
+		Policy *ElementPolicy
 	}
 
 	// RemoveElementRequest contains an element to remove from the list
@@ -267,8 +306,15 @@ func (p *netlist) ListNetworkLists(ctx context.Context, params ListNetworkListsR
 
 	q := req.URL.Query()
 
-	q.Add("extended", strconv.FormatBool(params.Extended))
-	q.Add("includeElements", strconv.FormatBool(params.IncludeElements))
+	// both default to false, elements of every list make the response huge
+	if params.OptionalParams != nil {
+		if params.Extended {
+			q.Add("extended", "true")
+		}
+		if params.IncludeElements {
+			q.Add("includeElements", "true")
+		}
+	}
 
 	if params.Search != "" {
 		q.Add("search", params.Search)
@@ -336,6 +382,7 @@ func (p *netlist) UpdateNetworkList(ctx context.Context, params UpdateNetworkLis
 
 	logger := p.Log(ctx)
 	logger.Debug("UpdateNetworkList")
+	logElementWarnings(logger, params.Type, params.List, params.Policy)
 
 	var rval NetworkListResponse
 
@@ -365,9 +412,13 @@ func (p *netlist) UpdateNetworkList(ctx context.Context, params UpdateNetworkLis
 }
 
 func (p *netlist) CreateNetworkList(ctx context.Context, params CreateNetworkListRequest) (*NetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
 
 	logger := p.Log(ctx)
 	logger.Debug("CreateNetworkList")
+	logElementWarnings(logger, params.Type, params.List, params.Policy)
 
 	var rval NetworkListResponse
 
@@ -427,6 +478,9 @@ func (p *netlist) AppendList(ctx context.Context, params AppendListRequest) (*Ne
 
 	logger := p.Log(ctx)
 	logger.Debug("AppendNetworkList")
+	if params.Type != 0 {
+		logElementWarnings(logger, params.Type.String(), params.List, params.Policy)
+	}
 
 	var rval NetworkListResponse
 
@@ -457,6 +511,9 @@ func (p *netlist) AddElement(ctx context.Context, params AddElementRequest) (*Ne
 
 	logger := p.Log(ctx)
 	logger.Debug("AddElement")
+	if params.Type != 0 {
+		logElementWarnings(logger, params.Type.String(), []string{params.Element}, params.Policy)
+	}
 
 	var rval NetworkListResponse
 
@@ -651,10 +708,30 @@ func (v GetNetworkListRequest) Validate() error {
 	}.Filter()
 }
 
+// Validate validates BodyNetworkListRequest
+func (v BodyNetworkListRequest) Validate() error {
+	return validation.Errors{
+		"name": validation.Validate(v.Name, validation.Required),
+		"type": validation.Validate(v.Type, validation.Required, validation.In(IP.String(), GEO.String())),
+		"list": validateElements(v.Type, v.List, v.Policy),
+	}.Filter()
+}
+
+// Validate validates CreateNetworkListRequest
+func (v CreateNetworkListRequest) Validate() error {
+	if v.BodyNetworkListRequest == nil {
+		return validation.Errors{"body": validation.ErrRequired}
+	}
+	return v.BodyNetworkListRequest.Validate()
+}
+
 // Validate validates UpdateNetworkListRequest
 func (v UpdateNetworkListRequest) Validate() error {
 	return validation.Errors{
 		"networkListId": validation.Validate(v.GetNetworkListRequest.NetworkListID, validation.Required),
+		"name":          validation.Validate(v.Name, validation.Required),
+		"type":          validation.Validate(v.Type, validation.Required, validation.In(IP.String(), GEO.String())),
+		"list":          validateElements(v.Type, v.List, v.Policy),
 	}.Filter()
 }
 
@@ -662,14 +739,19 @@ func (v UpdateNetworkListRequest) Validate() error {
 func (v AppendListRequest) Validate() error {
 	return validation.Errors{
 		"networkListId": validation.Validate(v.NetworkListID, validation.Required),
+		"list":          validateTypedElements(v.Type, v.List, v.Policy),
 	}.Filter()
 }
 
 // Validate validates AddElementRequest
 func (v AddElementRequest) Validate() error {
+	elementErr := validation.Validate(v.Element, validation.Required)
+	if elementErr == nil {
+		elementErr = validateTypedElements(v.Type, []string{v.Element}, v.Policy)
+	}
 	return validation.Errors{
 		"networkListId": validation.Validate(v.NetworkListID, validation.Required),
-		"element":       validation.Validate(v.Element, validation.Required),
+		"element":       elementErr,
 	}.Filter()
 }
 
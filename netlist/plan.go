@@ -0,0 +1,380 @@
+package netlist
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Actions a Plan can describe
+const (
+	// PlanCreate means a new list would be created
+	PlanCreate PlanAction = "create"
+	// PlanUpdate means the list would be replaced through UpdateNetworkList
+	PlanUpdate PlanAction = "update"
+	// PlanAppend means elements would be appended through AppendList
+	PlanAppend PlanAction = "append"
+	// PlanAdd means a single element would be added
+	PlanAdd PlanAction = "add"
+	// PlanRemove means a single element would be removed
+	PlanRemove PlanAction = "remove"
+	// PlanDelete means the list would be deleted
+	PlanDelete PlanAction = "delete"
+	// PlanActivate means the list would be activated
+	PlanActivate PlanAction = "activate"
+	// PlanDetails means only the name and the description would change
+	PlanDetails PlanAction = "details"
+	// PlanSync means the list would be reconciled by SyncNetworkList using Strategy
+	PlanSync PlanAction = "sync"
+	// PlanRestore means the elements would be replaced by those of an older sync point
+	PlanRestore PlanAction = "restore"
+)
+
+type (
+	// PlanAction describes the call a Plan was computed for
+	PlanAction string
+
+	// Plan describes what a mutating call would change on a network list
+	// without executing it
+	Plan struct {
+		Action        PlanAction   `json:"action"`
+		Strategy      SyncStrategy `json:"strategy,omitempty"`
+		NetworkListID string       `json:"networkListId,omitempty"`
+		Type          string       `json:"type,omitempty"`
+		SyncPoint     int          `json:"syncPoint"`
+		Name          *FieldChange `json:"name,omitempty"`
+		Description   *FieldChange `json:"description,omitempty"`
+		ElementsDiff
+		// ElementCount is the number of elements the list holds today
+		ElementCount int                 `json:"elementCount"`
+		Activations  []PlannedActivation `json:"activations,omitempty"`
+	}
+
+	// FieldChange holds the current and the planned value of a field
+	FieldChange struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+
+	// PlannedActivation describes an activation a Plan would trigger
+	PlannedActivation struct {
+		Environment      string `json:"environment"`
+		CurrentStatus    string `json:"currentStatus,omitempty"`
+		CurrentSyncPoint int    `json:"currentSyncPoint"`
+		TargetSyncPoint  int    `json:"targetSyncPoint"`
+	}
+)
+
+// HasChanges reports whether applying the plan would change anything
+func (p *Plan) HasChanges() bool {
+	switch {
+	case p.Action == PlanCreate, p.Action == PlanDelete:
+		return true
+	case p.Action == PlanSync && p.Strategy == SyncCreate:
+		return true
+	}
+	return !p.Empty() || p.Name != nil || p.Description != nil || len(p.Activations) > 0
+}
+
+// String renders the plan for humans, one change per line
+func (p *Plan) String() string {
+	var b bytes.Buffer
+
+	verbs := map[PlanAction]string{
+		PlanCreate:   "will be created",
+		PlanUpdate:   "will be replaced",
+		PlanAppend:   "will be appended to",
+		PlanAdd:      "will get a new element",
+		PlanRemove:   "will lose an element",
+		PlanDelete:   "will be deleted",
+		PlanActivate: "will be activated",
+		PlanDetails:  "will be renamed",
+		PlanSync:     fmt.Sprintf("will be synced using %s", p.Strategy),
+		PlanRestore:  "will be restored",
+	}
+	id := p.NetworkListID
+	if id == "" {
+		id = "(new)"
+	}
+	fmt.Fprintf(&b, "network list %s (%s, sync point %d) %s\n", id, p.Type, p.SyncPoint, verbs[p.Action])
+
+	if p.Name != nil {
+		fmt.Fprintf(&b, "  ~ name: %q -> %q\n", p.Name.From, p.Name.To)
+	}
+	if p.Description != nil {
+		fmt.Fprintf(&b, "  ~ description: %q -> %q\n", p.Description.From, p.Description.To)
+	}
+	for _, e := range p.Added {
+		fmt.Fprintf(&b, "  + %s\n", e)
+	}
+	for _, e := range p.Removed {
+		fmt.Fprintf(&b, "  - %s\n", e)
+	}
+	for _, a := range p.Activations {
+		current := a.CurrentStatus
+		if current == "" {
+			current = "unknown"
+		}
+		fmt.Fprintf(&b, "  > activate on %s: %s at sync point %d -> sync point %d\n",
+			a.Environment, current, a.CurrentSyncPoint, a.TargetSyncPoint)
+	}
+
+	fmt.Fprintf(&b, "Plan: %d to add, %d to remove, %d of %d elements kept",
+		len(p.Added), len(p.Removed), p.ElementCount-len(p.Removed), p.ElementCount)
+	if len(p.Activations) > 0 {
+		fmt.Fprintf(&b, ", %d activations", len(p.Activations))
+	}
+	b.WriteString(".\n")
+	return b.String()
+}
+
+// PlanCreateNetworkList describes the list CreateNetworkList would create
+func PlanCreateNetworkList(_ context.Context, _ NetworkList, params CreateNetworkListRequest) (*Plan, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	return &Plan{
+		Action:       PlanCreate,
+		Type:         params.Type,
+		Name:         changeOf("", params.Name),
+		Description:  changeOf("", params.Description),
+		ElementsDiff: DiffElements(nil, params.List),
+	}, nil
+}
+
+// PlanUpdateNetworkList describes what UpdateNetworkList would change
+func PlanUpdateNetworkList(ctx context.Context, client NetworkList, params UpdateNetworkListRequest) (*Plan, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	current, err := fetchForPlan(ctx, client, params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := newPlan(PlanUpdate, current)
+	plan.ElementsDiff = DiffElements(current.List, params.List)
+	plan.Name = changeOf(current.Name, params.Name)
+	plan.Description = changeOf(current.Description, params.Description)
+	return plan, nil
+}
+
+// PlanAppendList describes what AppendList would add
+func PlanAppendList(ctx context.Context, client NetworkList, params AppendListRequest) (*Plan, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	current, err := fetchForPlan(ctx, client, params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := newPlan(PlanAppend, current)
+	plan.Added = DiffElements(current.List, append(append([]string{}, current.List...), params.List...)).Added
+	return plan, nil
+}
+
+// PlanAddElement describes what AddElement would add
+func PlanAddElement(ctx context.Context, client NetworkList, params AddElementRequest) (*Plan, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	current, err := fetchForPlan(ctx, client, params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := newPlan(PlanAdd, current)
+	plan.Added = DiffElements(current.List, append(append([]string{}, current.List...), params.Element)).Added
+	return plan, nil
+}
+
+// PlanRemoveElement describes what RemoveElement would remove
+func PlanRemoveElement(ctx context.Context, client NetworkList, params RemoveElementRequest) (*Plan, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	current, err := fetchForPlan(ctx, client, params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := newPlan(PlanRemove, current)
+	for _, e := range current.List {
+		if strings.TrimSpace(e) == strings.TrimSpace(params.Element) {
+			plan.Removed = append(plan.Removed, e)
+		}
+	}
+	return plan, nil
+}
+
+// PlanDeleteNetworkList describes the list DeleteNetworkList would delete
+func PlanDeleteNetworkList(ctx context.Context, client NetworkList, params DeleteNetworkListRequest) (*Plan, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	current, err := fetchForPlan(ctx, client, params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := newPlan(PlanDelete, current)
+	plan.Removed = current.List
+	return plan, nil
+}
+
+// PlanUpdateNetworkListDetails describes what UpdateNetworkListDetails would change
+func PlanUpdateNetworkListDetails(ctx context.Context, client NetworkList, params UpdateNetworkListDetailsRequest) (*Plan, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	current, err := fetchForPlan(ctx, client, params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := newPlan(PlanDetails, current)
+	plan.Name = changeOf(current.Name, params.Name)
+	plan.Description = changeOf(current.Description, params.Description)
+	return plan, nil
+}
+
+// PlanActivateNetworkList describes the activation ActivateNetworkList would trigger
+func PlanActivateNetworkList(ctx context.Context, client NetworkList, params ActivateNetworkListRequest) (*Plan, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	current, err := fetchForPlan(ctx, client, params.NetworkListID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := newPlan(PlanActivate, current)
+	activation, err := planActivation(ctx, client, current.UniqueID, params.Environment, current.SyncPoint)
+	if err != nil {
+		return nil, err
+	}
+	plan.Activations = append(plan.Activations, *activation)
+	return plan, nil
+}
+
+// PlanSyncNetworkList describes how SyncNetworkList would reconcile the list
+func PlanSyncNetworkList(ctx context.Context, client NetworkList, params SyncNetworkListRequest) (*Plan, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+	if err := params.normalize(); err != nil {
+		return nil, err
+	}
+
+	desired := params.Desired
+	current, err := findNetworkList(ctx, client, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	if current == nil {
+		plan, err := PlanCreateNetworkList(ctx, client, CreateNetworkListRequest{
+			BodyNetworkListRequest: &BodyNetworkListRequest{
+				Name:        desired.Name,
+				Type:        strings.ToUpper(desired.Type),
+				Description: desired.Description,
+				List:        desired.List,
+				Policy:      params.Policy,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		plan.Action, plan.Strategy = PlanSync, SyncCreate
+		for _, env := range params.Activate {
+			plan.Activations = append(plan.Activations, PlannedActivation{Environment: env.String()})
+		}
+		return plan, nil
+	}
+
+	if !strings.EqualFold(current.Type, desired.Type) {
+		return nil, fmt.Errorf("%w: %s is %s, desired %s", ErrTypeMismatch, current.UniqueID, current.Type, desired.Type)
+	}
+
+	if err := validateElements(current.Type, desired.List, params.Policy); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	plan := newPlan(PlanSync, current)
+	plan.ElementsDiff = DiffElements(current.List, desired.List)
+	plan.Name = changeOf(current.Name, desired.Name)
+	plan.Description = changeOf(current.Description, desired.Description)
+	plan.Strategy = syncStrategy(plan.ElementsDiff, plan.Name != nil || plan.Description != nil, params.ElementCallsLimit)
+
+	// every call made by the sync bumps the sync point once
+	target := current.SyncPoint
+	switch plan.Strategy {
+	case SyncElements:
+		target += len(plan.Added) + len(plan.Removed)
+	case SyncAppend, SyncUpdate, SyncDetails:
+		target++
+	}
+	for _, env := range params.Activate {
+		activation, err := planActivation(ctx, client, current.UniqueID, env, target)
+		if err != nil {
+			return nil, err
+		}
+		plan.Activations = append(plan.Activations, *activation)
+	}
+	return plan, nil
+}
+
+func fetchForPlan(ctx context.Context, client NetworkList, listID string) (*NetworkListResponse, error) {
+	return client.GetNetworkList(ctx, GetNetworkListRequest{
+		OptionalParams: &OptionalParams{
+			Extended:        true,
+			IncludeElements: true,
+		},
+		NetworkListID: listID,
+	})
+}
+
+func planActivation(ctx context.Context, client NetworkList, listID string, env Environment, target int) (*PlannedActivation, error) {
+	status, err := client.GetActivationNetworkList(ctx, ActivateNetworkListRequest{
+		NetworkListID: listID,
+		Environment:   env,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PlannedActivation{
+		Environment:      env.String(),
+		CurrentStatus:    status.ActivationStatus,
+		CurrentSyncPoint: status.SyncPoint,
+		TargetSyncPoint:  target,
+	}, nil
+}
+
+func newPlan(action PlanAction, current *NetworkListResponse) *Plan {
+	return &Plan{
+		Action:        action,
+		NetworkListID: current.UniqueID,
+		Type:          current.Type,
+		SyncPoint:     current.SyncPoint,
+		ElementCount:  len(current.List),
+	}
+}
+
+// changeOf returns nil when the value does not change
+func changeOf(from, to string) *FieldChange {
+	if from == to {
+		return nil
+	}
+	return &FieldChange{From: from, To: to}
+}
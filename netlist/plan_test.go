@@ -0,0 +1,210 @@
+package netlist_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akamai-playground/netlist"
+	"github.com/akamai-playground/netlist/fake"
+)
+
+func TestPlans(t *testing.T) {
+	const id = "1001_BLOCKED"
+
+	tests := map[string]struct {
+		plan        func(context.Context, netlist.NetworkList) (*netlist.Plan, error)
+		action      netlist.PlanAction
+		strategy    netlist.SyncStrategy
+		diff        netlist.ElementsDiff
+		name        *netlist.FieldChange
+		activations int
+		changes     bool
+	}{
+		"create": {
+			plan: func(ctx context.Context, c netlist.NetworkList) (*netlist.Plan, error) {
+				return netlist.PlanCreateNetworkList(ctx, c, netlist.CreateNetworkListRequest{
+					BodyNetworkListRequest: &netlist.BodyNetworkListRequest{Name: "New", Type: "IP", List: []string{"4.4.4.4"}},
+				})
+			},
+			action:  netlist.PlanCreate,
+			diff:    netlist.ElementsDiff{Added: []string{"4.4.4.4"}},
+			name:    &netlist.FieldChange{To: "New"},
+			changes: true,
+		},
+		"update": {
+			plan: func(ctx context.Context, c netlist.NetworkList) (*netlist.Plan, error) {
+				return netlist.PlanUpdateNetworkList(ctx, c, netlist.UpdateNetworkListRequest{
+					BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+						GetNetworkListRequest: &netlist.GetNetworkListRequest{NetworkListID: id},
+						Name:                  "Blocked", Type: "IP", List: []string{"1.1.1.1", "4.4.4.4"},
+					},
+				})
+			},
+			action:  netlist.PlanUpdate,
+			diff:    netlist.ElementsDiff{Added: []string{"4.4.4.4"}, Removed: []string{"2.2.2.2"}},
+			changes: true,
+		},
+		"append of known elements": {
+			plan: func(ctx context.Context, c netlist.NetworkList) (*netlist.Plan, error) {
+				return netlist.PlanAppendList(ctx, c, netlist.AppendListRequest{NetworkListID: id, List: []string{"1.1.1.1"}})
+			},
+			action: netlist.PlanAppend,
+		},
+		"append": {
+			plan: func(ctx context.Context, c netlist.NetworkList) (*netlist.Plan, error) {
+				return netlist.PlanAppendList(ctx, c, netlist.AppendListRequest{NetworkListID: id, List: []string{"1.1.1.1", "4.4.4.4"}})
+			},
+			action:  netlist.PlanAppend,
+			diff:    netlist.ElementsDiff{Added: []string{"4.4.4.4"}},
+			changes: true,
+		},
+		"remove": {
+			plan: func(ctx context.Context, c netlist.NetworkList) (*netlist.Plan, error) {
+				return netlist.PlanRemoveElement(ctx, c, netlist.RemoveElementRequest{
+					AddElementRequest: &netlist.AddElementRequest{NetworkListID: id, Element: "2.2.2.2"},
+				})
+			},
+			action:  netlist.PlanRemove,
+			diff:    netlist.ElementsDiff{Removed: []string{"2.2.2.2"}},
+			changes: true,
+		},
+		"delete": {
+			plan: func(ctx context.Context, c netlist.NetworkList) (*netlist.Plan, error) {
+				return netlist.PlanDeleteNetworkList(ctx, c, netlist.DeleteNetworkListRequest{
+					GetNetworkListRequest: &netlist.GetNetworkListRequest{NetworkListID: id},
+				})
+			},
+			action:  netlist.PlanDelete,
+			diff:    netlist.ElementsDiff{Removed: []string{"1.1.1.1", "2.2.2.2"}},
+			changes: true,
+		},
+		"rename": {
+			plan: func(ctx context.Context, c netlist.NetworkList) (*netlist.Plan, error) {
+				return netlist.PlanUpdateNetworkListDetails(ctx, c, netlist.UpdateNetworkListDetailsRequest{NetworkListID: id, Name: "Renamed", Description: "blocked"})
+			},
+			action:  netlist.PlanDetails,
+			name:    &netlist.FieldChange{From: "Blocked", To: "Renamed"},
+			changes: true,
+		},
+		"activate": {
+			plan: func(ctx context.Context, c netlist.NetworkList) (*netlist.Plan, error) {
+				return netlist.PlanActivateNetworkList(ctx, c, netlist.ActivateNetworkListRequest{NetworkListID: id, Environment: netlist.STAGING})
+			},
+			action:      netlist.PlanActivate,
+			activations: 1,
+			changes:     true,
+		},
+		"sync": {
+			plan: func(ctx context.Context, c netlist.NetworkList) (*netlist.Plan, error) {
+				return netlist.PlanSyncNetworkList(ctx, c, netlist.SyncNetworkListRequest{
+					Desired:  netlist.DesiredNetworkList{Name: "Blocked", Type: "IP", List: []string{"1.1.1.1", "3.3.3.3"}},
+					Activate: []netlist.Environment{netlist.PRODUCTION},
+				})
+			},
+			action:      netlist.PlanSync,
+			strategy:    netlist.SyncElements,
+			diff:        netlist.ElementsDiff{Added: []string{"3.3.3.3"}, Removed: []string{"2.2.2.2"}},
+			activations: 1,
+			changes:     true,
+		},
+		"sync of a missing list": {
+			plan: func(ctx context.Context, c netlist.NetworkList) (*netlist.Plan, error) {
+				return netlist.PlanSyncNetworkList(ctx, c, netlist.SyncNetworkListRequest{
+					Desired: netlist.DesiredNetworkList{Name: "Other", Type: "GEO"},
+				})
+			},
+			action:   netlist.PlanSync,
+			strategy: netlist.SyncCreate,
+			name:     &netlist.FieldChange{To: "Other"},
+			changes:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := fake.New()
+			client.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: "IP", List: []string{"1.1.1.1", "2.2.2.2"}, Description: "blocked", SyncPoint: 2})
+
+			plan, err := test.plan(context.Background(), client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plan.Action != test.action || plan.Strategy != test.strategy {
+				t.Errorf("plan %s/%s, want %s/%s", plan.Action, plan.Strategy, test.action, test.strategy)
+			}
+			if !reflect.DeepEqual(plan.ElementsDiff, test.diff) {
+				t.Errorf("diff = %+v, want %+v", plan.ElementsDiff, test.diff)
+			}
+			if !reflect.DeepEqual(plan.Name, test.name) {
+				t.Errorf("name = %+v, want %+v", plan.Name, test.name)
+			}
+			if len(plan.Activations) != test.activations {
+				t.Errorf("%d activations, want %d", len(plan.Activations), test.activations)
+			}
+			if plan.HasChanges() != test.changes {
+				t.Errorf("HasChanges() = %t, want %t", plan.HasChanges(), test.changes)
+			}
+
+			for _, method := range []string{fake.MethodCreateNetworkList, fake.MethodUpdateNetworkList, fake.MethodAppendList,
+				fake.MethodAddElement, fake.MethodRemoveElement, fake.MethodDeleteNetworkList,
+				fake.MethodActivateNetworkList, fake.MethodUpdateNetworkListDetails} {
+				if calls := client.Calls(method); calls != 0 {
+					t.Errorf("planning called %s %d times", method, calls)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanSyncTargetSyncPoint(t *testing.T) {
+	client := fake.New()
+	client.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: "IP", List: []string{"1.1.1.1", "2.2.2.2"}, SyncPoint: 2})
+
+	desired := netlist.DesiredNetworkList{Name: "Blocked", Type: "IP", List: []string{"1.1.1.1", "3.3.3.3", "4.4.4.4"}}
+	plan, err := netlist.PlanSyncNetworkList(context.Background(), client, netlist.SyncNetworkListRequest{
+		Desired:  desired,
+		Activate: []netlist.Environment{netlist.STAGING},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := netlist.SyncNetworkList(context.Background(), client, netlist.SyncNetworkListRequest{Desired: desired})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target := plan.Activations[0].TargetSyncPoint; target != res.List.SyncPoint {
+		t.Errorf("planned sync point %d, synced to %d", target, res.List.SyncPoint)
+	}
+}
+
+func TestPlanString(t *testing.T) {
+	plan := &netlist.Plan{
+		Action:        netlist.PlanSync,
+		Strategy:      netlist.SyncElements,
+		NetworkListID: "1001_BLOCKED",
+		Type:          "IP",
+		SyncPoint:     2,
+		Description:   &netlist.FieldChange{From: "old", To: "new"},
+		ElementsDiff:  netlist.ElementsDiff{Added: []string{"3.3.3.3"}, Removed: []string{"2.2.2.2"}},
+		ElementCount:  2,
+		Activations: []netlist.PlannedActivation{
+			{Environment: "STAGING", CurrentStatus: netlist.StatusActive, CurrentSyncPoint: 2, TargetSyncPoint: 4},
+		},
+	}
+
+	want := strings.Join([]string{
+		"network list 1001_BLOCKED (IP, sync point 2) will be synced using elements",
+		`  ~ description: "old" -> "new"`,
+		"  + 3.3.3.3",
+		"  - 2.2.2.2",
+		"  > activate on STAGING: ACTIVE at sync point 2 -> sync point 4",
+		"Plan: 1 to add, 1 to remove, 1 of 2 elements kept, 1 activations.",
+		"",
+	}, "\n")
+	if got := plan.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,375 @@
+package netlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Strategies used by SyncNetworkList to reconcile a list
+const (
+	// SyncNone means the live list already matches the desired state
+	SyncNone SyncStrategy = "none"
+	// SyncCreate means the list did not exist and was created
+	SyncCreate SyncStrategy = "create"
+	// SyncAppend means elements were only added, through AddElement or AppendList
+	SyncAppend SyncStrategy = "append"
+	// SyncElements means elements were added and removed one by one
+	SyncElements SyncStrategy = "elements"
+	// SyncUpdate means the whole list was replaced through UpdateNetworkList
+	SyncUpdate SyncStrategy = "update"
+	// SyncDetails means only the name or the description changed
+	SyncDetails SyncStrategy = "details"
+)
+
+const defaultElementCallsLimit = 10
+
+var (
+	// ErrAmbiguousList is returned when a list name matches several network lists
+	ErrAmbiguousList = errors.New("ambiguous network list name")
+
+	// ErrTypeMismatch is returned when the desired type differs from the live list type
+	ErrTypeMismatch = errors.New("network list type mismatch")
+)
+
+type (
+	// SyncStrategy describes which calls were used to reconcile a list
+	SyncStrategy string
+
+	// DesiredNetworkList is the desired state of a network list.
+	// The live list is looked up by UniqueID or, when empty, by its exact name
+	DesiredNetworkList struct {
+		UniqueID    string   `json:"uniqueId,omitempty"`
+		Name        string   `json:"name"`
+		Type        string   `json:"type"`
+		Description string   `json:"description"`
+		List        []string `json:"list"`
+		ContractID  string   `json:"contractId,omitempty"`
+		GroupID     int      `json:"groupId,omitempty"`
+	}
+
+	// SyncNetworkListRequest is a wrapper for SyncNetworkList call
+	SyncNetworkListRequest struct {
+		Desired DesiredNetworkList
+		// ElementCallsLimit is the maximum number of AddElement and RemoveElement
+		// calls made when elements are removed, bigger changes are submitted
+		// as a single UpdateNetworkList call
+		ElementCallsLimit int
+		// Activate lists the environments to activate the list on once reconciled
+		Activate               []Environment
+		Comments               string
+		NotificationRecipients []string
+		// Wait blocks until every activation reaches a terminal status
+		Wait bool
+		// NormalizeIP canonicalizes and deduplicates the elements of an IP list
+		// before reconciling, AggregateIP merges adjacent prefixes as well
+		NormalizeIP bool
+		AggregateIP bool
+		// Policy tells how IP elements in special purpose ranges are validated
+		Policy *ElementPolicy
+	}
+
+	// ElementsDiff lists the elements to add and to remove from a list
+	ElementsDiff struct {
+		Added   []string `json:"added"`
+		Removed []string `json:"removed"`
+	}
+
+	// SyncNetworkListResponse represents the outcome of a sync
+	SyncNetworkListResponse struct {
+		ElementsDiff
+		Strategy    SyncStrategy
+		List        *NetworkListResponse
+		Activations []*ActivationNetworkListResponse
+	}
+)
+
+// Empty reports whether the diff holds no change
+func (d ElementsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// DiffElements returns the elements of desired missing from current
+// and the elements of current missing from desired, in their original order
+func DiffElements(current, desired []string) ElementsDiff {
+	var diff ElementsDiff
+
+	currentSet := make(map[string]struct{}, len(current))
+	for _, e := range current {
+		currentSet[strings.TrimSpace(e)] = struct{}{}
+	}
+	desiredSet := make(map[string]struct{}, len(desired))
+	for _, e := range desired {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if _, ok := desiredSet[e]; ok {
+			continue
+		}
+		desiredSet[e] = struct{}{}
+		if _, ok := currentSet[e]; !ok {
+			diff.Added = append(diff.Added, e)
+		}
+	}
+	for _, e := range current {
+		if _, ok := desiredSet[strings.TrimSpace(e)]; !ok {
+			diff.Removed = append(diff.Removed, e)
+		}
+	}
+	return diff
+}
+
+// SyncNetworkList reconciles a live network list with the desired state.
+// Missing lists are created, additions are appended, small changes are applied
+// element by element and anything bigger replaces the list guarded by its current
+// SyncPoint, so concurrent edits made by others are never silently overwritten.
+func SyncNetworkList(ctx context.Context, client NetworkList, params SyncNetworkListRequest) (*SyncNetworkListResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	logger := loggerFor(ctx, client)
+	logger.Debug("SyncNetworkList")
+
+	if err := params.normalize(); err != nil {
+		return nil, err
+	}
+	desired := params.Desired
+	current, err := findNetworkList(ctx, client, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	var rval *SyncNetworkListResponse
+	if current == nil {
+		rval, err = createDesired(ctx, client, desired, params.Policy)
+	} else {
+		rval, err = reconcile(ctx, client, current, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("%[1]s synced using %[2]s: %[3]d added, %[4]d removed",
+		rval.List.UniqueID, rval.Strategy, len(rval.Added), len(rval.Removed))
+
+	for _, env := range params.Activate {
+		activation, err := client.ActivateNetworkList(ctx, ActivateNetworkListRequest{
+			NetworkListID:          rval.List.UniqueID,
+			Environment:            env,
+			Comments:               params.Comments,
+			NotificationRecipients: params.NotificationRecipients,
+		})
+		if err != nil {
+			return rval, err
+		}
+		if params.Wait {
+			activation, err = WaitForActivation(ctx, client, WaitForActivationRequest{
+				NetworkListID: rval.List.UniqueID,
+				Environment:   env,
+				SyncPoint:     activation.SyncPoint,
+			})
+			if err != nil {
+				return rval, err
+			}
+		}
+		rval.Activations = append(rval.Activations, activation)
+	}
+
+	return rval, nil
+}
+
+// findNetworkList fetches the live list matching desired, nil when there is none
+func findNetworkList(ctx context.Context, client NetworkList, desired DesiredNetworkList) (*NetworkListResponse, error) {
+	id := desired.UniqueID
+	if id == "" {
+		listType, _ := ParseNetworkType(desired.Type)
+		lists, err := client.ListNetworkLists(ctx, ListNetworkListsRequest{
+			OptionalParams: &OptionalParams{},
+			ListType:       listType,
+			Search:         desired.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range lists.NetworkLists {
+			if l.NetworkListResponse == nil || l.Name != desired.Name {
+				continue
+			}
+			if id != "" {
+				return nil, fmt.Errorf("%w: %q", ErrAmbiguousList, desired.Name)
+			}
+			id = l.UniqueID
+		}
+		if id == "" {
+			return nil, nil
+		}
+	}
+
+	return client.GetNetworkList(ctx, GetNetworkListRequest{
+		OptionalParams: &OptionalParams{
+			Extended:        true,
+			IncludeElements: true,
+		},
+		NetworkListID: id,
+	})
+}
+
+func createDesired(ctx context.Context, client NetworkList, desired DesiredNetworkList, policy *ElementPolicy) (*SyncNetworkListResponse, error) {
+	diff := DiffElements(nil, desired.List)
+
+	list, err := client.CreateNetworkList(ctx, CreateNetworkListRequest{
+		BodyNetworkListRequest: &BodyNetworkListRequest{
+			Name:        desired.Name,
+			Type:        strings.ToUpper(desired.Type),
+			Description: desired.Description,
+			List:        diff.Added,
+			ContractID:  desired.ContractID,
+			GroupID:     desired.GroupID,
+			Policy:      policy,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SyncNetworkListResponse{ElementsDiff: diff, Strategy: SyncCreate, List: list}, nil
+}
+
+func reconcile(ctx context.Context, client NetworkList, current *NetworkListResponse, params SyncNetworkListRequest) (*SyncNetworkListResponse, error) {
+	desired := params.Desired
+	if !strings.EqualFold(current.Type, desired.Type) {
+		return nil, fmt.Errorf("%w: %s is %s, desired %s", ErrTypeMismatch, current.UniqueID, current.Type, desired.Type)
+	}
+	if err := validateElements(current.Type, desired.List, params.Policy); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+
+	rval := &SyncNetworkListResponse{
+		ElementsDiff: DiffElements(current.List, desired.List),
+		List:         current,
+	}
+	detailsChanged := current.Name != desired.Name || current.Description != desired.Description
+	rval.Strategy = syncStrategy(rval.ElementsDiff, detailsChanged, params.ElementCallsLimit)
+
+	var err error
+	switch rval.Strategy {
+	case SyncAppend:
+		if len(rval.Added) == 1 {
+			rval.List, err = client.AddElement(ctx, AddElementRequest{
+				NetworkListID: current.UniqueID,
+				Element:       rval.Added[0],
+			})
+			break
+		}
+		rval.List, err = client.AppendList(ctx, AppendListRequest{
+			NetworkListID: current.UniqueID,
+			List:          rval.Added,
+		})
+	case SyncElements:
+		rval.List, err = applyElements(ctx, client, current.UniqueID, rval.ElementsDiff)
+	case SyncUpdate:
+		rval.List, err = client.UpdateNetworkList(ctx, UpdateNetworkListRequest{
+			BodyNetworkListRequest: &BodyNetworkListRequest{
+				GetNetworkListRequest: &GetNetworkListRequest{
+					OptionalParams: &OptionalParams{
+						Extended:        true,
+						IncludeElements: true,
+					},
+					NetworkListID: current.UniqueID,
+				},
+				Name:        desired.Name,
+				Type:        current.Type,
+				Description: desired.Description,
+				List:        DiffElements(nil, desired.List).Added,
+				Policy:      params.Policy,
+			},
+			SyncPoint: current.SyncPoint,
+		})
+		detailsChanged = false
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if detailsChanged {
+		err := client.UpdateNetworkListDetails(ctx, UpdateNetworkListDetailsRequest{
+			NetworkListID: current.UniqueID,
+			Name:          desired.Name,
+			Description:   desired.Description,
+		})
+		if err != nil {
+			return nil, err
+		}
+		rval.List.Name = desired.Name
+		rval.List.Description = desired.Description
+	}
+
+	return rval, nil
+}
+
+// syncStrategy picks the calls reconciling a list given its elements diff
+func syncStrategy(diff ElementsDiff, detailsChanged bool, elementCallsLimit int) SyncStrategy {
+	if elementCallsLimit <= 0 {
+		elementCallsLimit = defaultElementCallsLimit
+	}
+
+	switch {
+	case diff.Empty() && detailsChanged:
+		return SyncDetails
+	case diff.Empty():
+		return SyncNone
+	case len(diff.Removed) == 0:
+		return SyncAppend
+	case len(diff.Added)+len(diff.Removed) <= elementCallsLimit:
+		return SyncElements
+	}
+	return SyncUpdate
+}
+
+func applyElements(ctx context.Context, client NetworkList, listID string, diff ElementsDiff) (*NetworkListResponse, error) {
+	var list *NetworkListResponse
+	var err error
+
+	for _, e := range diff.Added {
+		list, err = client.AddElement(ctx, AddElementRequest{NetworkListID: listID, Element: e})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range diff.Removed {
+		list, err = client.RemoveElement(ctx, RemoveElementRequest{
+			AddElementRequest: &AddElementRequest{NetworkListID: listID, Element: e},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// normalize applies the requested IP normalization to the desired list
+func (v *SyncNetworkListRequest) normalize() error {
+	if !strings.EqualFold(v.Desired.Type, IP.String()) || !(v.NormalizeIP || v.AggregateIP) {
+		return nil
+	}
+
+	list, err := NormalizeIPElements(v.Desired.List, v.AggregateIP)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrStructValidation, err.Error())
+	}
+	v.Desired.List = list
+	return nil
+}
+
+// Validate validates SyncNetworkListRequest
+func (v SyncNetworkListRequest) Validate() error {
+	return validation.Errors{
+		"name": validation.Validate(v.Desired.Name, validation.Required),
+		"type": validation.Validate(strings.ToUpper(v.Desired.Type), validation.Required,
+			validation.In(IP.String(), GEO.String())),
+	}.Filter()
+}
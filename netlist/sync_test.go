@@ -0,0 +1,206 @@
+package netlist_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/akamai-playground/netlist"
+	"github.com/akamai-playground/netlist/fake"
+)
+
+func TestDiffElements(t *testing.T) {
+	tests := map[string]struct {
+		current []string
+		desired []string
+		want    netlist.ElementsDiff
+	}{
+		"same elements": {
+			current: []string{"1.1.1.1", "2.2.2.2"},
+			desired: []string{"2.2.2.2", "1.1.1.1"},
+		},
+		"added and removed in their original order": {
+			current: []string{"1.1.1.1", "3.3.3.3", "2.2.2.2"},
+			desired: []string{"5.5.5.5", "1.1.1.1", "4.4.4.4"},
+			want: netlist.ElementsDiff{
+				Added:   []string{"5.5.5.5", "4.4.4.4"},
+				Removed: []string{"3.3.3.3", "2.2.2.2"},
+			},
+		},
+		"blanks and duplicates are ignored": {
+			current: []string{" 1.1.1.1"},
+			desired: []string{"1.1.1.1 ", "", "2.2.2.2", "2.2.2.2"},
+			want:    netlist.ElementsDiff{Added: []string{"2.2.2.2"}},
+		},
+		"new list": {
+			desired: []string{"FR", "US"},
+			want:    netlist.ElementsDiff{Added: []string{"FR", "US"}},
+		},
+		"emptied list": {
+			current: []string{"FR", "US"},
+			want:    netlist.ElementsDiff{Removed: []string{"FR", "US"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := netlist.DiffElements(test.current, test.desired)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("DiffElements() = %+v, want %+v", got, test.want)
+			}
+			if got.Empty() != (len(test.want.Added) == 0 && len(test.want.Removed) == 0) {
+				t.Errorf("Empty() = %t", got.Empty())
+			}
+		})
+	}
+}
+
+func TestSyncNetworkList(t *testing.T) {
+	current := []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"}
+
+	tests := map[string]struct {
+		desired  netlist.DesiredNetworkList
+		limit    int
+		strategy netlist.SyncStrategy
+		calls    map[string]int
+		want     []string
+		err      error
+	}{
+		"unchanged": {
+			desired:  netlist.DesiredNetworkList{Name: "Blocked", Type: "IP", List: []string{"3.3.3.3", "1.1.1.1", "2.2.2.2"}},
+			strategy: netlist.SyncNone,
+			want:     current,
+		},
+		"missing list is created": {
+			desired:  netlist.DesiredNetworkList{Name: "Other", Type: "IP", List: []string{"4.4.4.4"}},
+			strategy: netlist.SyncCreate,
+			calls:    map[string]int{fake.MethodCreateNetworkList: 1},
+			want:     []string{"4.4.4.4"},
+		},
+		"one addition": {
+			desired:  netlist.DesiredNetworkList{Name: "Blocked", Type: "IP", List: append(current, "4.4.4.4")},
+			strategy: netlist.SyncAppend,
+			calls:    map[string]int{fake.MethodAddElement: 1},
+			want:     append(current, "4.4.4.4"),
+		},
+		"several additions": {
+			desired:  netlist.DesiredNetworkList{Name: "Blocked", Type: "IP", List: append(current, "4.4.4.4", "5.5.5.5")},
+			strategy: netlist.SyncAppend,
+			calls:    map[string]int{fake.MethodAppendList: 1},
+			want:     append(current, "4.4.4.4", "5.5.5.5"),
+		},
+		"small change": {
+			desired:  netlist.DesiredNetworkList{Name: "Blocked", Type: "IP", List: []string{"1.1.1.1", "4.4.4.4"}},
+			strategy: netlist.SyncElements,
+			calls:    map[string]int{fake.MethodAddElement: 1, fake.MethodRemoveElement: 2},
+			want:     []string{"1.1.1.1", "4.4.4.4"},
+		},
+		"change above the element calls limit": {
+			desired:  netlist.DesiredNetworkList{Name: "Blocked", Type: "IP", List: []string{"1.1.1.1", "4.4.4.4"}},
+			limit:    2,
+			strategy: netlist.SyncUpdate,
+			calls:    map[string]int{fake.MethodUpdateNetworkList: 1},
+			want:     []string{"1.1.1.1", "4.4.4.4"},
+		},
+		"description only": {
+			desired:  netlist.DesiredNetworkList{Name: "Blocked", Type: "IP", Description: "from git", List: current},
+			strategy: netlist.SyncDetails,
+			calls:    map[string]int{fake.MethodUpdateNetworkListDetails: 1},
+			want:     current,
+		},
+		"looked up by id": {
+			desired:  netlist.DesiredNetworkList{UniqueID: "1001_BLOCKED", Name: "Renamed", Type: "IP", Description: "from git", List: current},
+			strategy: netlist.SyncDetails,
+			calls:    map[string]int{fake.MethodListNetworkLists: 0, fake.MethodUpdateNetworkListDetails: 1},
+			want:     current,
+		},
+		"type mismatch": {
+			desired: netlist.DesiredNetworkList{UniqueID: "1001_BLOCKED", Name: "Blocked", Type: "GEO", List: []string{"FR"}},
+			err:     netlist.ErrTypeMismatch,
+		},
+		"invalid elements": {
+			desired: netlist.DesiredNetworkList{Name: "Blocked", Type: "IP", List: []string{"FR"}},
+			err:     netlist.ErrStructValidation,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := fake.New()
+			client.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: "IP", List: current, SyncPoint: 3})
+
+			res, err := netlist.SyncNetworkList(context.Background(), client, netlist.SyncNetworkListRequest{
+				Desired:           test.desired,
+				ElementCallsLimit: test.limit,
+			})
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Strategy != test.strategy {
+				t.Errorf("strategy = %s, want %s", res.Strategy, test.strategy)
+			}
+			for method, calls := range test.calls {
+				if got := client.Calls(method); got != calls {
+					t.Errorf("%s called %d times, want %d", method, got, calls)
+				}
+			}
+			if !equalElements(res.List.List, test.want) {
+				t.Errorf("elements = %v, want %v", res.List.List, test.want)
+			}
+			if res.List.Name != test.desired.Name || res.List.Description != test.desired.Description {
+				t.Errorf("details = %q, %q, want %q, %q",
+					res.List.Name, res.List.Description, test.desired.Name, test.desired.Description)
+			}
+		})
+	}
+}
+
+func TestSyncNetworkListAmbiguous(t *testing.T) {
+	client := fake.New()
+	client.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: "IP"})
+	client.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: "IP"})
+
+	_, err := netlist.SyncNetworkList(context.Background(), client, netlist.SyncNetworkListRequest{
+		Desired: netlist.DesiredNetworkList{Name: "Blocked", Type: "IP"},
+	})
+	if !errors.Is(err, netlist.ErrAmbiguousList) {
+		t.Errorf("error = %v, want %v", err, netlist.ErrAmbiguousList)
+	}
+}
+
+func TestSyncNetworkListActivate(t *testing.T) {
+	client := fake.New(fake.WithActivationPolls(0))
+	client.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: "IP", List: []string{"1.1.1.1"}})
+
+	res, err := netlist.SyncNetworkList(context.Background(), client, netlist.SyncNetworkListRequest{
+		Desired:  netlist.DesiredNetworkList{Name: "Blocked", Type: "IP", List: []string{"1.1.1.1", "2.2.2.2"}},
+		Activate: []netlist.Environment{netlist.STAGING, netlist.PRODUCTION},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Activations) != 2 {
+		t.Fatalf("%d activations, want 2", len(res.Activations))
+	}
+	for _, a := range res.Activations {
+		if a.ActivationStatus != netlist.StatusActive || a.SyncPoint != res.List.SyncPoint {
+			t.Errorf("activation %s at sync point %d, want %s at %d",
+				a.ActivationStatus, a.SyncPoint, netlist.StatusActive, res.List.SyncPoint)
+		}
+	}
+}
+
+func equalElements(got, want []string) bool {
+	if len(got) == 0 && len(want) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(got, want)
+}
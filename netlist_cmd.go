@@ -0,0 +1,1554 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akamai-playground/expiry"
+	"github.com/akamai-playground/feed"
+	"github.com/akamai-playground/input"
+	"github.com/akamai-playground/netlist"
+
+	"github.com/apex/log"
+)
+
+// errUsage is returned when a command was invoked with wrong arguments
+var errUsage = errors.New("invalid usage")
+
+type (
+	// netlistCommand describes a single netlist subcommand
+	netlistCommand struct {
+		description string
+		run         func(ctx context.Context, client netlist.NETLIST, args []string) error
+	}
+
+	// stringsFlag collects repeated and comma separated flag values
+	stringsFlag []string
+
+	// optionalBool is a bool flag which stays nil unless it is passed
+	optionalBool struct {
+		value *bool
+	}
+)
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*s = append(*s, v)
+		}
+	}
+	return nil
+}
+
+func (b *optionalBool) String() string {
+	if b.value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b.value)
+}
+
+func (b *optionalBool) Set(value string) error {
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	b.value = &v
+	return nil
+}
+
+// IsBoolFlag lets the flag be passed without a value
+func (b *optionalBool) IsBoolFlag() bool {
+	return true
+}
+
+var netlistCommands = map[string]netlistCommand{
+	"list":     {"list network lists", listNetworkLists},
+	"get":      {"show a network list with its elements", getNetworkList},
+	"create":   {"create a new network list", createNetworkList},
+	"update":   {"replace name, description and elements of a network list", updateNetworkList},
+	"append":   {"append elements to a network list", appendNetworkList},
+	"add":      {"add a single element to a network list", addElement},
+	"remove":   {"remove a single element from a network list", removeElement},
+	"delete":   {"delete a network list", deleteNetworkList},
+	"activate": {"activate a network list on STAGING or PRODUCTION", activateNetworkList},
+	"status":   {"show the activation status of a network list", getActivationNetworkList},
+	"snapshot": {"show a network list as of a given sync point", getActivationSnapshot},
+	"history":  {"show what changed at every sync point of a network list", syncPointHistory},
+	"restore":  {"restore the elements of a network list from an older sync point", restoreNetworkList},
+	"rename":   {"update name and description of a network list", updateNLDetails},
+	"sync":     {"reconcile a network list with a desired state file", syncNetworkList},
+	"export":   {"write a network list to a JSON, YAML, CSV or text file", exportNetworkList},
+	"import":   {"create or update a network list from an exported file", importNetworkList},
+	"feed":     {"reconcile an IP network list with threat intelligence feeds", applyFeed},
+	"expire":   {"remove the elements added with --ttl once elapsed", expireElements},
+}
+
+// runNetlist dispatches the netlist subcommand given in args,
+// the client is only created once the subcommand is known
+func runNetlist(ctx context.Context, args []string, newClient func() (netlist.NETLIST, error)) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		netlistUsage()
+		return nil
+	}
+
+	cmd, ok := netlistCommands[args[0]]
+	if !ok {
+		netlistUsage()
+		return fmt.Errorf("%w: unknown netlist command %q", errUsage, args[0])
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	return cmd.run(ctx, client, args[1:])
+}
+
+func netlistUsage() {
+	names := make([]string, 0, len(netlistCommands))
+	for name := range netlistCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "Usage: %s netlist <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, netlistCommands[name].description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s netlist <command> -h' for the command flags\n", os.Args[0])
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("netlist "+name, flag.ContinueOnError)
+}
+
+// requireFlags returns an error naming every empty flag
+func requireFlags(values map[string]string) error {
+	var missing []string
+	for name, value := range values {
+		if value == "" {
+			missing = append(missing, "--"+name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("%w: missing required flags %s", errUsage, strings.Join(missing, ", "))
+}
+
+// collectElements merges elements given inline with elements read from files,
+// stdin, file:// URLs or glob patterns. File elements are checked against
+// listType when set, so that invalid ones are reported with their line
+func collectElements(listType netlist.NetworkType, files, elements []string) ([]string, error) {
+	var opts []input.Option
+	if listType != 0 {
+		opts = append(opts, input.WithValidator(func(value string) error {
+			_, err := netlist.ValidateElements(listType, []string{value}, netlist.ElementPolicy{})
+			var elErrs netlist.ElementsError
+			if errors.As(err, &elErrs) {
+				return errors.New(elErrs[0].Reason)
+			}
+			return err
+		}))
+	}
+
+	fileElements, err := input.NewReader(opts...).Read(files...)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]string{}, elements...), input.Values(fileElements)...), nil
+}
+
+// planFlags registers the flags switching a mutating command to plan mode
+func planFlags(fs *flag.FlagSet) (enabled *bool, format *string) {
+	enabled = fs.Bool("plan", false, "print what would change without executing anything")
+	format = fs.String("plan-format", "text", "plan output format: text or json")
+	return
+}
+
+// printPlan writes the plan to the standard output in the given format
+func printPlan(plan *netlist.Plan, format string) error {
+	switch format {
+	case "text":
+		_, err := fmt.Fprint(os.Stdout, plan)
+		return err
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+	return fmt.Errorf("%w: unknown plan format %q", errUsage, format)
+}
+
+// ipNormalizationFlags registers the flags canonicalizing IP elements before sending them
+func ipNormalizationFlags(fs *flag.FlagSet) (normalize, aggregate *bool) {
+	normalize = fs.Bool("normalize", false, "canonicalize IP elements, dropping duplicates and covered addresses")
+	aggregate = fs.Bool("aggregate", false, "like --normalize, also merging adjacent prefixes")
+	return
+}
+
+// normalizeElements canonicalizes the elements of IP lists when requested
+func normalizeElements(listType string, elements []string, normalize, aggregate bool) ([]string, error) {
+	if !strings.EqualFold(listType, netlist.IP.String()) || !(normalize || aggregate) {
+		return elements, nil
+	}
+
+	normalized, err := netlist.NormalizeIPElements(elements, aggregate)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Normalized %[1]d IP elements into %[2]d", len(elements), len(normalized))
+	return normalized, nil
+}
+
+// bulkFlags registers the flags splitting large element sets into AppendList chunks
+func bulkFlags(fs *flag.FlagSet) (chunkSize, concurrency *int) {
+	chunkSize = fs.Int("chunk-size", netlist.DefaultChunkSize, "number of elements sent per request")
+	concurrency = fs.Int("concurrency", 1, "number of chunks sent at once")
+	return
+}
+
+// bulkAppend appends elements in chunks, logging the progress and telling
+// how to resume when a chunk fails
+func bulkAppend(ctx context.Context, client netlist.NETLIST, params netlist.BulkAppendRequest) (*netlist.BulkAppendResponse, error) {
+	params.Progress = func(p netlist.BulkAppendProgress) {
+		log.Infof("%[1]s: %[2]d/%[3]d chunks, %[4]d/%[5]d elements, SyncPoint: %[6]d",
+			params.NetworkListID, p.Completed, p.Chunks, p.Appended, p.Total, p.SyncPoint)
+	}
+
+	out, err := netlist.BulkAppend(ctx, client, params)
+	var bulkErr *netlist.BulkAppendError
+	if errors.As(err, &bulkErr) {
+		log.Warnf("Resume with: netlist append --id %[1]s --chunk-size %[2]d --start-chunk %[3]d and the same elements",
+			params.NetworkListID, params.ChunkSize, bulkErr.NextChunk)
+	}
+	return out, err
+}
+
+// expiryFlags registers the flags recording the added elements for netlist expire
+func expiryFlags(fs *flag.FlagSet) (ttl *time.Duration, state *string) {
+	ttl = fs.Duration("ttl", 0, "remove the elements with netlist expire once elapsed, e.g. 72h")
+	state = fs.String("state", expiry.DefaultPath, "state file recording the elements added with --ttl")
+	return
+}
+
+// openExpiryStore opens the state file when a ttl is given, before anything is changed
+func openExpiryStore(state string, ttl time.Duration) (*expiry.Store, error) {
+	if ttl < 0 {
+		return nil, fmt.Errorf("%w: negative --ttl %s", errUsage, ttl)
+	}
+	if ttl == 0 {
+		return nil, nil
+	}
+	return expiry.Open(state)
+}
+
+// newElements returns the elements about to be added which the list does not
+// hold yet or holds as tracked temporary elements, the only ones trackElements
+// may record: the elements the list already held for good never expire
+func newElements(ctx context.Context, client netlist.NETLIST, store *expiry.Store, listID string, elements []string) ([]string, error) {
+	if store == nil {
+		return nil, nil
+	}
+	current, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+		OptionalParams: &netlist.OptionalParams{IncludeElements: true},
+		NetworkListID:  listID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	held := make(map[string]bool, len(current.List))
+	for _, e := range current.List {
+		held[e] = true
+	}
+	for _, e := range store.Entries() {
+		if e.NetworkListID == listID {
+			delete(held, e.Element)
+		}
+	}
+	rval := make([]string, 0, len(elements))
+	for _, e := range elements {
+		if !held[e] {
+			rval = append(rval, e)
+		}
+	}
+	return rval, nil
+}
+
+// trackElements records the added elements in the store opened by openExpiryStore, if any
+func trackElements(store *expiry.Store, listID string, elements []string, ttl time.Duration) error {
+	if store == nil || len(elements) == 0 {
+		return nil
+	}
+	now := time.Now()
+	store.Track(listID, elements, now, ttl)
+	if err := store.Save(); err != nil {
+		return err
+	}
+	log.Infof("%[1]d elements expire at %[2]s", len(elements), now.Add(ttl).Format(time.RFC3339))
+	return nil
+}
+
+// elementPolicyFlag registers the flag telling how IP elements in private,
+// reserved and multicast ranges are validated
+func elementPolicyFlag(fs *flag.FlagSet) *string {
+	return fs.String("ip-policy", "warn", "IP elements in private, reserved or multicast ranges: allow, warn or reject")
+}
+
+func parseElementPolicy(name string) (*netlist.ElementPolicy, error) {
+	policy, err := netlist.ParseRangePolicy(name)
+	if err != nil {
+		return nil, err
+	}
+	return &netlist.ElementPolicy{Private: policy, Reserved: policy, Multicast: policy}, nil
+}
+
+// mutateRetries converts a --retries flag to MutateNetworkListRequest.MaxRetries,
+// where 0 means the default number of retries and a negative value none
+func mutateRetries(flag int) int {
+	if flag == 0 {
+		return -1
+	}
+	return flag
+}
+
+// optionalNetworkType parses the list type of a flag which may be left empty
+func optionalNetworkType(name string) (netlist.NetworkType, error) {
+	if name == "" {
+		return 0, nil
+	}
+	return netlist.ParseNetworkType(name)
+}
+
+func elementLabel(listType string) string {
+	if listType == netlist.GEO.String() {
+		return "Country"
+	}
+	return "IP address"
+}
+
+func logElements(listType string, elements []string) {
+	label := elementLabel(listType)
+	for _, e := range elements {
+		log.Infof("%[1]s: %[2]s", label, e)
+	}
+}
+
+func listNetworkLists(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("list")
+	listType := fs.String("type", "", "filter by list type: IP or GEO")
+	search := fs.String("search", "", "only lists whose name contains the term or holding it as an element")
+	name := fs.String("name", "", "only lists whose name matches the glob pattern, e.g. 'blocked*'")
+	var shared, readOnly optionalBool
+	fs.Var(&shared, "shared", "only shared lists, --shared=false for the others")
+	fs.Var(&readOnly, "read-only", "only read-only lists, --read-only=false for the others")
+	minElements := fs.Int("min-elements", 0, "only lists holding at least that many elements")
+	maxElements := fs.Int("max-elements", 0, "only lists holding at most that many elements")
+	var status stringsFlag
+	fs.Var(&status, "status", "only lists with this activation status on --env, repeatable")
+	env := fs.String("env", "", "environment of --status: STAGING or PRODUCTION")
+	page := fs.Int("page", 1, "page to show, numbered from 1")
+	pageSize := fs.Int("page-size", 0, "lists per page, every list when 0")
+	extended := fs.Bool("extended", true, "include extended information")
+	includeElements := fs.Bool("include-elements", false, "include the list elements")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	params := netlist.FindNetworkListsRequest{
+		Filter: netlist.ListFilter{
+			Search:           *search,
+			Name:             *name,
+			Shared:           shared.value,
+			ReadOnly:         readOnly.value,
+			MinElements:      *minElements,
+			MaxElements:      *maxElements,
+			ActivationStatus: status,
+		},
+		Extended:        *extended,
+		IncludeElements: *includeElements,
+		Page:            *page,
+		PageSize:        *pageSize,
+	}
+	if *listType != "" {
+		t, err := netlist.ParseNetworkType(*listType)
+		if err != nil {
+			return err
+		}
+		params.Filter.Type = t
+	}
+	if *env != "" {
+		e, err := netlist.ParseEnvironment(*env)
+		if err != nil {
+			return err
+		}
+		params.Filter.Environment = e
+	}
+	if len(status) > 0 && *env == "" {
+		return fmt.Errorf("%w: --status requires --env", errUsage)
+	}
+
+	out, err := netlist.FindNetworkLists(ctx, client, params)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range out.Lists {
+		log.Infof("Unique ID: %[1]s, Name: %[2]s, Type: %[3]s, Elements: %[4]d, SyncPoint: %[5]d",
+			l.UniqueID, l.Name, l.Type, l.ElementCount, l.SyncPoint)
+		if *includeElements {
+			logElements(l.Type, l.List)
+		}
+	}
+	if out.PageSize > 0 {
+		log.Infof("Page %[1]d of %[2]d, %[3]d lists", out.Page, (out.TotalSize+out.PageSize-1)/out.PageSize, out.TotalSize)
+	}
+	return nil
+}
+
+func getNetworkList(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("get")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	includeElements := fs.Bool("include-elements", true, "include the list elements")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID}); err != nil {
+		return err
+	}
+
+	params := netlist.GetNetworkListRequest{
+		OptionalParams: &netlist.OptionalParams{
+			Extended:        true,
+			IncludeElements: *includeElements,
+		},
+		NetworkListID: *listID,
+	}
+
+	out, err := client.GetNetworkList(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Unique ID: %[1]s, Name: %[2]s, Type: %[3]s, Elements: %[4]d, SyncPoint: %[5]d",
+		out.UniqueID, out.Name, out.Type, out.ElementCount, out.SyncPoint)
+	if out.Description != "" {
+		log.Infof("Description: %s", out.Description)
+	}
+	logElements(out.Type, out.List)
+	return nil
+}
+
+func createNetworkList(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("create")
+	name := fs.String("name", "", "network list name (required)")
+	listType := fs.String("type", netlist.IP.String(), "list type: IP or GEO")
+	description := fs.String("description", "", "network list description")
+	contractID := fs.String("contract", "", "contract ID the list belongs to")
+	groupID := fs.Int("group", 0, "group ID the list belongs to")
+	var files, elements stringsFlag
+	fs.Var(&files, "file", "file, glob, file:// URL or - for stdin with one element per line, gzip compressed or not (repeatable)")
+	fs.Var(&elements, "element", "element to put into the list (repeatable)")
+	normalize, aggregate := ipNormalizationFlags(fs)
+	ipPolicy := elementPolicyFlag(fs)
+	chunkSize, concurrency := bulkFlags(fs)
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"name": *name}); err != nil {
+		return err
+	}
+
+	NLType, err := netlist.ParseNetworkType(*listType)
+	if err != nil {
+		return err
+	}
+	policy, err := parseElementPolicy(*ipPolicy)
+	if err != nil {
+		return err
+	}
+	NList, err := collectElements(NLType, files, elements)
+	if err != nil {
+		return err
+	}
+	if NList, err = normalizeElements(NLType.String(), NList, *normalize, *aggregate); err != nil {
+		return err
+	}
+	contract := NewContractParams(strings.TrimPrefix(*contractID, "ctr_"), *groupID)
+
+	params := netlist.CreateNetworkListRequest{
+		BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+			Name:        *name,
+			Type:        NLType.String(),
+			Description: *description,
+			List:        NList,
+			ContractID:  contract.ContractID,
+			GroupID:     contract.GroupID,
+			Policy:      policy,
+		},
+	}
+
+	if *plan {
+		p, err := netlist.PlanCreateNetworkList(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	// large lists are created with their first chunk, the rest is appended.
+	// The whole list is validated first not to leave a partial list behind
+	if *chunkSize > 0 && len(NList) > *chunkSize {
+		if err := params.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", netlist.ErrStructValidation, err.Error())
+		}
+		params.List = NList[:*chunkSize]
+	}
+
+	out, err := client.CreateNetworkList(ctx, params)
+	if err != nil {
+		return err
+	}
+	log.Infof("Unique ID of the created list: %[1]s, SyncPoint: %[2]d", out.UniqueID, out.SyncPoint)
+
+	if len(params.List) < len(NList) {
+		bulk, err := bulkAppend(ctx, client, netlist.BulkAppendRequest{
+			NetworkListID: out.UniqueID,
+			List:          NList,
+			ChunkSize:     *chunkSize,
+			Concurrency:   *concurrency,
+			StartChunk:    1,
+		})
+		if err != nil {
+			return err
+		}
+		log.Infof("Appended %[1]d elements to %[2]s, SyncPoint: %[3]d", bulk.Appended, out.UniqueID, bulk.SyncPoint)
+	}
+	return nil
+}
+
+func updateNetworkList(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("update")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	name := fs.String("name", "", "new network list name (defaults to the current one)")
+	description := fs.String("description", "", "new network list description (defaults to the current one)")
+	syncPoint := fs.Int("sync-point", -1, "sync point the update is based on (defaults to the current one, retried on conflicts)")
+	retries := fs.Int("retries", 3, "number of retries when the list changes concurrently, unless --sync-point is set")
+	var files, elements stringsFlag
+	fs.Var(&files, "file", "file, glob, file:// URL or - for stdin with one element per line, gzip compressed or not (repeatable, elements default to the current ones)")
+	fs.Var(&elements, "element", "element to put into the list (repeatable, elements default to the current ones)")
+	normalize, aggregate := ipNormalizationFlags(fs)
+	ipPolicy := elementPolicyFlag(fs)
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID}); err != nil {
+		return err
+	}
+
+	policy, err := parseElementPolicy(*ipPolicy)
+	if err != nil {
+		return err
+	}
+	NList, err := collectElements(0, files, elements)
+	if err != nil {
+		return err
+	}
+
+	// elements are only replaced when given, like name and description
+	replaceElements := len(files) > 0 || len(elements) > 0
+
+	current, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+		OptionalParams: &netlist.OptionalParams{
+			Extended:        true,
+			IncludeElements: !replaceElements,
+		},
+		NetworkListID: *listID,
+	})
+	if err != nil {
+		return err
+	}
+	if replaceElements {
+		if NList, err = normalizeElements(current.Type, NList, *normalize, *aggregate); err != nil {
+			return err
+		}
+	} else {
+		NList = current.List
+	}
+	// the given fields only are applied on retries not to revert concurrent changes
+	newName, newDescription := *name, *description
+	if *name == "" {
+		*name = current.Name
+	}
+	if *description == "" {
+		*description = current.Description
+	}
+	explicitSyncPoint := *syncPoint >= 0
+	if !explicitSyncPoint {
+		*syncPoint = current.SyncPoint
+	}
+
+	params := netlist.UpdateNetworkListRequest{
+		BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+			GetNetworkListRequest: &netlist.GetNetworkListRequest{
+				OptionalParams: &netlist.OptionalParams{
+					Extended:        true,
+					IncludeElements: true,
+				},
+				NetworkListID: *listID,
+			},
+			Name:        *name,
+			Type:        current.Type,
+			Description: *description,
+			List:        NList,
+			Policy:      policy,
+		},
+		SyncPoint: *syncPoint,
+	}
+
+	if *plan {
+		p, err := netlist.PlanUpdateNetworkList(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	var out *netlist.NetworkListResponse
+	if explicitSyncPoint {
+		out, err = client.UpdateNetworkList(ctx, params)
+	} else {
+		out, err = netlist.MutateNetworkList(ctx, client, netlist.MutateNetworkListRequest{
+			NetworkListID: *listID,
+			Mutate: func(list *netlist.NetworkListResponse) error {
+				if newName != "" {
+					list.Name = newName
+				}
+				if newDescription != "" {
+					list.Description = newDescription
+				}
+				if replaceElements {
+					list.List = NList
+				}
+				return nil
+			},
+			MaxRetries: mutateRetries(*retries),
+			Policy:     policy,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Updated list: %[1]s, SyncPoint: %[2]d", out.UniqueID, out.SyncPoint)
+	logElements(out.Type, out.List)
+	return nil
+}
+
+func appendNetworkList(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("append")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	var files, elements stringsFlag
+	fs.Var(&files, "file", "file, glob, file:// URL or - for stdin with one element per line, gzip compressed or not (repeatable)")
+	fs.Var(&elements, "element", "element to append (repeatable)")
+	listType := fs.String("type", "", "list type, IP or GEO, to validate the elements against")
+	normalize, aggregate := ipNormalizationFlags(fs)
+	ipPolicy := elementPolicyFlag(fs)
+	chunkSize, concurrency := bulkFlags(fs)
+	startChunk := fs.Int("start-chunk", 0, "chunk to resume a failed append from, numbered from 0")
+	ttl, state := expiryFlags(fs)
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID}); err != nil {
+		return err
+	}
+
+	NLType, err := optionalNetworkType(*listType)
+	if err != nil {
+		return err
+	}
+	policy, err := parseElementPolicy(*ipPolicy)
+	if err != nil {
+		return err
+	}
+	NList, err := collectElements(NLType, files, elements)
+	if err != nil {
+		return err
+	}
+	if NLType == 0 {
+		if *normalize || *aggregate {
+			log.Warn("Elements are not normalized, pass --type IP to normalize them")
+		}
+	} else if NList, err = normalizeElements(NLType.String(), NList, *normalize, *aggregate); err != nil {
+		return err
+	}
+	if len(NList) == 0 {
+		return fmt.Errorf("%w: nothing to append, use --file or --element", errUsage)
+	}
+	store, err := openExpiryStore(*state, *ttl)
+	if err != nil {
+		return err
+	}
+
+	params := netlist.AppendListRequest{
+		NetworkListID: *listID,
+		List:          NList,
+		Type:          NLType,
+		Policy:        policy,
+	}
+
+	if *plan {
+		p, err := netlist.PlanAppendList(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	added, err := newElements(ctx, client, store, params.NetworkListID, params.List)
+	if err != nil {
+		return err
+	}
+	out, err := bulkAppend(ctx, client, netlist.BulkAppendRequest{
+		NetworkListID: params.NetworkListID,
+		List:          params.List,
+		ChunkSize:     *chunkSize,
+		Concurrency:   *concurrency,
+		StartChunk:    *startChunk,
+		Type:          params.Type,
+		Policy:        params.Policy,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Appended %[1]d elements to %[2]s, SyncPoint: %[3]d", out.Appended, params.NetworkListID, out.SyncPoint)
+	return trackElements(store, params.NetworkListID, added, *ttl)
+}
+
+func addElement(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("add")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	element := fs.String("element", "", "element to add (required)")
+	listType := fs.String("type", "", "list type, IP or GEO, to validate the element against")
+	ipPolicy := elementPolicyFlag(fs)
+	ttl, state := expiryFlags(fs)
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID, "element": *element}); err != nil {
+		return err
+	}
+
+	NLType, err := optionalNetworkType(*listType)
+	if err != nil {
+		return err
+	}
+	policy, err := parseElementPolicy(*ipPolicy)
+	if err != nil {
+		return err
+	}
+
+	store, err := openExpiryStore(*state, *ttl)
+	if err != nil {
+		return err
+	}
+
+	params := netlist.AddElementRequest{
+		NetworkListID: *listID,
+		Element:       *element,
+		Type:          NLType,
+		Policy:        policy,
+	}
+
+	if *plan {
+		p, err := netlist.PlanAddElement(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	added, err := newElements(ctx, client, store, params.NetworkListID, []string{params.Element})
+	if err != nil {
+		return err
+	}
+	out, err := client.AddElement(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Added %[1]s to %[2]s, SyncPoint: %[3]d", *element, out.UniqueID, out.SyncPoint)
+	return trackElements(store, params.NetworkListID, added, *ttl)
+}
+
+func removeElement(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("remove")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	element := fs.String("element", "", "element to remove (required)")
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID, "element": *element}); err != nil {
+		return err
+	}
+
+	params := netlist.RemoveElementRequest{
+		AddElementRequest: &netlist.AddElementRequest{
+			NetworkListID: *listID,
+			Element:       *element,
+		},
+	}
+
+	if *plan {
+		p, err := netlist.PlanRemoveElement(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	out, err := client.RemoveElement(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Removed %[1]s from %[2]s, SyncPoint: %[3]d", *element, out.UniqueID, out.SyncPoint)
+	return nil
+}
+
+func deleteNetworkList(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("delete")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID}); err != nil {
+		return err
+	}
+
+	params := netlist.DeleteNetworkListRequest{
+		GetNetworkListRequest: &netlist.GetNetworkListRequest{
+			NetworkListID: *listID,
+		},
+	}
+
+	if *plan {
+		p, err := netlist.PlanDeleteNetworkList(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	out, err := client.DeleteNetworkList(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Delete Status: %d", out.Status)
+	return nil
+}
+
+func activateNetworkList(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("activate")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	env := fs.String("env", netlist.STAGING.String(), "target environment: STAGING or PRODUCTION")
+	comments := fs.String("comments", "", "activation comments")
+	wait := fs.Bool("wait", false, "block until the activation reaches a terminal status")
+	timeout := fs.Duration("timeout", 30*time.Minute, "how long to wait for the activation with --wait")
+	pollInterval := fs.Duration("poll-interval", 10*time.Second, "initial delay between status checks with --wait")
+	var recipients stringsFlag
+	fs.Var(&recipients, "notify", "email to notify about the activation (repeatable)")
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID}); err != nil {
+		return err
+	}
+
+	environment, err := netlist.ParseEnvironment(*env)
+	if err != nil {
+		return err
+	}
+
+	params := netlist.ActivateNetworkListRequest{
+		NetworkListID:          *listID,
+		Environment:            environment,
+		Comments:               *comments,
+		NotificationRecipients: recipients,
+	}
+
+	if *plan {
+		p, err := netlist.PlanActivateNetworkList(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	out, err := client.ActivateNetworkList(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Activation ID: %[1]d, Status: %[2]s, SyncPoint: %[3]d", out.ActivationID, out.ActivationStatus, out.SyncPoint)
+	if !*wait {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	status, err := netlist.WaitForActivation(ctx, client, netlist.WaitForActivationRequest{
+		NetworkListID: *listID,
+		Environment:   environment,
+		SyncPoint:     out.SyncPoint,
+		PollInterval:  *pollInterval,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("%[1]s is %[2]s on %[3]s, SyncPoint: %[4]d", *listID, status.ActivationStatus, environment, status.SyncPoint)
+	return nil
+}
+
+func getActivationNetworkList(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("status")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	env := fs.String("env", netlist.STAGING.String(), "environment: STAGING or PRODUCTION")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID}); err != nil {
+		return err
+	}
+
+	environment, err := netlist.ParseEnvironment(*env)
+	if err != nil {
+		return err
+	}
+
+	params := netlist.ActivateNetworkListRequest{
+		NetworkListID: *listID,
+		Environment:   environment,
+	}
+
+	out, err := client.GetActivationNetworkList(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("%[1]s: %[2]s, SyncPoint: %[3]d", environment, out.ActivationStatus, out.SyncPoint)
+	return nil
+}
+
+func getActivationSnapshot(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("snapshot")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	syncPoint := fs.Int("sync-point", 0, "sync point to retrieve")
+	extended := fs.Bool("extended", true, "include extended information")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID}); err != nil {
+		return err
+	}
+
+	params := netlist.GetActivationSnapshotRequest{
+		NetworkListID: *listID,
+		SyncPoint:     *syncPoint,
+		Extended:      *extended,
+	}
+
+	out, err := client.GetActivationSnapshot(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Name: %[1]s, SyncPoint: %[2]d", out.Name, out.SyncPoint)
+	logElements(out.Type, out.List)
+	return nil
+}
+
+func syncPointHistory(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("history")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	from := fs.Int("from", 0, "first sync point to show")
+	to := fs.Int("to", -1, "last sync point to show (defaults to the current one)")
+	format := fs.String("format", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID}); err != nil {
+		return err
+	}
+
+	params := netlist.SyncPointHistoryRequest{
+		NetworkListID: *listID,
+		From:          *from,
+	}
+	if *to >= 0 {
+		params.To = to
+	}
+	changes, err := netlist.GetSyncPointHistory(ctx, client, params)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	case "text":
+	default:
+		return fmt.Errorf("%w: unknown format %q", errUsage, *format)
+	}
+
+	for _, c := range changes {
+		fmt.Printf("sync point %d by %s at %s: %d added, %d removed, %d elements\n",
+			c.SyncPoint, c.UpdatedBy, c.UpdateDate, len(c.Added), len(c.Removed), c.ElementCount)
+		if c.Name != nil {
+			fmt.Printf("  ~ name: %q -> %q\n", c.Name.From, c.Name.To)
+		}
+		if c.Description != nil {
+			fmt.Printf("  ~ description: %q -> %q\n", c.Description.From, c.Description.To)
+		}
+		for _, e := range c.Added {
+			fmt.Printf("  + %s\n", e)
+		}
+		for _, e := range c.Removed {
+			fmt.Printf("  - %s\n", e)
+		}
+	}
+	return nil
+}
+
+func restoreNetworkList(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("restore")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	syncPoint := fs.Int("sync-point", -1, "sync point to restore the elements of (required)")
+	retries := fs.Int("retries", 3, "number of retries when the list changes concurrently")
+	ipPolicy := elementPolicyFlag(fs)
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID}); err != nil {
+		return err
+	}
+	if *syncPoint < 0 {
+		return fmt.Errorf("%w: --sync-point is required", errUsage)
+	}
+
+	policy, err := parseElementPolicy(*ipPolicy)
+	if err != nil {
+		return err
+	}
+
+	params := netlist.RestoreNetworkListRequest{
+		NetworkListID: *listID,
+		SyncPoint:     *syncPoint,
+		MaxRetries:    mutateRetries(*retries),
+		Policy:        policy,
+	}
+
+	if *plan {
+		p, err := netlist.PlanRestoreNetworkList(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	out, err := netlist.RestoreNetworkList(ctx, client, params)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Restored list: %[1]s to the elements of sync point %[2]d, SyncPoint: %[3]d", out.UniqueID, *syncPoint, out.SyncPoint)
+	return nil
+}
+
+func updateNLDetails(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("rename")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	name := fs.String("name", "", "new network list name (defaults to the current one)")
+	description := fs.String("description", "", "new network list description (defaults to the current one)")
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID}); err != nil {
+		return err
+	}
+	if *name == "" && *description == "" {
+		return fmt.Errorf("%w: nothing to update, use --name or --description", errUsage)
+	}
+
+	if *name == "" || *description == "" {
+		current, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+			OptionalParams: &netlist.OptionalParams{Extended: true},
+			NetworkListID:  *listID,
+		})
+		if err != nil {
+			return err
+		}
+		if *name == "" {
+			*name = current.Name
+		}
+		if *description == "" {
+			*description = current.Description
+		}
+	}
+
+	params := netlist.UpdateNetworkListDetailsRequest{
+		NetworkListID: *listID,
+		Name:          *name,
+		Description:   *description,
+	}
+
+	if *plan {
+		p, err := netlist.PlanUpdateNetworkListDetails(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	if err := client.UpdateNetworkListDetails(ctx, params); err != nil {
+		return err
+	}
+
+	log.Infof("Updated details of %s", *listID)
+	return nil
+}
+
+func syncNetworkList(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("sync")
+	file := fs.String("file", "", "JSON file with the desired name, type, description and list (required)")
+	listID := fs.String("id", "", "network list unique ID (defaults to the uniqueId of the file, then a lookup by name)")
+	limit := fs.Int("element-calls-limit", 10, "maximum single element calls before replacing the whole list")
+	comments := fs.String("comments", "", "activation comments")
+	wait := fs.Bool("wait", false, "block until the activations reach a terminal status")
+	timeout := fs.Duration("timeout", 30*time.Minute, "how long to wait for the activations with --wait")
+	var elementFiles, activate, recipients stringsFlag
+	fs.Var(&elementFiles, "elements-file", "file, glob, file:// URL or - for stdin with one element per line added to the desired list (repeatable)")
+	fs.Var(&activate, "activate", "environment to activate the list on once synced (repeatable)")
+	fs.Var(&recipients, "notify", "email to notify about the activation (repeatable)")
+	normalize, aggregate := ipNormalizationFlags(fs)
+	ipPolicy := elementPolicyFlag(fs)
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"file": *file}); err != nil {
+		return err
+	}
+
+	policy, err := parseElementPolicy(*ipPolicy)
+	if err != nil {
+		return err
+	}
+	desired, err := readDesiredState(*file)
+	if err != nil {
+		return err
+	}
+	if *listID != "" {
+		desired.UniqueID = *listID
+	}
+	fileElements, err := collectElements(0, elementFiles, nil)
+	if err != nil {
+		return err
+	}
+	desired.List = append(desired.List, fileElements...)
+
+	params := netlist.SyncNetworkListRequest{
+		Desired:                *desired,
+		ElementCallsLimit:      *limit,
+		Comments:               *comments,
+		NotificationRecipients: recipients,
+		Wait:                   *wait,
+		NormalizeIP:            *normalize,
+		AggregateIP:            *aggregate,
+		Policy:                 policy,
+	}
+	for _, env := range activate {
+		environment, err := netlist.ParseEnvironment(env)
+		if err != nil {
+			return err
+		}
+		params.Activate = append(params.Activate, environment)
+	}
+
+	if *plan {
+		p, err := netlist.PlanSyncNetworkList(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	if *wait {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	out, err := netlist.SyncNetworkList(ctx, client, params)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Unique ID: %[1]s, Strategy: %[2]s, Added: %[3]d, Removed: %[4]d, SyncPoint: %[5]d",
+		out.List.UniqueID, out.Strategy, len(out.Added), len(out.Removed), out.List.SyncPoint)
+	for _, a := range out.Activations {
+		log.Infof("Activation ID: %[1]d, Status: %[2]s, SyncPoint: %[3]d", a.ActivationID, a.ActivationStatus, a.SyncPoint)
+	}
+	return nil
+}
+
+func exportNetworkList(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("export")
+	listID := fs.String("id", "", "network list unique ID (required)")
+	file := fs.String("file", "-", "file to write, - for the standard output")
+	format := fs.String("format", "", "json, yaml, csv or text (defaults to the file extension, json on the standard output)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID}); err != nil {
+		return err
+	}
+	listFormat, err := documentFormat(*format, *file, netlist.FormatJSON)
+	if err != nil {
+		return err
+	}
+
+	list, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+		OptionalParams: &netlist.OptionalParams{
+			Extended:        true,
+			IncludeElements: true,
+		},
+		NetworkListID: *listID,
+	})
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *file != "-" {
+		if out, err = os.Create(*file); err != nil {
+			return err
+		}
+	}
+	err = netlist.WriteListDocument(out, netlist.NewListDocument(list), listFormat)
+	if out != os.Stdout {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if *file != "-" {
+		log.Infof("Exported %[1]s at sync point %[2]d to %[3]s", list.UniqueID, list.SyncPoint, *file)
+	}
+	return nil
+}
+
+func importNetworkList(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("import")
+	file := fs.String("file", "", "file written by export, - for the standard input (required)")
+	format := fs.String("format", "", "json, yaml, csv or text (defaults to the file extension, json on the standard input)")
+	listID := fs.String("id", "", "network list to update (defaults to the uniqueId of the file)")
+	create := fs.Bool("create", false, "create a new list even when the file has a uniqueId")
+	checkSyncPoint := fs.Bool("check-sync-point", false, "fail when the list changed since the sync point of the file")
+	retries := fs.Int("retries", 3, "number of retries when the list changes concurrently, unless --check-sync-point is set")
+	ipPolicy := elementPolicyFlag(fs)
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"file": *file}); err != nil {
+		return err
+	}
+	listFormat, err := documentFormat(*format, *file, netlist.FormatJSON)
+	if err != nil {
+		return err
+	}
+	policy, err := parseElementPolicy(*ipPolicy)
+	if err != nil {
+		return err
+	}
+
+	in := os.Stdin
+	if *file != "-" {
+		if in, err = os.Open(*file); err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+	doc, err := netlist.ReadListDocument(in, listFormat)
+	if err != nil {
+		return err
+	}
+
+	id := *listID
+	if id == "" && !*create {
+		id = doc.UniqueID
+	}
+
+	if id == "" {
+		params := netlist.CreateNetworkListRequest{
+			BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+				Name:        doc.Name,
+				Type:        doc.Type,
+				Description: doc.Description,
+				List:        doc.Elements(),
+				Policy:      policy,
+			},
+		}
+		if *plan {
+			p, err := netlist.PlanCreateNetworkList(ctx, client, params)
+			if err != nil {
+				return err
+			}
+			return printPlan(p, *planFormat)
+		}
+
+		out, err := client.CreateNetworkList(ctx, params)
+		if err != nil {
+			return err
+		}
+		log.Infof("Imported %[1]d elements into the created list: %[2]s, SyncPoint: %[3]d",
+			len(out.List), out.UniqueID, out.SyncPoint)
+		return nil
+	}
+
+	current, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+		OptionalParams: &netlist.OptionalParams{Extended: true},
+		NetworkListID:  id,
+	})
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(current.Type, doc.Type) {
+		return fmt.Errorf("%w: %s is %s, the file holds a %s list", netlist.ErrTypeMismatch, id, current.Type, doc.Type)
+	}
+
+	syncPoint := current.SyncPoint
+	if *checkSyncPoint {
+		syncPoint = doc.SyncPoint
+	}
+	params := netlist.UpdateNetworkListRequest{
+		BodyNetworkListRequest: &netlist.BodyNetworkListRequest{
+			GetNetworkListRequest: &netlist.GetNetworkListRequest{
+				OptionalParams: &netlist.OptionalParams{
+					Extended:        true,
+					IncludeElements: true,
+				},
+				NetworkListID: id,
+			},
+			Name:        doc.Name,
+			Type:        current.Type,
+			Description: doc.Description,
+			List:        doc.Elements(),
+			Policy:      policy,
+		},
+		SyncPoint: syncPoint,
+	}
+
+	if *plan {
+		p, err := netlist.PlanUpdateNetworkList(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	var out *netlist.NetworkListResponse
+	if *checkSyncPoint {
+		out, err = client.UpdateNetworkList(ctx, params)
+	} else {
+		out, err = netlist.MutateNetworkList(ctx, client, netlist.MutateNetworkListRequest{
+			NetworkListID: id,
+			Mutate: func(list *netlist.NetworkListResponse) error {
+				list.Name, list.Description, list.List = doc.Name, doc.Description, doc.Elements()
+				return nil
+			},
+			MaxRetries: mutateRetries(*retries),
+			Policy:     policy,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof("Imported %[1]d elements into %[2]s, SyncPoint: %[3]d", len(out.List), out.UniqueID, out.SyncPoint)
+	return nil
+}
+
+// documentFormat returns the format given by name, else by the extension of the file,
+// else the fallback for the standard streams
+func documentFormat(name, file string, fallback netlist.Format) (netlist.Format, error) {
+	switch {
+	case name != "":
+		return netlist.ParseFormat(name)
+	case file == "-":
+		return fallback, nil
+	}
+	return netlist.FormatOf(file)
+}
+
+func applyFeed(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("feed")
+	listID := fs.String("id", "", "IP network list unique ID (required)")
+	format := fs.String("format", "plain", "feed format: plain, drop, netset or csv")
+	column := fs.String("column", feed.DefaultColumn, "header of the IP column of csv feeds")
+	aggregate := fs.Bool("aggregate", false, "merge adjacent prefixes of the feed")
+	limit := fs.Int("element-calls-limit", 10, "maximum single element calls before replacing the whole list")
+	var files stringsFlag
+	fs.Var(&files, "file", "feed file, glob, file:// URL or - for stdin, gzip compressed or not (repeatable, required)")
+	ipPolicy := elementPolicyFlag(fs)
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(map[string]string{"id": *listID, "file": files.String()}); err != nil {
+		return err
+	}
+
+	feedFormat, err := feed.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	policy, err := parseElementPolicy(*ipPolicy)
+	if err != nil {
+		return err
+	}
+	elements, err := feed.Read(feed.Options{Format: feedFormat, Column: *column}, files...)
+	if err != nil {
+		return err
+	}
+	log.Infof("Read %d feed elements", len(elements))
+
+	params := feed.ApplyRequest{
+		NetworkListID:     *listID,
+		Elements:          input.Values(elements),
+		Aggregate:         *aggregate,
+		ElementCallsLimit: *limit,
+		Policy:            policy,
+	}
+
+	if *plan {
+		p, err := feed.Plan(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		return printPlan(p, *planFormat)
+	}
+
+	out, err := feed.Apply(ctx, client, params)
+	if err != nil {
+		return err
+	}
+	log.Infof("Unique ID: %[1]s, Strategy: %[2]s, Added: %[3]d, Removed: %[4]d, SyncPoint: %[5]d",
+		out.List.UniqueID, out.Strategy, len(out.Added), len(out.Removed), out.List.SyncPoint)
+	return nil
+}
+
+func expireElements(ctx context.Context, client netlist.NETLIST, args []string) error {
+	fs := newFlagSet("expire")
+	state := fs.String("state", expiry.DefaultPath, "state file recording the elements added with --ttl")
+	listID := fs.String("id", "", "network list unique ID (defaults to every list of the state file)")
+	retries := fs.Int("retries", 3, "number of retries when a list changes concurrently")
+	comments := fs.String("comments", "", "activation comments")
+	wait := fs.Bool("wait", false, "block until the activations reach a terminal status")
+	timeout := fs.Duration("timeout", 30*time.Minute, "how long to wait for the activations with --wait")
+	pollInterval := fs.Duration("poll-interval", 10*time.Second, "initial delay between status checks with --wait")
+	var activate, recipients stringsFlag
+	fs.Var(&activate, "activate", "environment to activate the changed lists on (repeatable)")
+	fs.Var(&recipients, "notify", "email to notify about the activations (repeatable)")
+	plan, planFormat := planFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := expiry.Open(*state)
+	if err != nil {
+		return err
+	}
+	params := expiry.ExpireRequest{
+		Store:                  store,
+		NetworkListID:          *listID,
+		MaxRetries:             mutateRetries(*retries),
+		Comments:               *comments,
+		NotificationRecipients: recipients,
+		Wait:                   *wait,
+		PollInterval:           *pollInterval,
+	}
+	for _, env := range activate {
+		environment, err := netlist.ParseEnvironment(env)
+		if err != nil {
+			return err
+		}
+		params.Activate = append(params.Activate, environment)
+	}
+
+	if *plan {
+		plans, err := expiry.Plan(ctx, client, params)
+		if err != nil {
+			return err
+		}
+		for _, p := range plans {
+			if err := printPlan(p, *planFormat); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if *wait {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	results, err := expiry.Expire(ctx, client, params)
+	for _, r := range results {
+		log.Infof("%[1]s: %[2]d elements removed, %[3]d already gone", r.NetworkListID, len(r.Removed), len(r.Missing))
+		for _, a := range r.Activations {
+			log.Infof("Activation ID: %[1]d, Status: %[2]s, SyncPoint: %[3]d", a.ActivationID, a.ActivationStatus, a.SyncPoint)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		log.Info("Nothing elapsed")
+	}
+	return nil
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/akamai-playground/expiry"
+	"github.com/akamai-playground/input"
+	"github.com/akamai-playground/netlist"
+	"github.com/akamai-playground/netlist/fake"
+)
+
+func TestUpdateNetworkList(t *testing.T) {
+	tests := map[string]struct {
+		args        []string
+		name        string
+		description string
+		list        []string
+	}{
+		"rename keeps elements": {
+			args:        []string{"--name", "renamed"},
+			name:        "renamed",
+			description: "blocked",
+			list:        []string{"1.1.1.1", "2.2.2.2"},
+		},
+		"description with an explicit sync point keeps elements": {
+			args:        []string{"--description", "updated", "--sync-point", "1"},
+			name:        "Blocked",
+			description: "updated",
+			list:        []string{"1.1.1.1", "2.2.2.2"},
+		},
+		"elements are replaced": {
+			args:        []string{"--element", "3.3.3.3"},
+			name:        "Blocked",
+			description: "blocked",
+			list:        []string{"3.3.3.3"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.New()
+			l := client.Seed(netlist.NetworkListResponse{
+				Name:        "Blocked",
+				Type:        netlist.IP.String(),
+				Description: "blocked",
+				SyncPoint:   1,
+				List:        []string{"1.1.1.1", "2.2.2.2"},
+			})
+
+			args := append([]string{"--id", l.UniqueID}, test.args...)
+			if err := updateNetworkList(ctx, client, args); err != nil {
+				t.Fatalf("update %v: %v", args, err)
+			}
+
+			got, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+				OptionalParams: &netlist.OptionalParams{IncludeElements: true},
+				NetworkListID:  l.UniqueID,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != test.name || got.Description != test.description {
+				t.Errorf("name, description = %q, %q, want %q, %q", got.Name, got.Description, test.name, test.description)
+			}
+			if !reflect.DeepEqual(got.List, test.list) {
+				t.Errorf("list = %v, want %v", got.List, test.list)
+			}
+		})
+	}
+}
+
+func TestAppendNetworkList(t *testing.T) {
+	tests := map[string]struct {
+		listType string
+		seed     []string
+		args     []string
+		list     []string
+	}{
+		"GEO elements are not normalized": {
+			listType: netlist.GEO.String(),
+			seed:     []string{"DE"},
+			args:     []string{"--type", "GEO", "--normalize", "--element", "FR"},
+			list:     []string{"DE", "FR"},
+		},
+		"IP elements are normalized": {
+			listType: netlist.IP.String(),
+			seed:     []string{"1.1.1.1"},
+			args:     []string{"--type", "IP", "--normalize", "--element", "10.0.0.1/8", "--element", "10.1.1.1"},
+			list:     []string{"1.1.1.1", "10.0.0.0/8"},
+		},
+		"elements of an unknown type are sent as is": {
+			listType: netlist.GEO.String(),
+			seed:     []string{"DE"},
+			args:     []string{"--normalize", "--element", "FR"},
+			list:     []string{"DE", "FR"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.New()
+			l := client.Seed(netlist.NetworkListResponse{Name: "List", Type: test.listType, List: test.seed})
+
+			args := append([]string{"--id", l.UniqueID}, test.args...)
+			if err := appendNetworkList(ctx, client, args); err != nil {
+				t.Fatalf("append %v: %v", args, err)
+			}
+
+			got, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+				OptionalParams: &netlist.OptionalParams{IncludeElements: true},
+				NetworkListID:  l.UniqueID,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.List, test.list) {
+				t.Errorf("list = %v, want %v", got.List, test.list)
+			}
+		})
+	}
+}
+
+func TestListNetworkListsFlags(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		err  bool
+	}{
+		"defaults":               {},
+		"filters":                {args: []string{"--type", "IP", "--name", "blocked*", "--shared=false", "--min-elements", "1"}},
+		"status":                 {args: []string{"--status", "ACTIVE,MODIFIED", "--env", "STAGING"}},
+		"page":                   {args: []string{"--page", "2", "--page-size", "1"}},
+		"status without env":     {args: []string{"--status", "ACTIVE"}, err: true},
+		"invalid shared value":   {args: []string{"--shared=maybe"}, err: true},
+		"invalid name pattern":   {args: []string{"--name", "[blocked"}, err: true},
+		"invalid environment":    {args: []string{"--status", "ACTIVE", "--env", "QA"}, err: true},
+		"negative element count": {args: []string{"--min-elements", "-1"}, err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := fake.New()
+			client.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: netlist.IP.String(), List: []string{"1.1.1.1"}})
+
+			err := listNetworkLists(context.Background(), client, test.args)
+			if (err != nil) != test.err {
+				t.Errorf("list %v: error = %v, want error %t", test.args, err, test.err)
+			}
+		})
+	}
+}
+
+func TestExportImportNetworkList(t *testing.T) {
+	for _, ext := range []string{"json", "yaml", "csv", "txt"} {
+		t.Run(ext, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "netlist")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			file := filepath.Join(dir, "blocked."+ext)
+
+			ctx := context.Background()
+			client := fake.New()
+			l := client.Seed(netlist.NetworkListResponse{
+				Name:        "Blocked",
+				Type:        netlist.IP.String(),
+				Description: "blocked",
+				SyncPoint:   1,
+				List:        []string{"1.1.1.1", "2.2.2.2"},
+			})
+
+			if err := exportNetworkList(ctx, client, []string{"--id", l.UniqueID, "--file", file}); err != nil {
+				t.Fatal(err)
+			}
+
+			// the list changes after the export, importing reverts it
+			if _, err := client.AppendList(ctx, netlist.AppendListRequest{NetworkListID: l.UniqueID, List: []string{"3.3.3.3"}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := importNetworkList(ctx, client, []string{"--file", file, "--check-sync-point"}); !errors.Is(err, netlist.ErrConflict) {
+				t.Fatalf("import with --check-sync-point: error = %v, want %v", err, netlist.ErrConflict)
+			}
+			if err := importNetworkList(ctx, client, []string{"--file", file}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+				OptionalParams: &netlist.OptionalParams{IncludeElements: true},
+				NetworkListID:  l.UniqueID,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"1.1.1.1", "2.2.2.2"}; !reflect.DeepEqual(got.List, want) || got.SyncPoint != 3 {
+				t.Errorf("imported list at sync point %d with %v, want 3 with %v", got.SyncPoint, got.List, want)
+			}
+
+			if err := importNetworkList(ctx, client, []string{"--file", file, "--create"}); err != nil {
+				t.Fatal(err)
+			}
+			if calls := client.Calls(fake.MethodCreateNetworkList); calls != 1 {
+				t.Errorf("%d lists created, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestReadDesiredState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "desired")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// an exported document, the elements with a comment are objects
+	file := filepath.Join(dir, "blocked.json")
+	doc := `{"uniqueId": "1001_BLOCKED", "name": "Blocked", "type": "IP", "description": "blocked", "syncPoint": 3,
+		"list": ["1.1.1.1", {"value": "2.2.2.2", "comment": "scanner"}]}`
+	if err := ioutil.WriteFile(file, []byte(doc), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readDesiredState(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &netlist.DesiredNetworkList{
+		UniqueID:    "1001_BLOCKED",
+		Name:        "Blocked",
+		Type:        "IP",
+		Description: "blocked",
+		List:        []string{"1.1.1.1", "2.2.2.2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readDesiredState() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCollectElements(t *testing.T) {
+	dir, err := ioutil.TempDir("", "elements")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "countries")
+	if err := ioutil.WriteFile(file, []byte("FR # France\r\nXX\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := collectElements(0, []string{file}, []string{"US"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"US", "FR", "XX"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("collected %v, want %v", got, want)
+	}
+
+	var lineErrs input.LineErrors
+	if _, err := collectElements(netlist.GEO, []string{file}, nil); !errors.As(err, &lineErrs) ||
+		len(lineErrs) != 1 || lineErrs[0].Line != 2 {
+		t.Errorf("error = %v, want line 2 reported", err)
+	}
+}
+
+func TestApplyFeed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "drop.txt")
+	if err := ioutil.WriteFile(file, []byte("; Spamhaus DROP List\n1.10.16.0/20 ; SBL256894\n1.19.0.0/16 ; SBL434604\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	client := fake.New()
+	l := client.Seed(netlist.NetworkListResponse{Name: "DROP", Type: netlist.IP.String(), List: []string{"1.10.16.0/20", "5.5.5.5"}})
+
+	if err := applyFeed(ctx, client, []string{"--id", l.UniqueID, "--file", file, "--format", "drop", "--plan"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyFeed(ctx, client, []string{"--id", l.UniqueID, "--file", file, "--format", "drop"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+		OptionalParams: &netlist.OptionalParams{IncludeElements: true},
+		NetworkListID:  l.UniqueID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1.10.16.0/20", "1.19.0.0/16"}; !reflect.DeepEqual(got.List, want) {
+		t.Errorf("list holds %v, want %v", got.List, want)
+	}
+
+	if err := applyFeed(ctx, client, []string{"--id", l.UniqueID}); !errors.Is(err, errUsage) {
+		t.Errorf("error = %v, want %v", err, errUsage)
+	}
+}
+
+func TestExpireElements(t *testing.T) {
+	dir, err := ioutil.TempDir("", "expiry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "state.json")
+
+	ctx := context.Background()
+	client := fake.New()
+	l := client.Seed(netlist.NetworkListResponse{Name: "Blocked", Type: netlist.IP.String(), List: []string{"1.1.1.1"}})
+
+	if err := addElement(ctx, client, []string{"--id", l.UniqueID, "--element", "2.2.2.2", "--ttl", "1ns", "--state", state}); err != nil {
+		t.Fatal(err)
+	}
+	// 1.1.1.1 was in the list before, adding it again with a TTL must not make it expire
+	if err := addElement(ctx, client, []string{"--id", l.UniqueID, "--element", "1.1.1.1", "--ttl", "1ns", "--state", state}); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendNetworkList(ctx, client, []string{"--id", l.UniqueID, "--element", "1.1.1.1", "--element", "3.3.3.3", "--ttl", "24h", "--state", state}); err != nil {
+		t.Fatal(err)
+	}
+	if err := addElement(ctx, client, []string{"--id", l.UniqueID, "--element", "4.4.4.4", "--ttl", "-1h", "--state", state}); !errors.Is(err, errUsage) {
+		t.Errorf("negative ttl: error = %v, want %v", err, errUsage)
+	}
+
+	if err := expireElements(ctx, client, []string{"--state", state, "--plan"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := expireElements(ctx, client, []string{"--state", state}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.GetNetworkList(ctx, netlist.GetNetworkListRequest{
+		OptionalParams: &netlist.OptionalParams{IncludeElements: true},
+		NetworkListID:  l.UniqueID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1.1.1.1", "3.3.3.3"}; !reflect.DeepEqual(got.List, want) {
+		t.Errorf("list holds %v, want %v", got.List, want)
+	}
+
+	store, err := expiry.Open(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := store.Entries(); len(entries) != 1 || entries[0].Element != "3.3.3.3" {
+		t.Errorf("tracked %+v, want 3.3.3.3 only", entries)
+	}
+}
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Kinds of API errors shared by the netlist and appsec packages,
+// their errors match them with errors.Is
+var (
+	// ErrNotFound is returned when requested resource was not found
+	ErrNotFound = errors.New("resource not found")
+	// ErrConflict is returned when the resource changed concurrently, e.g. on a stale sync point
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is returned when the API rejected the request content
+	ErrValidation = errors.New("validation failed")
+	// ErrForbidden is returned when the credentials do not grant access to the resource
+	ErrForbidden = errors.New("forbidden")
+	// ErrRateLimited is returned when too many requests were sent
+	ErrRateLimited = errors.New("rate limited")
+	// ErrServerError is returned when the API failed to process the request
+	ErrServerError = errors.New("server error")
+)
+
+// problemKinds maps words of problem types to error kinds,
+// e.g. https://problems.luna.akamaiapis.net/network-lists/error-types/not-found
+var problemKinds = []struct {
+	word string
+	kind error
+}{
+	{"not-found", ErrNotFound},
+	{"conflict", ErrConflict},
+	{"sync-point", ErrConflict},
+	{"rate-limit", ErrRateLimited},
+	{"too-many-requests", ErrRateLimited},
+	{"forbidden", ErrForbidden},
+	{"unauthorized", ErrForbidden},
+	{"not-authorized", ErrForbidden},
+	{"invalid", ErrValidation},
+	{"validation", ErrValidation},
+	{"bad-request", ErrValidation},
+	{"internal-server-error", ErrServerError},
+}
+
+// ErrorKind returns the kind of an API error from its status code. The problem
+// type only refines a 400, which the API also uses for stale sync points and
+// unknown references, and classifies the statuses none of the kinds covers.
+// It returns nil when the error is of none of the kinds
+func ErrorKind(statusCode int, problemType string) error {
+	switch {
+	case statusCode == http.StatusNotFound:
+		return ErrNotFound
+	case statusCode == http.StatusConflict, statusCode == http.StatusPreconditionFailed:
+		return ErrConflict
+	case statusCode == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden:
+		return ErrForbidden
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode >= http.StatusInternalServerError:
+		return ErrServerError
+	case statusCode == http.StatusBadRequest:
+		if kind := problemKind(problemType); kind == ErrConflict || kind == ErrNotFound {
+			return kind
+		}
+		return ErrValidation
+	}
+	return problemKind(problemType)
+}
+
+// problemKind returns the kind named by the problem type, or nil
+func problemKind(problemType string) error {
+	problemType = strings.ToLower(problemType)
+	for _, k := range problemKinds {
+		if strings.Contains(problemType, k.word) {
+			return k.kind
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestErrorKind(t *testing.T) {
+	tests := map[string]struct {
+		status      int
+		problemType string
+		want        error
+	}{
+		"not found":                     {http.StatusNotFound, "", ErrNotFound},
+		"not found with another type":   {http.StatusNotFound, "https://problems.luna.akamaiapis.net/appsec/error-types/invalid-input", ErrNotFound},
+		"conflict":                      {http.StatusConflict, "https://problems.luna.akamaiapis.net/appsec/error-types/unexpected", ErrConflict},
+		"precondition failed":           {http.StatusPreconditionFailed, "", ErrConflict},
+		"unprocessable":                 {http.StatusUnprocessableEntity, "", ErrValidation},
+		"unauthorized with invalid":     {http.StatusUnauthorized, "https://problems.luna.akamaiapis.net/-/pep-authn/request-error/invalid-token", ErrForbidden},
+		"forbidden":                     {http.StatusForbidden, "", ErrForbidden},
+		"rate limited":                  {http.StatusTooManyRequests, "", ErrRateLimited},
+		"server error":                  {http.StatusServiceUnavailable, "https://problems.luna.akamaiapis.net/appsec/error-types/not-found", ErrServerError},
+		"bad request":                   {http.StatusBadRequest, "", ErrValidation},
+		"bad request with a stale sync": {http.StatusBadRequest, "https://problems.luna.akamaiapis.net/network-lists/error-types/sync-point-mismatch", ErrConflict},
+		"bad request with not found":    {http.StatusBadRequest, "https://problems.luna.akamaiapis.net/appsec/error-types/config-not-found", ErrNotFound},
+		"bad request rate limited type": {http.StatusBadRequest, "https://problems.luna.akamaiapis.net/-/rate-limit", ErrValidation},
+		"other status with a type":      {http.StatusMethodNotAllowed, "https://problems.luna.akamaiapis.net/-/too-many-requests", ErrRateLimited},
+		"other status":                  {http.StatusMethodNotAllowed, "", nil},
+		"unread response":               {0, "", nil},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := ErrorKind(test.status, test.problemType); got != test.want {
+				t.Errorf("ErrorKind(%d, %q) = %v, want %v", test.status, test.problemType, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/apex/log"
+)
+
+type (
+	// RetryPolicy configures how failed API calls are retried. Rate limited calls
+	// (429) are always safe to retry, transient server and network errors only
+	// are for idempotent methods, so a POST activation or append which may have
+	// been processed is never submitted twice
+	RetryPolicy struct {
+		// MaxRetries is the number of retries after the first attempt, 0 disables retries
+		MaxRetries int
+		// MinBackoff is the delay before the first retry, it doubles with every retry
+		MinBackoff time.Duration
+		// MaxBackoff caps the computed delay, delays asked by the API through
+		// Retry-After or X-RateLimit-Next are honored as is
+		MaxBackoff time.Duration
+	}
+
+	// ExecFunc executes a request like session.Session.Exec
+	ExecFunc func(r *http.Request, out interface{}, in ...interface{}) (*http.Response, error)
+)
+
+// DefaultRetryPolicy retries 3 times, waiting from 1 to 30 seconds
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: time.Second,
+	MaxBackoff: 30 * time.Second,
+}
+
+// Exec executes the request through exec, retrying it according to the policy.
+// The request is cloned for every attempt, its body must be given through in
+func (p RetryPolicy) Exec(logger log.Interface, exec ExecFunc, r *http.Request, out interface{}, in ...interface{}) (*http.Response, error) {
+	if p.MaxRetries <= 0 {
+		return exec(r, out, in...)
+	}
+
+	ctx := r.Context()
+	for attempt := 0; ; attempt++ {
+		entry := logger.WithFields(log.Fields{
+			"method":  r.Method,
+			"path":    r.URL.Path,
+			"attempt": attempt + 1,
+		})
+		entry.Debug("executing request")
+
+		resp, err := exec(r.Clone(ctx), out, in...)
+		retry, reason := p.retryable(r.Method, resp, err)
+		if !retry || attempt >= p.MaxRetries {
+			if retry {
+				entry.WithField("reason", reason).Warn("giving up retrying request")
+			}
+			if err == nil && !retry {
+				throttle(ctx, entry, resp)
+			}
+			return resp, err
+		}
+
+		wait := p.backoff(attempt)
+		if d, ok := RetryDelay(resp); ok {
+			wait = d
+		}
+		entry.WithFields(log.Fields{
+			"reason": reason,
+			"wait":   wait.String(),
+		}).Warn("retrying request")
+
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable tells whether the outcome of an attempt is worth retrying and why
+func (p RetryPolicy) retryable(method string, resp *http.Response, err error) (bool, string) {
+	if err != nil {
+		var urlErr *url.Error
+		if !errors.As(err, &urlErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false, ""
+		}
+		return IsIdempotent(method), err.Error()
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true, resp.Status
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return IsIdempotent(method), resp.Status
+	}
+	return false, ""
+}
+
+// backoff returns the exponential delay before the given retry, with jitter
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	min, max := p.MinBackoff, p.MaxBackoff
+	if min <= 0 {
+		min = DefaultRetryPolicy.MinBackoff
+	}
+	if max < min {
+		max = min
+	}
+
+	d := min
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	// half of the delay is kept, the other half is random
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// IsIdempotent reports whether a request with the given method can be
+// submitted twice without side effects
+func IsIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// RetryDelay returns the delay asked by the API before the next request,
+// from the Retry-After header (seconds or HTTP date) or the X-RateLimit-Next
+// header (RFC 3339 timestamp)
+func RetryDelay(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return nonNegative(time.Until(t)), true
+		}
+	}
+	if v := resp.Header.Get("X-RateLimit-Next"); v != "" {
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return nonNegative(time.Until(t)), true
+		}
+	}
+	return 0, false
+}
+
+// throttle waits for the rate limit window to reset once the API reported
+// that no request is left in it, so the next call is not rejected
+func throttle(ctx context.Context, logger log.Interface, resp *http.Response) {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+	wait, ok := RetryDelay(resp)
+	if !ok || wait <= 0 {
+		return
+	}
+	logger.WithField("wait", wait.String()).Info("rate limit reached, waiting")
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
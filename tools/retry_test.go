@@ -0,0 +1,258 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// attempt is the outcome of one call of a stub ExecFunc
+type attempt struct {
+	status int
+	header http.Header
+	err    error
+}
+
+// stubExec answers the calls with the given attempts in turn, repeating the last one
+func stubExec(attempts []attempt, calls *int) ExecFunc {
+	return func(r *http.Request, out interface{}, in ...interface{}) (*http.Response, error) {
+		a := attempts[len(attempts)-1]
+		if *calls < len(attempts) {
+			a = attempts[*calls]
+		}
+		*calls++
+		if a.err != nil {
+			return nil, a.err
+		}
+		header := a.header
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			StatusCode: a.status,
+			Status:     http.StatusText(a.status),
+			Header:     header,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Request:    r,
+		}, nil
+	}
+}
+
+func TestRetryPolicyExec(t *testing.T) {
+	netErr := &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("connection reset")}
+	policy := RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := map[string]struct {
+		method   string
+		attempts []attempt
+		calls    int
+		status   int
+		err      error
+	}{
+		"success":                       {method: http.MethodGet, attempts: []attempt{{status: 200}}, calls: 1, status: 200},
+		"GET retried on 503":            {method: http.MethodGet, attempts: []attempt{{status: 503}, {status: 200}}, calls: 2, status: 200},
+		"GET retries exhausted":         {method: http.MethodGet, attempts: []attempt{{status: 502}}, calls: 4, status: 502},
+		"GET retried on url.Error":      {method: http.MethodGet, attempts: []attempt{{err: netErr}, {status: 200}}, calls: 2, status: 200},
+		"PUT retried on 500":            {method: http.MethodPut, attempts: []attempt{{status: 500}, {status: 204}}, calls: 2, status: 204},
+		"GET 429 retried":               {method: http.MethodGet, attempts: []attempt{{status: 429}, {status: 200}}, calls: 2, status: 200},
+		"POST 429 retried":              {method: http.MethodPost, attempts: []attempt{{status: 429}, {status: 429}, {status: 201}}, calls: 3, status: 201},
+		"POST not retried on 500":       {method: http.MethodPost, attempts: []attempt{{status: 500}}, calls: 1, status: 500},
+		"POST not retried on 502":       {method: http.MethodPost, attempts: []attempt{{status: 502}}, calls: 1, status: 502},
+		"POST not retried on 503":       {method: http.MethodPost, attempts: []attempt{{status: 503}}, calls: 1, status: 503},
+		"POST not retried on 504":       {method: http.MethodPost, attempts: []attempt{{status: 504}}, calls: 1, status: 504},
+		"POST not retried on url.Error": {method: http.MethodPost, attempts: []attempt{{err: netErr}, {status: 200}}, calls: 1, err: netErr},
+		"client errors not retried":     {method: http.MethodGet, attempts: []attempt{{status: 400}}, calls: 1, status: 400},
+		"cancellation not retried": {
+			method:   http.MethodGet,
+			attempts: []attempt{{err: &url.Error{Op: "Get", URL: "https://example.com", Err: context.Canceled}}},
+			calls:    1,
+			err:      context.Canceled,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, "https://example.com/network-list/v2/network-lists", nil)
+			calls := 0
+
+			resp, err := policy.Exec(log.Log, stubExec(test.attempts, &calls), req, nil)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("error = %v, want %v", err, test.err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if resp.StatusCode != test.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, test.status)
+			}
+			if calls != test.calls {
+				t.Errorf("%d attempts, want %d", calls, test.calls)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyExecDelay(t *testing.T) {
+	// the backoff alone would outlast the test, the delays asked by the API are used instead
+	policy := RetryPolicy{MaxRetries: 1, MinBackoff: time.Hour, MaxBackoff: time.Hour}
+
+	tests := map[string]http.Header{
+		"Retry-After seconds":   {"Retry-After": []string{"0"}},
+		"Retry-After HTTP date": {"Retry-After": []string{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)}},
+		"X-RateLimit-Next":      {"X-Ratelimit-Next": []string{time.Now().Add(-time.Minute).Format(time.RFC3339Nano)}},
+	}
+
+	for name, header := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			req := httptest.NewRequest(http.MethodPost, "https://example.com/appsec/v1/activations", nil).WithContext(ctx)
+			calls := 0
+
+			resp, err := policy.Exec(log.Log, stubExec([]attempt{{status: 429, header: header}, {status: 200}}, &calls), req, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != 200 || calls != 2 {
+				t.Errorf("status %d after %d attempts, want 200 after 2", resp.StatusCode, calls)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		header http.Header
+		min    time.Duration
+		max    time.Duration
+		ok     bool
+	}{
+		"none":                  {},
+		"Retry-After seconds":   {header: http.Header{"Retry-After": []string{"7"}}, min: 7 * time.Second, max: 7 * time.Second, ok: true},
+		"Retry-After HTTP date": {header: http.Header{"Retry-After": []string{now.Add(10 * time.Second).UTC().Format(http.TimeFormat)}}, min: 8 * time.Second, max: 10 * time.Second, ok: true},
+		"Retry-After past date": {header: http.Header{"Retry-After": []string{now.Add(-time.Hour).UTC().Format(http.TimeFormat)}}, ok: true},
+		"Retry-After invalid":   {header: http.Header{"Retry-After": []string{"soon"}}},
+		"X-RateLimit-Next":      {header: http.Header{"X-Ratelimit-Next": []string{now.Add(5 * time.Second).Format(time.RFC3339Nano)}}, min: 4 * time.Second, max: 5 * time.Second, ok: true},
+		"Retry-After first": {
+			header: http.Header{"Retry-After": []string{"2"}, "X-Ratelimit-Next": []string{now.Add(time.Hour).Format(time.RFC3339Nano)}},
+			min:    2 * time.Second,
+			max:    2 * time.Second,
+			ok:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, ok := RetryDelay(&http.Response{Header: test.header})
+			if ok != test.ok {
+				t.Fatalf("RetryDelay() ok = %t, want %t", ok, test.ok)
+			}
+			if d < test.min || d > test.max {
+				t.Errorf("RetryDelay() = %s, want between %s and %s", d, test.min, test.max)
+			}
+		})
+	}
+
+	if _, ok := RetryDelay(nil); ok {
+		t.Error("RetryDelay(nil) ok = true, want false")
+	}
+}
+
+func TestRetryPolicyExecCancelled(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, MinBackoff: time.Hour, MaxBackoff: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/appsec/v1/configs", nil).WithContext(ctx)
+
+	calls := 0
+	exec := stubExec([]attempt{{status: 503}}, &calls)
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	resp, err := policy.Exec(log.Log, exec, req, nil)
+	if !errors.Is(err, context.Canceled) || resp != nil {
+		t.Errorf("Exec() = %v, %v, want nil, %v", resp, err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Errorf("%d attempts, want 1", calls)
+	}
+}
+
+func TestRetryPolicyExecThrottle(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond}
+	header := http.Header{
+		"X-Ratelimit-Remaining": []string{"0"},
+		"X-Ratelimit-Next":      []string{time.Now().Add(50 * time.Millisecond).Format(time.RFC3339Nano)},
+	}
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/network-list/v2/network-lists", nil)
+
+	calls := 0
+	start := time.Now()
+	resp, err := policy.Exec(log.Log, stubExec([]attempt{{status: 201, header: header}}, &calls), req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 201 || calls != 1 {
+		t.Errorf("status %d after %d attempts, want 201 after 1", resp.StatusCode, calls)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("returned after %s, want the rate limit window to be waited for", elapsed)
+	}
+}
+
+func TestRetryPolicyExecBody(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// exec encodes the body for every attempt like session.Session.Exec
+	exec := func(r *http.Request, out interface{}, in ...interface{}) (*http.Response, error) {
+		if len(in) > 0 {
+			data, err := json.Marshal(in[0])
+			if err != nil {
+				return nil, err
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(data))
+			r.ContentLength = int64(len(data))
+		}
+		return srv.Client().Do(r)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, srv.URL+"/network-list/v2/network-lists/1_A", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	resp, err := policy.Exec(log.Log, exec, req, nil, map[string]string{"name": "Blocked"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if len(bodies) != 3 {
+		t.Fatalf("%d attempts, want 3", len(bodies))
+	}
+	for i, body := range bodies {
+		if body != `{"name":"Blocked"}` {
+			t.Errorf("attempt %d sent %q", i+1, body)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Package tools holds the account switching, retry and error kind helpers
+// shared by the netlist and appsec clients
+package tools
+
+import (
+	"context"
+	"net/http"
+)
+
+type accountSwitchKeyContext struct{}
+
+// CheckAccountID adds the accountSwitchKey query parameter to the request
+// when accountID is a non empty string
+func CheckAccountID(accountID interface{}, req *http.Request) {
+	id, ok := accountID.(string)
+	if !ok || id == "" {
+		return
+	}
+	q := req.URL.Query()
+	q.Set("accountSwitchKey", id)
+	req.URL.RawQuery = q.Encode()
+}
+
+// ContextWithAccountSwitchKey returns a context overriding the account switch key
+// of the clients for the calls made with it, an empty key disables account switching
+func ContextWithAccountSwitchKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, accountSwitchKeyContext{}, key)
+}
+
+// AccountSwitchKey returns the account switch key to use for the request,
+// the one of its context when set, otherwise the client one
+func AccountSwitchKey(req *http.Request, clientKey string) string {
+	if key, ok := req.Context().Value(accountSwitchKeyContext{}).(string); ok {
+		return key
+	}
+	return clientKey
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAccountSwitchKey(t *testing.T) {
+	tests := map[string]struct {
+		url       string
+		clientKey string
+		ctxKey    *string
+		want      string
+	}{
+		"no key":              {url: "/network-list/v2/network-lists", want: "/network-list/v2/network-lists"},
+		"client key":          {url: "/network-list/v2/network-lists?extended=true", clientKey: "1-A", want: "/network-list/v2/network-lists?accountSwitchKey=1-A&extended=true"},
+		"context key":         {url: "/appsec/v1/configs", clientKey: "1-A", ctxKey: strPtr("1-B"), want: "/appsec/v1/configs?accountSwitchKey=1-B"},
+		"context disables it": {url: "/appsec/v1/configs", clientKey: "1-A", ctxKey: strPtr(""), want: "/appsec/v1/configs"},
+		"key replaced":        {url: "/appsec/v1/configs?accountSwitchKey=1-OLD", clientKey: "1-A", want: "/appsec/v1/configs?accountSwitchKey=1-A"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", test.url, nil)
+			if test.ctxKey != nil {
+				req = req.WithContext(ContextWithAccountSwitchKey(context.Background(), *test.ctxKey))
+			}
+
+			CheckAccountID(AccountSwitchKey(req, test.clientKey), req)
+			if got := req.URL.RequestURI(); got != test.want {
+				t.Errorf("URL = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}